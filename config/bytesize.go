@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ByteSize — размер в байтах. Из окружения принимает число байт или число с суффиксом:
+// KB/MB/GB (степени 1000) и KiB/MiB/GiB (степени 1024), например "256MiB".
+type ByteSize int64
+
+// byteUnits — суффиксы ByteSize; двоичные раньше десятичных, чтобы "MiB" не разобрался как "B".
+var byteUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+	{"B", 1},
+}
+
+// Decode — разбор значения из окружения (envconfig.Decoder).
+func (b *ByteSize) Decode(value string) error {
+	v := strings.TrimSpace(value)
+	factor := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(strings.ToUpper(v), strings.ToUpper(u.suffix)) {
+			v, factor = strings.TrimSpace(v[:len(v)-len(u.suffix)]), u.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 || n > (1<<63-1)/factor {
+		return fmt.Errorf("invalid byte size %q", value)
+	}
+	*b = ByteSize(n * factor)
+	return nil
+}
@@ -20,11 +20,23 @@ type HTTP struct {
 	IdleTimeout       time.Duration `default:"60s" envconfig:"IDLE_TIMEOUT"`
 	HandlerTimeout    time.Duration `default:"3s"  envconfig:"HANDLER_TIMEOUT"`
 	GracefulTimeout   time.Duration `default:"5s"  envconfig:"GRACEFUL_TIMEOUT"`
+
+	// ShutdownDelay — пауза между переходом /readyz в 503 и остановкой сервера: балансировщик успевает
+	// убрать реплику из ротации, пока она ещё отвечает (0 — останавливаться сразу).
+	ShutdownDelay time.Duration `default:"0s" envconfig:"SHUTDOWN_DELAY"`
+
+	// AdminToken — Bearer-токен для /admin/cache (пусто — админка выключена).
+	AdminToken string `default:"" envconfig:"ADMIN_TOKEN"`
 }
 
 // Metrics — конфигурация метрик (Prometheus).
 type Metrics struct {
 	Addr string `default:":2112" envconfig:"ADDR"`
+
+	// Границы гистограмм через запятую (пусто — по умолчанию): DurationBuckets — секунды
+	// (HTTP, обработка Kafka, SQL), SizeBuckets — байты (размер HTTP-ответа).
+	DurationBuckets []float64 `default:"" envconfig:"DURATION_BUCKETS"`
+	SizeBuckets     []float64 `default:"" envconfig:"SIZE_BUCKETS"`
 }
 
 // TracingConfig — конфигурация OpenTelemetry.
@@ -51,13 +63,64 @@ type Kafka struct {
 	ProcessTimeout time.Duration `default:"5s" envconfig:"PROCESS_TIMEOUT"` // время ожидания обработки сообщения
 	RetryInitial   time.Duration `default:"1s" envconfig:"RETRY_INITIAL"`   // начальное время повтора
 	RetryMax       time.Duration `default:"30s" envconfig:"RETRY_MAX"`      // максимальное время повтора
+
+	DeadLetterTopic string `default:"" envconfig:"DLQ_TOPIC"`     // топик для невалидных сообщений (пусто — отключено)
+	MaxAttempts     int    `default:"5" envconfig:"MAX_ATTEMPTS"` // попыток до parking (0 — без ограничения; без parking/DLQ — повторы до успеха)
+	ParkingTopic    string `default:"" envconfig:"PARKING_TOPIC"` // топик для исчерпавших попытки (пусто — DLQ_TOPIC)
+
+	BatchSize   int           `default:"1" envconfig:"BATCH_SIZE"`       // сообщений в пакете (1 — обработка по одному)
+	BatchLinger time.Duration `default:"100ms" envconfig:"BATCH_LINGER"` // ожидание добора пакета
+
+	WorkerCount int `default:"1" envconfig:"WORKER_COUNT"` // воркеров обработки (1 — последовательно)
+
+	// ReadyMaxIdle — /readyz не готов, если консьюмер не читал сообщений дольше этого времени
+	// (0 — не проверять: на топике без трафика FetchMessage просто ждёт).
+	ReadyMaxIdle time.Duration `default:"0s" envconfig:"READY_MAX_IDLE"`
 }
 
-// Cache — конфигурация кэша в памяти.
+// Outbox — конфигурация публикации событий outbox в Kafka (брокеры — из Kafka).
+type Outbox struct {
+	// Выключено по умолчанию: перед включением нужны миграции outbox и созданный топик Topic
+	// (без них сохранение заказов падает, а relay бесконечно ретраит публикацию).
+	Enabled      bool          `default:"false" envconfig:"ENABLED"`
+	Topic        string        `default:"orders-events" envconfig:"TOPIC"`
+	PollInterval time.Duration `default:"1s" envconfig:"POLL_INTERVAL"` // пауза опроса, если событий нет
+	BatchSize    int           `default:"100" envconfig:"BATCH_SIZE"`   // событий за одну публикацию
+	Lease        time.Duration `default:"30s" envconfig:"LEASE"`        // аренда захваченной пачки (другие реплики её не берут)
+	Retention    time.Duration `default:"168h" envconfig:"RETENTION"`   // хранение отправленных событий (0 — не удалять)
+}
+
+// Cache — конфигурация кэша заказов.
 type Cache struct {
-	Capacity int           `default:"1000" envconfig:"CAPACITY"`
+	Backend  string        `default:"memory" envconfig:"BACKEND"` // memory | redis | tiered (L1 memory + L2 redis)
+	Capacity int           `default:"1000" envconfig:"CAPACITY"`  // элементов in-memory уровня (0 — без лимита при MAX_BYTES); redis — maxmemory
+	Shards   int           `default:"1" envconfig:"SHARDS"`       // шардов in-memory кэша (>1 — ShardedLRUCache)
+	Policy   string        `default:"lru" envconfig:"POLICY"`     // вытеснение in-memory уровня: lru | lfu | wtinylfu
+	MaxBytes ByteSize      `default:"0" envconfig:"MAX_BYTES"`    // бюджет памяти in-memory уровня (0 — без бюджета)
 	TTL      time.Duration `default:"10m" envconfig:"TTL"`
 	WarmUpN  int           `default:"0" envconfig:"WARM_UP_N"`
+
+	// NegativeTTL — сколько помнить, что заказа нет (0 — не кэшировать промахи БД).
+	NegativeTTL time.Duration `default:"30s" envconfig:"NEGATIVE_TTL"`
+
+	// Invalidate — сбрасывать локальные копии по LISTEN/NOTIFY при изменении заказа (memory и tiered).
+	// Нужно только при нескольких репликах: держит отдельное соединение с БД, а реплика получает
+	// и свои уведомления — запись, только что сохранённая в кэш, сбрасывается.
+	Invalidate bool `default:"false" envconfig:"INVALIDATE"`
+
+	// JanitorInterval — период фоновой очистки истёкших записей in-memory уровня (0 — выключена);
+	// JanitorBudget — время на один обход (очистка идёт порциями, между ними кэш доступен).
+	JanitorInterval time.Duration `default:"0" envconfig:"JANITOR_INTERVAL"`
+	JanitorBudget   time.Duration `default:"5ms" envconfig:"JANITOR_BUDGET"`
+
+	// SnapshotPath — файл снимка in-memory кэша (backend memory): пишется при остановке,
+	// читается при запуске вместо прогрева WARM_UP_N (пусто — выключено).
+	SnapshotPath string `default:"" envconfig:"SNAPSHOT_PATH"`
+
+	RedisAddr      string `default:"redis:6379" envconfig:"REDIS_ADDR"`
+	RedisPassword  string `default:"" envconfig:"REDIS_PASSWORD"`
+	RedisDB        int    `default:"0" envconfig:"REDIS_DB"`
+	RedisKeyPrefix string `default:"order:" envconfig:"REDIS_KEY_PREFIX"` // пространство имён ключей
 }
 
 // Logger — конфигурация логгера.
@@ -72,6 +135,7 @@ type Config struct {
 	Tracing  TracingConfig
 	Postgres Postgres
 	Kafka    Kafka
+	Outbox   Outbox
 	Cache    Cache
 	Logger   Logger
 }
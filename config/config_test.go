@@ -35,11 +35,20 @@ func TestLoadWithPrefix_Defaults(t *testing.T) {
 	if c.HTTP.HandlerTimeout != 3*time.Second {
 		t.Fatalf("HTTP.HandlerTimeout: want 3s, got %v", c.HTTP.HandlerTimeout)
 	}
+	if c.HTTP.AdminToken != "" {
+		t.Fatalf("HTTP.AdminToken must be empty by default (admin disabled)")
+	}
+	if c.HTTP.ShutdownDelay != 0 || c.Kafka.ReadyMaxIdle != 0 {
+		t.Fatalf("readiness settings must be disabled by default: delay=%v idle=%v", c.HTTP.ShutdownDelay, c.Kafka.ReadyMaxIdle)
+	}
 
 	// Metrics
 	if c.Metrics.Addr != ":2112" {
 		t.Fatalf("Metrics.Addr: want :2112, got %q", c.Metrics.Addr)
 	}
+	if len(c.Metrics.DurationBuckets) != 0 || len(c.Metrics.SizeBuckets) != 0 {
+		t.Fatalf("Metrics buckets must be empty by default: %+v", c.Metrics)
+	}
 
 	// Tracing
 	if c.Tracing.Enabled {
@@ -67,11 +76,28 @@ func TestLoadWithPrefix_Defaults(t *testing.T) {
 	if c.Kafka.ProcessTimeout != 5*time.Second || c.Kafka.RetryInitial != 1*time.Second || c.Kafka.RetryMax != 30*time.Second {
 		t.Fatalf("Kafka timeouts wrong: %+v", c.Kafka)
 	}
+	if c.Kafka.DeadLetterTopic != "" || c.Kafka.ParkingTopic != "" || c.Kafka.MaxAttempts != 5 {
+		t.Fatalf("Kafka DLQ/parking defaults wrong: %+v", c.Kafka)
+	}
+	if c.Kafka.BatchSize != 1 || c.Kafka.BatchLinger != 100*time.Millisecond || c.Kafka.WorkerCount != 1 {
+		t.Fatalf("Kafka batch/worker defaults wrong: %+v", c.Kafka)
+	}
+
+	// Outbox
+	if c.Outbox.Enabled || c.Outbox.Topic != "orders-events" || c.Outbox.PollInterval != time.Second || c.Outbox.BatchSize != 100 ||
+		c.Outbox.Lease != 30*time.Second || c.Outbox.Retention != 168*time.Hour {
+		t.Fatalf("Outbox defaults wrong: %+v", c.Outbox)
+	}
 
 	// Cache
-	if c.Cache.Capacity != 1000 || c.Cache.TTL != 10*time.Minute {
+	if c.Cache.Capacity != 1000 || c.Cache.TTL != 10*time.Minute || c.Cache.Backend != "memory" || c.Cache.NegativeTTL != 30*time.Second ||
+		c.Cache.Shards != 1 || c.Cache.MaxBytes != 0 || c.Cache.Policy != "lru" || c.Cache.SnapshotPath != "" ||
+		c.Cache.JanitorInterval != 0 || c.Cache.JanitorBudget != 5*time.Millisecond {
 		t.Fatalf("Cache defaults wrong: %+v", c.Cache)
 	}
+	if c.Cache.RedisAddr != "redis:6379" || c.Cache.RedisDB != 0 || c.Cache.RedisKeyPrefix != "order:" || c.Cache.Invalidate {
+		t.Fatalf("Cache redis defaults wrong: %+v", c.Cache)
+	}
 
 	// Logger
 	if c.Logger.IsProd {
@@ -91,9 +117,13 @@ func TestLoadWithPrefix_Overrides(t *testing.T) {
 	t.Setenv(p+"_HTTP_READ_HEADER_TIMEOUT", "1s")
 	t.Setenv(p+"_HTTP_IDLE_TIMEOUT", "15s")
 	t.Setenv(p+"_HTTP_HANDLER_TIMEOUT", "4500ms")
+	t.Setenv(p+"_HTTP_ADMIN_TOKEN", "admin-secret")
+	t.Setenv(p+"_HTTP_SHUTDOWN_DELAY", "3s")
 
 	// Metrics
 	t.Setenv(p+"_METRICS_ADDR", ":9998")
+	t.Setenv(p+"_METRICS_DURATION_BUCKETS", "0.01,0.1,1")
+	t.Setenv(p+"_METRICS_SIZE_BUCKETS", "1024,65536")
 
 	// Tracing
 	t.Setenv(p+"_TRACING_OTEL_ENABLED", "true")
@@ -113,10 +143,38 @@ func TestLoadWithPrefix_Overrides(t *testing.T) {
 	t.Setenv(p+"_KAFKA_PROCESS_TIMEOUT", "7s")
 	t.Setenv(p+"_KAFKA_RETRY_INITIAL", "250ms")
 	t.Setenv(p+"_KAFKA_RETRY_MAX", "2m")
+	t.Setenv(p+"_KAFKA_DLQ_TOPIC", "orders-dlq")
+	t.Setenv(p+"_KAFKA_MAX_ATTEMPTS", "3")
+	t.Setenv(p+"_KAFKA_PARKING_TOPIC", "orders-parking")
+	t.Setenv(p+"_KAFKA_BATCH_SIZE", "50")
+	t.Setenv(p+"_KAFKA_BATCH_LINGER", "250ms")
+	t.Setenv(p+"_KAFKA_WORKER_COUNT", "8")
+	t.Setenv(p+"_KAFKA_READY_MAX_IDLE", "10m")
+
+	// Outbox
+	t.Setenv(p+"_OUTBOX_ENABLED", "true")
+	t.Setenv(p+"_OUTBOX_TOPIC", "events-test")
+	t.Setenv(p+"_OUTBOX_POLL_INTERVAL", "5s")
+	t.Setenv(p+"_OUTBOX_BATCH_SIZE", "10")
+	t.Setenv(p+"_OUTBOX_LEASE", "1m")
+	t.Setenv(p+"_OUTBOX_RETENTION", "0s")
 
 	// Cache
 	t.Setenv(p+"_CACHE_CAPACITY", "777")
+	t.Setenv(p+"_CACHE_SHARDS", "16")
+	t.Setenv(p+"_CACHE_MAX_BYTES", "64MiB")
+	t.Setenv(p+"_CACHE_POLICY", "wtinylfu")
+	t.Setenv(p+"_CACHE_SNAPSHOT_PATH", "/var/lib/orders/cache.snapshot")
+	t.Setenv(p+"_CACHE_JANITOR_INTERVAL", "30s")
+	t.Setenv(p+"_CACHE_JANITOR_BUDGET", "2ms")
 	t.Setenv(p+"_CACHE_TTL", "30m")
+	t.Setenv(p+"_CACHE_NEGATIVE_TTL", "5s")
+	t.Setenv(p+"_CACHE_BACKEND", "tiered")
+	t.Setenv(p+"_CACHE_INVALIDATE", "true")
+	t.Setenv(p+"_CACHE_REDIS_ADDR", "cache:6380")
+	t.Setenv(p+"_CACHE_REDIS_PASSWORD", "secret")
+	t.Setenv(p+"_CACHE_REDIS_DB", "2")
+	t.Setenv(p+"_CACHE_REDIS_KEY_PREFIX", "o:")
 
 	// Logger
 	t.Setenv(p+"_LOGGER_IS_PROD", "true")
@@ -127,16 +185,17 @@ func TestLoadWithPrefix_Overrides(t *testing.T) {
 	}
 
 	// Проверки
-	if c.HTTP.Addr != ":9999" || c.HTTP.GinMode != "release" {
+	if c.HTTP.Addr != ":9999" || c.HTTP.GinMode != "release" || c.HTTP.AdminToken != "admin-secret" {
 		t.Fatalf("HTTP overrides wrong: %+v", c.HTTP)
 	}
 	if c.HTTP.ReadTimeout != 2*time.Second || c.HTTP.WriteTimeout != 3*time.Second ||
 		c.HTTP.ReadHeaderTimeout != 1*time.Second || c.HTTP.IdleTimeout != 15*time.Second ||
-		c.HTTP.HandlerTimeout != 4500*time.Millisecond {
+		c.HTTP.HandlerTimeout != 4500*time.Millisecond || c.HTTP.ShutdownDelay != 3*time.Second {
 		t.Fatalf("HTTP timeouts override wrong: %+v", c.HTTP)
 	}
-	if c.Metrics.Addr != ":9998" {
-		t.Fatalf("Metrics.Addr override wrong: %q", c.Metrics.Addr)
+	if c.Metrics.Addr != ":9998" || !slices.Equal(c.Metrics.DurationBuckets, []float64{0.01, 0.1, 1}) ||
+		!slices.Equal(c.Metrics.SizeBuckets, []float64{1024, 65536}) {
+		t.Fatalf("Metrics overrides wrong: %+v", c.Metrics)
 	}
 	if !c.Tracing.Enabled || c.Tracing.ServiceName != "svc" || c.Tracing.Endpoint != "collector:4318" || c.Tracing.SampleRatio != 0.25 {
 		t.Fatalf("Tracing overrides wrong: %+v", c.Tracing)
@@ -151,9 +210,26 @@ func TestLoadWithPrefix_Overrides(t *testing.T) {
 	if c.Kafka.ProcessTimeout != 7*time.Second || c.Kafka.RetryInitial != 250*time.Millisecond || c.Kafka.RetryMax != 2*time.Minute {
 		t.Fatalf("Kafka timeouts override wrong: %+v", c.Kafka)
 	}
-	if c.Cache.Capacity != 777 || c.Cache.TTL != 30*time.Minute {
+	if c.Kafka.DeadLetterTopic != "orders-dlq" || c.Kafka.MaxAttempts != 3 || c.Kafka.ParkingTopic != "orders-parking" {
+		t.Fatalf("Kafka DLQ/parking overrides wrong: %+v", c.Kafka)
+	}
+	if c.Kafka.BatchSize != 50 || c.Kafka.BatchLinger != 250*time.Millisecond || c.Kafka.WorkerCount != 8 ||
+		c.Kafka.ReadyMaxIdle != 10*time.Minute {
+		t.Fatalf("Kafka batch/worker overrides wrong: %+v", c.Kafka)
+	}
+	if !c.Outbox.Enabled || c.Outbox.Topic != "events-test" || c.Outbox.PollInterval != 5*time.Second || c.Outbox.BatchSize != 10 ||
+		c.Outbox.Lease != time.Minute || c.Outbox.Retention != 0 {
+		t.Fatalf("Outbox overrides wrong: %+v", c.Outbox)
+	}
+	if c.Cache.Capacity != 777 || c.Cache.TTL != 30*time.Minute || c.Cache.Backend != "tiered" || !c.Cache.Invalidate ||
+		c.Cache.NegativeTTL != 5*time.Second || c.Cache.Shards != 16 || c.Cache.MaxBytes != 64<<20 ||
+		c.Cache.Policy != "wtinylfu" || c.Cache.SnapshotPath != "/var/lib/orders/cache.snapshot" ||
+		c.Cache.JanitorInterval != 30*time.Second || c.Cache.JanitorBudget != 2*time.Millisecond {
 		t.Fatalf("Cache overrides wrong: %+v", c.Cache)
 	}
+	if c.Cache.RedisAddr != "cache:6380" || c.Cache.RedisPassword != "secret" || c.Cache.RedisDB != 2 || c.Cache.RedisKeyPrefix != "o:" {
+		t.Fatalf("Cache redis overrides wrong: %+v", c.Cache)
+	}
 	if !c.Logger.IsProd {
 		t.Fatalf("Logger.IsProd override wrong: %+v", c.Logger)
 	}
@@ -168,3 +244,33 @@ func TestLoadWithPrefix_InvalidValue_ReturnsError(t *testing.T) {
 		t.Fatalf("expected error for invalid duration, got nil")
 	}
 }
+
+// Размер памяти: число байт или суффиксы KB/MB/GB и KiB/MiB/GiB.
+func TestByteSize_Decode(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]cfg.ByteSize{
+		"0":       0,
+		"1024":    1024,
+		"512B":    512,
+		"64KB":    64_000,
+		"64kib":   64 << 10,
+		"256MiB":  256 << 20,
+		" 2 GB ":  2_000_000_000,
+		"1GiB":    1 << 30,
+		"100 MiB": 100 << 20,
+	}
+	for in, want := range cases {
+		var b cfg.ByteSize
+		if err := b.Decode(in); err != nil || b != want {
+			t.Fatalf("Decode(%q) = %d, %v; want %d", in, b, err, want)
+		}
+	}
+
+	for _, in := range []string{"", "MiB", "-1", "1.5GiB", "10TB", "9999999999GiB"} {
+		var b cfg.ByteSize
+		if err := b.Decode(in); err == nil {
+			t.Fatalf("Decode(%q): want error, got %d", in, b)
+		}
+	}
+}
@@ -3,17 +3,23 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Gunvolt24/wb_l0/config"
 	cachemem "github.com/Gunvolt24/wb_l0/internal/cache/memory"
+	cacheredis "github.com/Gunvolt24/wb_l0/internal/cache/redis"
+	"github.com/Gunvolt24/wb_l0/internal/cache/tiered"
 	"github.com/Gunvolt24/wb_l0/internal/kafka"
 	"github.com/Gunvolt24/wb_l0/internal/ports"
 	"github.com/Gunvolt24/wb_l0/internal/repo/postgres"
 	rest "github.com/Gunvolt24/wb_l0/internal/transport/http"
 	"github.com/Gunvolt24/wb_l0/internal/usecase"
+	"github.com/Gunvolt24/wb_l0/pkg/health"
 	"github.com/Gunvolt24/wb_l0/pkg/logger"
 	"github.com/Gunvolt24/wb_l0/pkg/metrics"
 	"github.com/Gunvolt24/wb_l0/pkg/telemetry"
@@ -23,10 +29,22 @@ import (
 
 // App — собранное приложение и его внешние интерфейсы (HTTP, consumer).
 type App struct {
-	Logger          ports.Logger          // логгер
-	HTTPServer      *http.Server          // HTTP-сервер
-	KafkaConsumer   ports.MessageConsumer // консьюмер сообщений
-	gracefulTimeout time.Duration         // время ожидания завершения HTTP-сервера
+	Logger          ports.Logger             // логгер
+	HTTPServer      *http.Server             // HTTP-сервер публичного API
+	OpsServer       *http.Server             // служебный HTTP-сервер: метрики, пробы, pprof, админка (nil — отключён)
+	KafkaConsumer   ports.MessageConsumer    // консьюмер сообщений
+	OutboxRelay     *usecase.OutboxRelay     // публикация событий outbox (nil — отключено)
+	CacheListener   *postgres.ChangeListener // сброс локального кэша по LISTEN/NOTIFY (nil — отключено)
+	CacheJanitor    *cachemem.Janitor        // фоновая очистка истёкших записей кэша (nil — отключено)
+	Health          *health.Checker          // проверки /readyz: при остановке переводится в draining (nil — нет)
+	gracefulTimeout time.Duration            // время ожидания завершения HTTP-сервера
+	shutdownDelay   time.Duration            // пауза между draining и остановкой HTTP-сервера
+
+	// WarmUpCache — стартовый прогрев кэша, выполняется в фоне (nil — не нужен).
+	WarmUpCache func(ctx context.Context) error
+
+	// SaveCacheSnapshot — запись снимка кэша при остановке (nil — отключено).
+	SaveCacheSnapshot func(ctx context.Context) error
 }
 
 // Cleanup — функция освобождения ресурсов.
@@ -48,6 +66,104 @@ func applyGinMode(ctx context.Context, mode string, log ports.Logger) {
 	}
 }
 
+// newLocalCache — in-memory кэш (backend memory и L1 у tiered): один кэш или Shards независимых шардов
+// с политикой вытеснения Policy.
+func newLocalCache(cfg *config.Cache) (tiered.Local, error) {
+	policy, err := cachemem.ParsePolicy(cfg.Policy)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Shards > 1 {
+		return cachemem.NewShardedLRUCache(cfg.Shards, policy, cfg.Capacity, int64(cfg.MaxBytes), cfg.TTL, cfg.NegativeTTL), nil
+	}
+	return cachemem.NewCacheWithPolicy(policy, cfg.Capacity, int64(cfg.MaxBytes), cfg.TTL, cfg.NegativeTTL), nil
+}
+
+// cacheSnapshotter — кэш, для которого включены снимки (nil — путь не задан или backend не in-memory:
+// у redis/tiered общий уровень и так переживает перезапуск).
+func cacheSnapshotter(ctx context.Context, cfg *config.Cache, cache ports.OrderCache, log ports.Logger) cachemem.Snapshotter {
+	if cfg.SnapshotPath == "" {
+		return nil
+	}
+	snapshot, ok := cache.(cachemem.Snapshotter)
+	if !ok {
+		log.Warnf(ctx, "cache snapshot is supported only for backend=memory, ORDER_CACHE_SNAPSHOT_PATH ignored")
+		return nil
+	}
+	return snapshot
+}
+
+// restoreCacheSnapshot — загрузка снимка кэша; возвращает число восстановленных записей.
+// Отсутствующий или устаревший (другая схема заказа) снимок — не ошибка: кэш стартует как обычно.
+func restoreCacheSnapshot(ctx context.Context, path string, snapshot cachemem.Snapshotter, log ports.Logger) int {
+	if snapshot == nil {
+		return 0
+	}
+	n, err := cachemem.LoadSnapshot(path, snapshot)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		log.Infof(ctx, "no cache snapshot at %s", path)
+	case errors.Is(err, cachemem.ErrSnapshotStale):
+		log.Warnf(ctx, "cache snapshot %s ignored: %v", path, err)
+	case err != nil:
+		log.Warnf(ctx, "cache snapshot restore failed: %v", err)
+	default:
+		log.Infof(ctx, "cache snapshot restored: %d entries from %s", n, path)
+	}
+	return n
+}
+
+// errWarmUpInProgress — стартовый прогрев кэша ещё идёт.
+var errWarmUpInProgress = errors.New("cache warm-up in progress")
+
+// consumerReadyCheck — проверка консьюмера: Run запущен и (при maxIdle > 0) последнее успешное чтение
+// было не раньше maxIdle назад.
+func consumerReadyCheck(consumer *kafka.Consumer, maxIdle time.Duration) health.Check {
+	return func(context.Context) error {
+		last := consumer.LastFetch()
+		if last.IsZero() {
+			return errors.New("consumer is not running")
+		}
+		if idle := time.Since(last); maxIdle > 0 && idle > maxIdle {
+			return fmt.Errorf("no messages fetched for %s (max %s)", idle.Round(time.Second), maxIdle)
+		}
+		return nil
+	}
+}
+
+// newOrderCache — кэш заказов по конфигурации (memory | redis | tiered), его in-memory уровень
+// (nil у backend=redis) и функция закрытия.
+func newOrderCache(ctx context.Context, cfg *config.Cache) (ports.OrderCache, tiered.Local, func() error, error) {
+	backend := strings.ToLower(strings.TrimSpace(cfg.Backend))
+	switch backend {
+	case "", "memory":
+		local, err := newLocalCache(cfg)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return local, local, func() error { return nil }, nil
+	case "redis", "tiered":
+		var local tiered.Local
+		if backend == "tiered" {
+			var err error
+			if local, err = newLocalCache(cfg); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		client, err := cacheredis.NewClient(ctx, cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		shared := cacheredis.NewOrderCache(client, cfg.TTL, cfg.NegativeTTL, cfg.RedisKeyPrefix)
+		if local == nil {
+			return shared, nil, client.Close, nil
+		}
+		return tiered.NewOrderCache(local, shared), local, client.Close, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown cache backend %q (want memory|redis|tiered)", cfg.Backend)
+	}
+}
+
 // Bootstrap — собирает зависимости и возвращает приложение, функцию очистки и ошибку.
 func Bootstrap(ctx context.Context, cfg *config.Config) (*App, Cleanup, error) {
 	// Логгер (dev/prod режим задаётся конфигурацией).
@@ -56,7 +172,13 @@ func Bootstrap(ctx context.Context, cfg *config.Config) (*App, Cleanup, error) {
 		return nil, func() {}, err
 	}
 
-	// Регистрация метрик (Prometheus).
+	// Регистрация метрик (Prometheus); границы гистограмм — до регистрации.
+	if err := metrics.ConfigureBuckets(cfg.Metrics.DurationBuckets, cfg.Metrics.SizeBuckets); err != nil {
+		if cErr := cleanupLogger(); cErr != nil {
+			logg.Warnf(ctx, "cleanup logger: %v", cErr)
+		}
+		return nil, func() {}, fmt.Errorf("metrics config: %w", err)
+	}
 	metrics.MustRegister()
 
 	// Пул подключений Postgres
@@ -81,17 +203,43 @@ func Bootstrap(ctx context.Context, cfg *config.Config) (*App, Cleanup, error) {
 		}
 	}
 
+	// Кэш заказов (in-memory или общий Redis).
+	orderCache, localCache, closeCache, err := newOrderCache(ctx, &cfg.Cache)
+	if err != nil {
+		if terr := shutdownTrace(context.Background()); terr != nil {
+			logg.Warnf(ctx, "shutdown tracing: %v", terr)
+		}
+		pool.Close()
+		if cErr := cleanupLogger(); cErr != nil {
+			logg.Warnf(ctx, "cleanup logger: %v", cErr)
+		}
+		return nil, func() {}, err
+	}
+	logg.Infof(ctx, "order cache backend=%s policy=%s", cfg.Cache.Backend, cfg.Cache.Policy)
+
 	// Сборка зависимостей доменного слоя.
-	orderCache := cachemem.NewLRUCacheTTL(cfg.Cache.Capacity, cfg.Cache.TTL)
 	orderRepo := postgres.NewOrderRepository(pool)
+	if cfg.Outbox.Enabled {
+		orderRepo = orderRepo.WithOutbox()
+	}
 	orderValidator := validate.NewOrderValidator()
 	orderService := usecase.NewOrderService(orderRepo, orderCache, logg, orderValidator)
 
-	// Прогрев кэша
-	if n := cfg.Cache.WarmUpN; n > 0 {
-		if err := orderService.WarmUpCache(ctx, n); err != nil {
-			logg.Warnf(ctx, "warm-up cache failed: %v", err)
+	// Снимок кэша с прошлой остановки; если его нет — прогрев последними заказами (в фоне, из Run:
+	// до его окончания /readyz отвечает 503).
+	snapshot := cacheSnapshotter(ctx, &cfg.Cache, orderCache, logg)
+	restored := restoreCacheSnapshot(ctx, cfg.Cache.SnapshotPath, snapshot, logg)
+	var (
+		warmUp   func(ctx context.Context) error
+		warmedUp atomic.Bool
+	)
+	if n := cfg.Cache.WarmUpN; n > 0 && restored == 0 {
+		warmUp = func(ctx context.Context) error {
+			defer warmedUp.Store(true) // неудачный прогрев не делает сервис неготовым
+			return orderService.WarmUpCache(ctx, n)
 		}
+	} else {
+		warmedUp.Store(true)
 	}
 
 	// Режим Gin.
@@ -103,7 +251,7 @@ func Bootstrap(ctx context.Context, cfg *config.Config) (*App, Cleanup, error) {
 		otelServiceName = cfg.Tracing.ServiceName
 	}
 
-	// Роутер и HTTP-сервер.
+	// Роутер и HTTP-сервер публичного API.
 	httpHandler := rest.NewHandler(orderService, logg, cfg.HTTP.HandlerTimeout)
 	router := rest.NewRouter(httpHandler, "./web", otelServiceName)
 
@@ -118,21 +266,95 @@ func Bootstrap(ctx context.Context, cfg *config.Config) (*App, Cleanup, error) {
 
 	// Конфигурация и создание консьюмера Kafka.
 	kafkaCfg := kafka.ConsumerConfig{
-		Brokers:        cfg.Kafka.Brokers,
-		GroupID:        cfg.Kafka.GroupID,
-		Topic:          cfg.Kafka.Topic,
-		StartOffset:    cfg.Kafka.StartOffset,
-		ProcessTimeout: cfg.Kafka.ProcessTimeout,
-		RetryInitial:   cfg.Kafka.RetryInitial,
-		RetryMax:       cfg.Kafka.RetryMax,
+		Brokers:         cfg.Kafka.Brokers,
+		GroupID:         cfg.Kafka.GroupID,
+		Topic:           cfg.Kafka.Topic,
+		StartOffset:     cfg.Kafka.StartOffset,
+		ProcessTimeout:  cfg.Kafka.ProcessTimeout,
+		RetryInitial:    cfg.Kafka.RetryInitial,
+		RetryMax:        cfg.Kafka.RetryMax,
+		DeadLetterTopic: cfg.Kafka.DeadLetterTopic,
+		MaxAttempts:     cfg.Kafka.MaxAttempts,
+		ParkingTopic:    cfg.Kafka.ParkingTopic,
+		BatchSize:       cfg.Kafka.BatchSize,
+		BatchLinger:     cfg.Kafka.BatchLinger,
+		WorkerCount:     cfg.Kafka.WorkerCount,
 	}
 	consumer := kafka.NewConsumer(&kafkaCfg, orderService, logg)
 
+	// Пробы: /healthz — процесс жив; /readyz — БД, консьюмер, прогрев кэша и отсутствие остановки.
+	readiness := health.NewChecker(cfg.HTTP.HandlerTimeout)
+	readiness.Add("postgres", pool.Ping)
+	readiness.Add("kafka_consumer", consumerReadyCheck(consumer, cfg.Kafka.ReadyMaxIdle))
+	readiness.Add("cache_warmup", func(context.Context) error {
+		if !warmedUp.Load() {
+			return errWarmUpInProgress
+		}
+		return nil
+	})
+
+	// Служебный сервер (Metrics.Addr): /metrics, пробы, pprof и админка кэша.
+	var opsSrv *http.Server
+	if cfg.Metrics.Addr != "" {
+		var adminHandler *rest.AdminHandler
+		if cfg.HTTP.AdminToken != "" {
+			adminHandler = rest.NewAdminHandler(orderCache, orderService, logg, cfg.HTTP.AdminToken, cfg.HTTP.HandlerTimeout)
+		}
+		opsSrv = &http.Server{
+			Addr:              cfg.Metrics.Addr,
+			Handler:           rest.NewOpsRouter(readiness, adminHandler),
+			ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+			IdleTimeout:       cfg.HTTP.IdleTimeout,
+			// WriteTimeout не задаём: /debug/pprof/profile и trace пишут ответ десятки секунд.
+		}
+	}
+
+	// Сброс локального кэша при изменении заказа другой репликой (только если есть in-process уровень).
+	var cacheListener *postgres.ChangeListener
+	if inv, ok := orderCache.(ports.CacheInvalidator); ok && cfg.Cache.Invalidate {
+		cacheListener = postgres.NewChangeListener(pool, inv, logg, time.Second)
+	}
+
+	// Фоновая очистка истёкших записей in-memory уровня (memory и L1 у tiered).
+	var cacheJanitor *cachemem.Janitor
+	if sweeper, ok := localCache.(cachemem.Sweeper); ok && cfg.Cache.JanitorInterval > 0 {
+		cacheJanitor = cachemem.NewJanitor(sweeper, logg, cfg.Cache.JanitorInterval, cfg.Cache.JanitorBudget)
+	}
+
+	// Outbox relay: публикация событий о сохранённых заказах.
+	var (
+		outboxRelay *usecase.OutboxRelay
+		producer    ports.MessageProducer
+	)
+	if cfg.Outbox.Enabled {
+		producer = kafka.NewProducer(&kafka.ProducerConfig{Brokers: cfg.Kafka.Brokers, Topic: cfg.Outbox.Topic})
+		outboxRelay = usecase.NewOutboxRelay(postgres.NewOutboxRepository(pool), producer, logg,
+			cfg.Outbox.PollInterval, cfg.Outbox.BatchSize, cfg.Outbox.Lease, cfg.Outbox.Retention)
+	}
+
 	app := &App{
 		Logger:          logg,
 		HTTPServer:      httpSrv,
+		OpsServer:       opsSrv,
 		KafkaConsumer:   consumer,
+		OutboxRelay:     outboxRelay,
+		CacheListener:   cacheListener,
+		CacheJanitor:    cacheJanitor,
+		Health:          readiness,
+		WarmUpCache:     warmUp,
 		gracefulTimeout: cfg.HTTP.GracefulTimeout,
+		shutdownDelay:   cfg.HTTP.ShutdownDelay,
+	}
+	if snapshot != nil {
+		path := cfg.Cache.SnapshotPath
+		app.SaveCacheSnapshot = func(ctx context.Context) error {
+			n, err := cachemem.SaveSnapshot(path, snapshot)
+			if err != nil {
+				return err
+			}
+			logg.Infof(ctx, "cache snapshot saved: %d entries to %s", n, path)
+			return nil
+		}
 	}
 
 	// Очистка ресурсов (в обратном порядке).
@@ -143,6 +365,15 @@ func Bootstrap(ctx context.Context, cfg *config.Config) (*App, Cleanup, error) {
 		if err := consumer.Close(); err != nil {
 			logg.Warnf(ctx, "kafka consumer close error: %v", err)
 		}
+		if producer != nil {
+			if err := producer.Close(); err != nil {
+				logg.Warnf(ctx, "outbox producer close error: %v", err)
+			}
+		}
+
+		if err := closeCache(); err != nil {
+			logg.Warnf(ctx, "cache close error: %v", err)
+		}
 
 		pool.Close()
 		if cerr := cleanupLogger(); cerr != nil {
@@ -153,9 +384,10 @@ func Bootstrap(ctx context.Context, cfg *config.Config) (*App, Cleanup, error) {
 	return app, cleanup, nil
 }
 
-// Run — запускает HTTP-сервер и консьюмера; ждёт отмены контекста или ошибки и останавливает их.
+// Run — запускает HTTP-серверы (API и служебный), консьюмера, outbox relay, слушателя инвалидации,
+// очистку и прогрев кэша; ждёт отмены контекста или ошибки, переводит /readyz в draining и останавливает их.
 func (a *App) Run(ctx context.Context) error {
-	errCh := make(chan error, 2)
+	errCh := make(chan error, 6)
 
 	// Запуск консьюмера.
 	go func() {
@@ -165,6 +397,53 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}()
 
+	// Запуск outbox relay (если включён).
+	if a.OutboxRelay != nil {
+		go func() {
+			a.Logger.Infof(ctx, "outbox relay starting")
+			if err := a.OutboxRelay.Run(ctx); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	// Запуск слушателя инвалидации кэша (если включён).
+	if a.CacheListener != nil {
+		go func() {
+			if err := a.CacheListener.Run(ctx); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	// Запуск фоновой очистки кэша (если включена).
+	if a.CacheJanitor != nil {
+		go func() {
+			if err := a.CacheJanitor.Run(ctx); err != nil {
+				errCh <- err
+			}
+		}()
+	}
+
+	// Стартовый прогрев кэша (если нужен): ошибка не останавливает сервис.
+	if a.WarmUpCache != nil {
+		go func() {
+			if err := a.WarmUpCache(ctx); err != nil {
+				a.Logger.Warnf(ctx, "warm-up cache failed: %v", err)
+			}
+		}()
+	}
+
+	// Запуск служебного HTTP-сервера (если включён).
+	if a.OpsServer != nil {
+		go func() {
+			a.Logger.Infof(ctx, "ops server starting (addr=%s)", a.OpsServer.Addr)
+			if err := a.OpsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- err
+			}
+		}()
+	}
+
 	// Запуск HTTP-сервера.
 	go func() {
 		a.Logger.Infof(ctx, "http server starting (addr=%s)", a.HTTPServer.Addr)
@@ -185,6 +464,15 @@ func (a *App) Run(ctx context.Context) error {
 		}
 	}
 
+	// Снимаем реплику с балансировки: /readyz отвечает 503, пока сервер ещё обслуживает запросы.
+	if a.Health != nil {
+		a.Health.SetDraining()
+	}
+	if a.shutdownDelay > 0 {
+		a.Logger.Infof(ctx, "readiness set to draining, waiting %s before shutdown", a.shutdownDelay)
+		time.Sleep(a.shutdownDelay)
+	}
+
 	gt := a.gracefulTimeout
 	if gt <= 0 {
 		gt = 5 * time.Second
@@ -205,6 +493,22 @@ func (a *App) Run(ctx context.Context) error {
 		a.Logger.Warnf(ctx, "kafka consumer close error: %v", err)
 	}
 
+	// Снимок кэша — после остановки HTTP и консьюмера, чтобы зафиксировать итоговое содержимое.
+	if a.SaveCacheSnapshot != nil {
+		if err := a.SaveCacheSnapshot(shutdownCtx); err != nil {
+			a.Logger.Warnf(ctx, "cache snapshot save failed: %v", err)
+		}
+	}
+
+	// Служебный сервер — последним: до конца остановки /readyz отвечает 503, а метрики доступны.
+	if a.OpsServer != nil {
+		if err := a.OpsServer.Shutdown(shutdownCtx); err != nil {
+			a.Logger.Warnf(ctx, "ops server shutdown failed: %v", err)
+		} else {
+			a.Logger.Infof(ctx, "ops server stopped gracefully")
+		}
+	}
+
 	a.Logger.Infof(ctx, "service stopped")
 	return nil
 }
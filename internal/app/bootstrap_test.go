@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/Gunvolt24/wb_l0/internal/app"
+	"github.com/Gunvolt24/wb_l0/pkg/health"
 )
 
 // логгер-заглушка
@@ -62,3 +63,106 @@ func TestAppRun_GracefulShutdown(t *testing.T) {
 		t.Fatalf("consumer.Close should be called")
 	}
 }
+
+func TestAppRun_SavesCacheSnapshotAfterConsumerStop(t *testing.T) {
+	fc := &fakeConsumer{}
+	var consumerClosedFirst, saved atomic.Bool
+	a := &app.App{
+		Logger:        nopLogger{},
+		HTTPServer:    &http.Server{Addr: "127.0.0.1:0", Handler: http.NewServeMux()},
+		KafkaConsumer: fc,
+		SaveCacheSnapshot: func(context.Context) error {
+			consumerClosedFirst.Store(atomic.LoadInt32(&fc.closeCalls) > 0)
+			saved.Store(true)
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !saved.Load() {
+		t.Fatalf("cache snapshot should be saved on shutdown")
+	}
+	if !consumerClosedFirst.Load() {
+		t.Fatalf("cache snapshot should be saved after consumer is closed")
+	}
+}
+
+func TestAppRun_WarmUpInBackgroundAndDrainingOnShutdown(t *testing.T) {
+	checker := health.NewChecker(0)
+	warmed := make(chan struct{})
+	a := &app.App{
+		Logger:        nopLogger{},
+		HTTPServer:    &http.Server{Addr: "127.0.0.1:0", Handler: http.NewServeMux()},
+		KafkaConsumer: &fakeConsumer{},
+		Health:        checker,
+		WarmUpCache: func(context.Context) error {
+			close(warmed)
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	select {
+	case <-warmed:
+	default:
+		t.Fatalf("cache warm-up should be started by Run")
+	}
+	if !checker.Draining() {
+		t.Fatalf("readiness should be switched to draining on shutdown")
+	}
+}
+
+func TestAppRun_OpsServerStopsAfterAPI(t *testing.T) {
+	apiStopped, opsStopped := make(chan struct{}), make(chan struct{})
+	api := &http.Server{Addr: "127.0.0.1:0", Handler: http.NewServeMux()}
+	api.RegisterOnShutdown(func() { close(apiStopped) })
+	ops := &http.Server{Addr: "127.0.0.1:0", Handler: http.NewServeMux()}
+	ops.RegisterOnShutdown(func() { close(opsStopped) })
+
+	// Снимок пишется после остановки API: служебный сервер в этот момент ещё работает.
+	var opsAliveAfterAPI atomic.Bool
+	a := &app.App{
+		Logger:        nopLogger{},
+		HTTPServer:    api,
+		OpsServer:     ops,
+		KafkaConsumer: &fakeConsumer{},
+		SaveCacheSnapshot: func(context.Context) error {
+			select {
+			case <-apiStopped:
+			case <-time.After(time.Second):
+				return nil
+			}
+			select {
+			case <-opsStopped:
+			default:
+				opsAliveAfterAPI.Store(true)
+			}
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if err := a.Run(ctx); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !opsAliveAfterAPI.Load() {
+		t.Fatalf("ops server should be shut down after the API server")
+	}
+	select {
+	case <-opsStopped:
+	case <-time.After(time.Second):
+		t.Fatalf("ops server should be shut down by Run")
+	}
+}
@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+)
+
+// sweepChunk — записей, проверяемых за одну блокировку: между порциями блокировку получают чтения.
+const sweepChunk = 1024
+
+// Sweeper — кэш, который умеет удалять истёкшие записи в пределах бюджета времени.
+type Sweeper interface {
+	// SweepExpired — удалить истёкшие записи, потратив не больше budget (минимум — одна порция);
+	// возвращает число удалённых.
+	SweepExpired(budget time.Duration) int
+}
+
+// Проверка, что кэши поддерживают фоновую очистку.
+var (
+	_ Sweeper = (*LRUCacheTTL)(nil)
+	_ Sweeper = (*ShardedLRUCache)(nil)
+)
+
+// Janitor — фоновая очистка истёкших записей: без неё запись с истёкшим TTL удаляется, только когда её
+// прочитают или она станет кандидатом на вытеснение, и до тех пор занимает память и учитывается в cache_size.
+type Janitor struct {
+	target   Sweeper
+	log      ports.Logger
+	interval time.Duration // пауза между обходами
+	budget   time.Duration // время на один обход
+}
+
+// NewJanitor — DI-конструктор. Если interval <= 0, ставим дефолт 1m; если budget <= 0 — 5ms.
+func NewJanitor(target Sweeper, log ports.Logger, interval, budget time.Duration) *Janitor {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if budget <= 0 {
+		budget = 5 * time.Millisecond
+	}
+	return &Janitor{target: target, log: log, interval: interval, budget: budget}
+}
+
+// Run — очищать кэш раз в interval до отмены контекста; возвращает ctx.Err().
+func (j *Janitor) Run(ctx context.Context) error {
+	j.log.Infof(ctx, "cache janitor started interval=%s budget=%s", j.interval, j.budget)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			j.target.SweepExpired(j.budget)
+		}
+	}
+}
+
+// SweepExpired — удалить истёкшие записи порциями по sweepChunk, пока не кончится бюджет или не будет
+// проверено столько записей, сколько их было в кэше на начало обхода. Записи обходятся по кругу в порядке
+// вставки, начиная с курсора, на котором остановился предыдущий обход, поэтому последовательные обходы
+// проверяют каждую запись (новые — в свою очередь), даже если бюджета хватает на одну порцию.
+func (c *LRUCacheTTL) SweepExpired(budget time.Duration) int {
+	if c.ttl <= 0 && c.negativeTTL <= 0 {
+		return 0
+	}
+	start := time.Now()
+	removed, checked, total := 0, 0, -1
+	for {
+		limit := sweepChunk
+		if total >= 0 {
+			limit = min(limit, total-checked)
+		}
+		r, n, size := c.sweepChunk(time.Now(), limit)
+		if total < 0 {
+			total = size // размер на начало обхода: удаления его уменьшают
+		}
+		removed += r
+		checked += n
+		if n < limit || checked >= total || time.Since(start) >= budget {
+			return removed
+		}
+	}
+}
+
+// sweepChunk — одна порция под блокировкой: проверить до limit записей (не больше размера кэша)
+// от курсора очистки и удалить истёкшие. Возвращает число удалённых, проверенных и размер кэша до порции.
+func (c *LRUCacheTTL) sweepChunk(now time.Time, limit int) (removed, checked, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	size = len(c.cache)
+	for checked < min(limit, size) {
+		elem := c.sweepNext
+		if elem == nil {
+			elem = c.sweepOrder.Front() // конец порядка — продолжаем с начала
+		}
+		c.sweepNext = elem.Next()
+		checked++
+
+		ent := elem.Value.(*entry)
+		if c.isExpired(ent, now) {
+			c.removeEntry(ent)
+			metrics.CacheOps.WithLabelValues("expired").Inc()
+			removed++
+		}
+	}
+	return removed, checked, size
+}
+
+// SweepExpired — очистка шардов по очереди в пределах общего бюджета; следующий обход начинается
+// со следующего шарда, чтобы при малом бюджете до всех шардов доходила очередь.
+func (c *ShardedLRUCache) SweepExpired(budget time.Duration) int {
+	start := time.Now()
+	first := int(c.nextSweep.Add(1)-1) % len(c.shards)
+	removed := 0
+	for i := range c.shards {
+		left := budget - time.Since(start)
+		if i > 0 && left <= 0 {
+			break
+		}
+		removed += c.shards[(first+i)%len(c.shards)].SweepExpired(left)
+	}
+	return removed
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+)
+
+type noopLogger struct{}
+
+func (noopLogger) Infof(context.Context, string, ...any)  {}
+func (noopLogger) Warnf(context.Context, string, ...any)  {}
+func (noopLogger) Errorf(context.Context, string, ...any) {}
+
+// expireAll — пометить истёкшими записи кэша с UID, начинающимся с prefix.
+func expireAll(c *LRUCacheTTL, prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, ent := range c.cache {
+		if strings.HasPrefix(id, prefix) {
+			ent.expiresAt = time.Now().Add(-time.Second)
+		}
+	}
+}
+
+func TestSweepExpired_RemovesOnlyExpired(t *testing.T) {
+	before := testutil.ToFloat64(metrics.CacheSize)
+	c := NewLRUCacheTTL(3000, 0, time.Minute, time.Minute)
+	mustSet(t, c, newOrder("fresh"))
+	if err := c.SetNegative(context.Background(), "missing"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	for i := range 2500 { // больше одной порции
+		mustSet(t, c, newOrder("old-"+strconv.Itoa(i)))
+	}
+	expireAll(c, "old-")
+
+	if removed := c.SweepExpired(time.Second); removed != 2500 {
+		t.Fatalf("SweepExpired removed %d, want 2500", removed)
+	}
+	listed := 0
+	c.policy.walk(func(*entry) bool { listed++; return true })
+	if len(c.cache) != 2 || listed != 2 {
+		t.Fatalf("want fresh and negative entries left, got map=%d policy=%d", len(c.cache), listed)
+	}
+	if got := testutil.ToFloat64(metrics.CacheSize) - before; got != 2 {
+		t.Fatalf("cache_size must drop with sweep, delta=%v", got)
+	}
+	if _, hit := c.Get(context.Background(), "fresh"); !hit {
+		t.Fatalf("fresh entry must survive sweep")
+	}
+	_ = c.Purge(context.Background())
+}
+
+// При бюджете на одну порцию каждый обход продолжает с места, где остановился предыдущий:
+// за ceil(N/sweepChunk) обходов проверяются все записи
+func TestSweepExpired_ResumesFromCursor(t *testing.T) {
+	c := NewLRUCacheTTL(3000, 0, time.Minute, time.Minute)
+	const total = 2500
+	for i := range total {
+		mustSet(t, c, newOrder("old-"+strconv.Itoa(i)))
+	}
+	expireAll(c, "old-")
+
+	removed := 0
+	for range (total + sweepChunk - 1) / sweepChunk {
+		removed += c.SweepExpired(time.Nanosecond) // бюджет исчерпан после первой порции
+	}
+	if removed != total || len(c.cache) != 0 || c.sweepOrder.Len() != 0 {
+		t.Fatalf("want all %d entries swept, removed=%d left=%d", total, removed, len(c.cache))
+	}
+
+	// Курсор переживает удаление записи, на которую указывает, и очистку кэша
+	for i := range 3 {
+		mustSet(t, c, newOrder("new-"+strconv.Itoa(i)))
+	}
+	c.sweepNext = c.cache["new-1"].sweepElem
+	if err := c.Delete(context.Background(), "new-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if c.sweepNext != c.cache["new-2"].sweepElem {
+		t.Fatalf("cursor must move to the next entry on delete")
+	}
+	_ = c.Purge(context.Background())
+	if c.sweepNext != nil || c.sweepOrder.Len() != 0 {
+		t.Fatalf("Purge must reset sweep order")
+	}
+}
+
+func TestSweepExpired_Sharded(t *testing.T) {
+	c := NewShardedLRUCache(4, PolicyLRU, 100, 0, time.Minute, time.Minute)
+	for i := range 40 {
+		mustSetSharded(t, c, "id-"+strconv.Itoa(i))
+	}
+	for _, shard := range c.shards {
+		expireAll(shard, "")
+	}
+
+	if removed := c.SweepExpired(time.Second); removed != 40 {
+		t.Fatalf("SweepExpired removed %d, want 40", removed)
+	}
+	if n, _ := c.Len(context.Background()); n != 0 {
+		t.Fatalf("want empty cache after sweep, got %d", n)
+	}
+}
+
+// countingSweeper — считает вызовы очистки.
+type countingSweeper struct{ calls atomic.Int32 }
+
+func (s *countingSweeper) SweepExpired(time.Duration) int {
+	s.calls.Add(1)
+	return 0
+}
+
+func TestJanitor_RunStopsOnCancel(t *testing.T) {
+	sweeper := &countingSweeper{}
+	j := NewJanitor(sweeper, noopLogger{}, 5*time.Millisecond, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- j.Run(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sweeper.calls.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor did not sweep")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Run must return ctx.Err(), got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("janitor did not stop on cancel")
+	}
+}
@@ -5,89 +5,160 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/internal/ports"
 	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/Gunvolt24/wb_l0/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// Проверка, что LRUCacheTTL удовлетворяет интерфейсу OrderCache.
-var _ ports.OrderCache = (*LRUCacheTTL)(nil)
+// tracerName — имя инструментирующей библиотеки для спанов кэша.
+const tracerName = "github.com/Gunvolt24/wb_l0/internal/cache/memory"
+
+// Атрибуты спанов кэша.
+const (
+	attrOrderUID = attribute.Key("order.uid")
+	attrCacheHit = attribute.Key("cache.hit")
+)
+
+// Проверка, что LRUCacheTTL удовлетворяет интерфейсам OrderCache и CacheInvalidator.
+var (
+	_ ports.OrderCache       = (*LRUCacheTTL)(nil)
+	_ ports.CacheInvalidator = (*LRUCacheTTL)(nil)
+)
 
 // ErrInvalidOrder — некорректные данные для сохранения в кэше.
 var ErrInvalidOrder = errors.New("invalid order: nil or empty order_uid")
 
-// entry — элемент LRU-списка с данными заказа и временем истечения TTL.
+// ErrOrderTooLarge — оценка размера заказа больше всего бюджета памяти кэша: такой заказ не кэшируется.
+var ErrOrderTooLarge = errors.New("order exceeds cache memory budget")
+
+// entry — запись кэша с данными заказа и временем истечения TTL.
+// order == nil — негативная запись (заказа нет в БД).
 type entry struct {
 	id        string
 	order     *domain.Order
 	expiresAt time.Time
+	storedAt  time.Time // время последней записи (Set/SetNegative)
+	size      int64     // оценка занимаемой памяти (estimateSize)
+
+	// Состояние политики вытеснения.
+	elem    *list.Element // элемент в списке политики
+	freq    int           // частота обращений (LFU)
+	segment uint8         // сегмент (W-TinyLFU)
+
+	sweepElem *list.Element // элемент в порядке фоновой очистки (LRUCacheTTL.sweepOrder)
 }
 
-// LRUCacheTTL — потокобезопасный LRU-кэш с TTL.
-// Get перемещает элемент в начало и при наличии TTL продлевает срок жизни.
+// LRUCacheTTL — потокобезопасный кэш с TTL; по умолчанию порядок вытеснения — LRU,
+// другая политика задаётся через NewCacheWithPolicy.
+// Get отмечает обращение в политике и при наличии TTL продлевает срок жизни.
+// Негативные записи живут фиксированный negativeTTL (без продления) и занимают место наравне с заказами.
+// Размер ограничивается числом элементов и/или бюджетом памяти: при превышении любого из лимитов
+// вытесняются записи, выбранные политикой.
 type LRUCacheTTL struct {
-	capacity int           // максимальное количество элементов
-	ttl      time.Duration // время истечения TTL
+	capacity    int           // максимальное количество элементов (0 — без ограничения, если задан maxBytes)
+	maxBytes    int64         // бюджет памяти по оценке estimateSize (0 — без ограничения)
+	bytes       int64         // текущая оценка занятой памяти
+	ttl         time.Duration // время истечения TTL
+	negativeTTL time.Duration // время жизни негативной записи (0 — негативное кэширование выключено)
+
+	policy evictionPolicy    // порядок вытеснения
+	cache  map[string]*entry // индекс по UID
+
+	sweepOrder *list.List    // записи в порядке вставки: по нему идёт фоновая очистка (SweepExpired)
+	sweepNext  *list.Element // курсор очистки: с этой записи продолжит следующая порция (nil — с начала)
 
-	ll    *list.List               // двухсвязный список для порядка LRU
-	cache map[string]*list.Element // индекс по UID
+	hits, misses atomic.Uint64 // обращения с запуска (для Stats)
 
 	mu sync.Mutex // защита структур от параллельных доступов
 }
 
-// NewLRUCacheTTL — создаёт кэш с заданной ёмкостью и TTL.
-// Если capacity <= 0, используется 1.
-func NewLRUCacheTTL(capacity int, ttl time.Duration) *LRUCacheTTL {
-	if capacity <= 0 {
+// NewLRUCacheTTL — создаёт LRU-кэш с заданной ёмкостью (элементов), бюджетом памяти (байт), TTL
+// и временем жизни негативных записей. maxBytes <= 0 — без бюджета памяти; capacity <= 0 —
+// без ограничения числа элементов, если бюджет задан, иначе 1. negativeTTL <= 0 выключает негативное кэширование.
+func NewLRUCacheTTL(capacity int, maxBytes int64, ttl, negativeTTL time.Duration) *LRUCacheTTL {
+	return NewCacheWithPolicy(PolicyLRU, capacity, maxBytes, ttl, negativeTTL)
+}
+
+// NewCacheWithPolicy — как NewLRUCacheTTL, но с заданной политикой вытеснения
+// (неизвестная политика — LRU; значение из конфигурации проверяет ParsePolicy).
+func NewCacheWithPolicy(policy Policy, capacity int, maxBytes int64, ttl, negativeTTL time.Duration) *LRUCacheTTL {
+	capacity, maxBytes = max(capacity, 0), max(maxBytes, 0)
+	if capacity == 0 && maxBytes == 0 {
 		capacity = 1
 	}
 	return &LRUCacheTTL{
-		capacity: capacity,
-		ttl:      ttl,
-		ll:       list.New(),
-		cache:    make(map[string]*list.Element),
+		capacity:    capacity,
+		maxBytes:    maxBytes,
+		ttl:         ttl,
+		negativeTTL: max(negativeTTL, 0),
+		policy:      newPolicy(policy, capacity, maxBytes),
+		cache:       make(map[string]*entry),
+		sweepOrder:  list.New(),
 	}
 }
 
 // Get — вернуть заказ по id.
-// (order, true) при попадании; (nil, false) при промахе или истёкшем TTL.
+// (order, true) при попадании; (nil, true) при негативной записи; (nil, false) при промахе или истёкшем TTL.
 // Возвращает копию сущности.
-func (c *LRUCacheTTL) Get(_ context.Context, id string) (*domain.Order, bool) {
+func (c *LRUCacheTTL) Get(ctx context.Context, id string) (order *domain.Order, hit bool) {
+	_, span := telemetry.StartSpan(ctx, tracerName, "LRUCacheTTL.Get", attrOrderUID.String(id))
+	defer func() {
+		span.SetAttributes(attrCacheHit.Bool(hit))
+		span.End()
+	}()
+
 	if id == "" {
 		metrics.CacheOps.WithLabelValues("miss").Inc()
+		c.misses.Add(1)
 		return nil, false
 	}
 
-	now := time.Now()
+	stored, hit := c.lookup(id, time.Now())
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	if !hit || stored == nil {
+		return nil, hit
+	}
+	// Копируем вне блокировки: сохранённый заказ не меняется на месте (Set подменяет указатель).
+	return cloneOrder(stored), true
+}
 
+// lookup — поиск под блокировкой: проверка TTL, учёт обращения в политике, продление срока и метрики.
+// Возвращает внутренний указатель (nil при негативной записи) — его нельзя отдавать наружу без копирования.
+func (c *LRUCacheTTL) lookup(id string, now time.Time) (*domain.Order, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	elem, ok := c.cache[id]
+	ent, ok := c.cache[id]
 	if !ok {
 		metrics.CacheOps.WithLabelValues("miss").Inc()
-		return nil, false
-	}
-
-	ent, ok := elem.Value.(*entry)
-	if !ok {
-		metrics.CacheOps.WithLabelValues("expired").Inc()
-		c.removeElement(elem)
-		metrics.CacheSize.Set(float64(c.ll.Len()))
+		c.policy.recordMiss(id)
 		return nil, false
 	}
 
 	if c.isExpired(ent, now) {
 		metrics.CacheOps.WithLabelValues("expired").Inc()
-		c.removeElement(elem)
-		metrics.CacheSize.Set(float64(c.ll.Len()))
+		c.removeEntry(ent)
+		c.policy.recordMiss(id)
 		return nil, false
 	}
 
-	// Перемещаем элемент в начало LRU
-	c.ll.MoveToFront(elem)
+	// Отмечаем обращение (для LRU — перемещение в начало)
+	c.policy.touch(ent)
+
+	// Негативная запись: срок не продлеваем, иначе сканер несуществующих UID держал бы её вечно
+	if ent.order == nil {
+		metrics.CacheOps.WithLabelValues("miss_negative").Inc()
+		return nil, true
+	}
 
 	// Обновляем TTL при попадании
 	if c.ttl > 0 {
@@ -95,59 +166,106 @@ func (c *LRUCacheTTL) Get(_ context.Context, id string) (*domain.Order, bool) {
 	}
 
 	metrics.CacheOps.WithLabelValues("hit").Inc()
-	return cloneOrder(ent.order), true
+	return ent.order, true
 }
 
 // Set — сохранить/обновить заказ.
-// Возвращает ErrInvalidOrder при пустом UID или nil-значении.
-func (c *LRUCacheTTL) Set(_ context.Context, order *domain.Order) error {
+// Возвращает ErrInvalidOrder при пустом UID или nil-значении и ErrOrderTooLarge, если заказ
+// больше всего бюджета памяти (прежняя запись этого UID тогда удаляется, чтобы не отдавать устаревшую).
+func (c *LRUCacheTTL) Set(ctx context.Context, order *domain.Order) (err error) {
+	_, span := telemetry.StartSpan(ctx, tracerName, "LRUCacheTTL.Set")
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	if order == nil || order.OrderUID == "" {
 		return ErrInvalidOrder
 	}
+	span.SetAttributes(attrOrderUID.String(order.OrderUID))
+
+	// Копия и оценка размера — до блокировки.
+	cloned := cloneOrder(order)
+	size := estimateSize(order.OrderUID, cloned)
 	now := time.Now()
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Обновление существующего элемента
-	if elem, found := c.cache[order.OrderUID]; found {
-		if ent, isEntry := elem.Value.(*entry); isEntry {
-			ent.order = cloneOrder(order)
-			ent.expiresAt = c.expiryFrom(now)
-			c.ll.MoveToFront(elem)
-			return nil
+	if c.maxBytes > 0 && size > c.maxBytes {
+		if ent, found := c.cache[order.OrderUID]; found {
+			c.removeEntry(ent)
 		}
-		// Некорректное состояние - удалить и вставить заново
-		c.removeElement(elem)
+		metrics.CacheOps.WithLabelValues("too_large").Inc()
+		return ErrOrderTooLarge
+	}
+
+	// Обновление существующего элемента
+	if ent, found := c.cache[order.OrderUID]; found {
+		ent.order = cloned
+		ent.expiresAt = c.expiryFrom(now)
+		ent.storedAt = now
+		c.resize(ent, size)
+		c.policy.touch(ent)
+		c.evictOverflow(now)
+		return nil
 	}
 
 	// Перед вставкой удаляем устаревшие элементы
-	c.pruneExpiredFromBack(now)
+	c.pruneExpired(now)
 
 	// Вставка нового элемента
-	elem := c.ll.PushFront(&entry{
+	c.insert(&entry{
 		id:        order.OrderUID,
-		order:     cloneOrder(order),
+		order:     cloned,
 		expiresAt: c.expiryFrom(now),
+		storedAt:  now,
+		size:      size,
 	})
-	c.cache[order.OrderUID] = elem
-	metrics.CacheSize.Set(float64(c.ll.Len()))
 
-	// Вытеснение при превышении ёмкости
-	if c.ll.Len() > c.capacity {
-		c.evictLRU()
+	// Вытеснение при превышении ёмкости или бюджета памяти
+	c.evictOverflow(now)
+	return nil
+}
+
+// SetNegative — запомнить отсутствие заказа на negativeTTL.
+// Запись с заказом не затирается (заказ мог прийти, пока шло чтение из БД).
+func (c *LRUCacheTTL) SetNegative(ctx context.Context, id string) (err error) {
+	_, span := telemetry.StartSpan(ctx, tracerName, "LRUCacheTTL.SetNegative", attrOrderUID.String(id))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if id == "" {
+		return ErrInvalidOrder
+	}
+	if c.negativeTTL <= 0 {
+		return nil
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ent, found := c.cache[id]; found {
+		if ent.order != nil && !c.isExpired(ent, now) {
+			return nil
+		}
+		c.removeEntry(ent)
 	}
+
+	c.pruneExpired(now)
+
+	c.insert(&entry{id: id, expiresAt: now.Add(c.negativeTTL), storedAt: now, size: estimateSize(id, nil)})
+	c.evictOverflow(now)
 	return nil
 }
 
 // WarmUp — массовая загрузка кэша (например, при запуске).
+// Заказ больше бюджета памяти пропускается (учитывается в cache_operations_total{op="too_large"}),
+// остальные загружаются.
 func (c *LRUCacheTTL) WarmUp(ctx context.Context, orders []*domain.Order) error {
 	for _, order := range orders {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			if err := c.Set(ctx, order); err != nil {
+			if err := c.Set(ctx, order); err != nil && !errors.Is(err, ErrOrderTooLarge) {
 				return err
 			}
 		}
@@ -155,4 +273,77 @@ func (c *LRUCacheTTL) WarmUp(ctx context.Context, orders []*domain.Order) error
 	return nil
 }
 
+// Delete — удалить заказ из кэша (отсутствие записи — не ошибка).
+func (c *LRUCacheTTL) Delete(_ context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ent, ok := c.cache[id]; ok {
+		c.removeEntry(ent)
+	}
+	return nil
+}
+
+// Purge — очистить кэш целиком.
+func (c *LRUCacheTTL) Purge(_ context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	metrics.CacheSize.Sub(float64(len(c.cache)))
+	metrics.CacheBytes.Sub(float64(c.bytes))
+	c.bytes = 0
+	c.policy.reset()
+	c.cache = make(map[string]*entry)
+	c.sweepOrder.Init()
+	c.sweepNext = nil
+	return nil
+}
+
+// Len — число записей (включая негативные и истёкшие, ещё не удалённые).
+func (c *LRUCacheTTL) Len(_ context.Context) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.cache), nil
+}
+
+// Keys — не более limit UID актуальных записей в порядке политики вытеснения
+// (для LRU — от недавно использованных к кандидатам на вытеснение). limit <= 0 — все.
+func (c *LRUCacheTTL) Keys(_ context.Context, limit int) ([]string, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limit <= 0 || limit > len(c.cache) {
+		limit = len(c.cache)
+	}
+	keys := make([]string, 0, limit)
+	c.policy.walk(func(ent *entry) bool {
+		if !c.isExpired(ent, now) {
+			keys = append(keys, ent.id)
+		}
+		return len(keys) < limit
+	})
+	return keys, nil
+}
+
+// Stats — размер, оценка памяти, обращения и возраст самой давней записи (поиск — O(n) под блокировкой).
+func (c *LRUCacheTTL) Stats(_ context.Context) (ports.CacheStats, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	stats := ports.CacheStats{Entries: len(c.cache), Bytes: c.bytes}
+	for _, ent := range c.cache {
+		stats.OldestAge = max(stats.OldestAge, now.Sub(ent.storedAt))
+	}
+	c.mu.Unlock()
+
+	stats.Hits, stats.Misses = c.hits.Load(), c.misses.Load()
+	return stats, nil
+}
+
+// Invalidate — сбросить локальную копию заказа, изменённого другим экземпляром сервиса.
+func (c *LRUCacheTTL) Invalidate(ctx context.Context, id string) { _ = c.Delete(ctx, id) }
 
+// InvalidateAll — сбросить все локальные копии (например, после пропуска уведомлений об изменениях).
+func (c *LRUCacheTTL) InvalidateAll(ctx context.Context) { _ = c.Purge(ctx) }
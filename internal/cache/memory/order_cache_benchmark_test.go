@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+)
+
+// Параллельные бенчмарки: один мьютекс (LRUCacheTTL) против шардов (ShardedLRUCache).
+// Запуск: go test -bench=Parallel -cpu=1,4,16 ./internal/cache/memory/
+func BenchmarkCache_Parallel(b *testing.B) {
+	const keys = 10_000
+
+	orders := make([]*domain.Order, keys)
+	for i := range orders {
+		orders[i] = newOrder("uid-" + strconv.Itoa(i))
+	}
+
+	caches := []struct {
+		name string
+		new  func() ports.OrderCache
+	}{
+		{"single", func() ports.OrderCache { return NewLRUCacheTTL(keys, 0, time.Minute, 0) }},
+		{"sharded-16", func() ports.OrderCache { return NewShardedLRUCache(16, PolicyLRU, keys, 0, time.Minute, 0) }},
+		{"sharded-64", func() ports.OrderCache { return NewShardedLRUCache(64, PolicyLRU, keys, 0, time.Minute, 0) }},
+	}
+	mixes := []struct {
+		name       string
+		writeEvery int // каждая N-я операция — Set (0 — только чтение)
+	}{
+		{"read-only", 0},
+		{"read-90", 10},
+	}
+
+	for _, cc := range caches {
+		for _, mix := range mixes {
+			b.Run(cc.name+"/"+mix.name, func(b *testing.B) {
+				c := cc.new()
+				ctx := context.Background()
+				if err := c.WarmUp(ctx, orders); err != nil {
+					b.Fatalf("WarmUp: %v", err)
+				}
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for i := 1; pb.Next(); i++ {
+						o := orders[rand.IntN(keys)]
+						if mix.writeEvery > 0 && i%mix.writeEvery == 0 {
+							_ = c.Set(ctx, o)
+							continue
+						}
+						c.Get(ctx, o.OrderUID)
+					}
+				})
+			})
+		}
+	}
+}
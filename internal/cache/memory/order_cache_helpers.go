@@ -1,36 +1,66 @@
 package memory
 
 import (
-	"container/list"
 	"time"
 
 	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/pkg/metrics"
 )
 
-// evictLRU — удаляет наименее используемый элемент.
-func (c *LRUCacheTTL) evictLRU() {
-	if back := c.ll.Back(); back != nil {
-		c.removeElement(back)
-		metrics.CacheOps.WithLabelValues("evicted").Inc()
-		metrics.CacheSize.Set(float64(c.ll.Len()))
+// evictOverflow — вытесняет выбранные политикой записи, пока кэш не уложится в ёмкость и бюджет памяти.
+// Запись с истёкшим TTL учитывается как "expired", остальные — как "evicted".
+func (c *LRUCacheTTL) evictOverflow(now time.Time) {
+	for len(c.cache) > 0 &&
+		((c.capacity > 0 && len(c.cache) > c.capacity) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		victim := c.policy.victim()
+		if victim == nil {
+			return
+		}
+		c.removeEntry(victim)
+		if c.isExpired(victim, now) {
+			metrics.CacheOps.WithLabelValues("expired").Inc()
+		} else {
+			metrics.CacheOps.WithLabelValues("evicted").Inc()
+		}
 	}
 }
 
-// removeElement — удаляет элемент из списка и индекса.
-func (c *LRUCacheTTL) removeElement(elem *list.Element) {
-	if elem == nil {
-		return
-	}
-	if ent, ok := elem.Value.(*entry); ok {
-		delete(c.cache, ent.id)
+// insert — добавляет запись в индекс и политику.
+// CacheSize меняется на ±1, а не выставляется по размеру индекса: экземпляров (шардов) может быть несколько.
+func (c *LRUCacheTTL) insert(ent *entry) {
+	c.cache[ent.id] = ent
+	c.policy.add(ent)
+	ent.sweepElem = c.sweepOrder.PushBack(ent)
+	c.bytes += ent.size
+	metrics.CacheSize.Inc()
+	metrics.CacheBytes.Add(float64(ent.size))
+}
+
+// resize — новая оценка размера записи (обновление заказа).
+func (c *LRUCacheTTL) resize(ent *entry, size int64) {
+	delta := size - ent.size
+	ent.size = size
+	c.policy.resize(ent, delta)
+	c.bytes += delta
+	metrics.CacheBytes.Add(float64(delta))
+}
+
+// removeEntry — удаляет запись из индекса, политики и порядка очистки (курсор очистки сдвигается дальше).
+func (c *LRUCacheTTL) removeEntry(ent *entry) {
+	delete(c.cache, ent.id)
+	c.policy.remove(ent)
+	if c.sweepNext == ent.sweepElem {
+		c.sweepNext = ent.sweepElem.Next()
 	}
-	c.ll.Remove(elem)
+	c.sweepOrder.Remove(ent.sweepElem)
+	c.bytes -= ent.size
+	metrics.CacheBytes.Sub(float64(ent.size))
+	metrics.CacheSize.Dec()
 }
 
-// isExpired — проверяет истечение TTL.
+// isExpired — проверяет истечение TTL (нулевой expiresAt — запись без срока).
 func (c *LRUCacheTTL) isExpired(ent *entry, now time.Time) bool {
-	if c.ttl <= 0 {
+	if ent.expiresAt.IsZero() {
 		return false
 	}
 	return now.After(ent.expiresAt)
@@ -44,29 +74,20 @@ func (c *LRUCacheTTL) expiryFrom(now time.Time) time.Time {
 	return now.Add(c.ttl)
 }
 
-// pruneExpiredFromBack — удаляет элементы с истекшим TTL из хвоста до первого актуального.
-func (c *LRUCacheTTL) pruneExpiredFromBack(now time.Time) {
-	if c.ttl <= 0 {
+// pruneExpired — удаляет записи с истекшим TTL из очереди на вытеснение до первой актуальной
+// (для LRU — из хвоста списка). Кандидат берётся через peekVictim: если ничего не истекло,
+// сегменты политики остаются как были.
+func (c *LRUCacheTTL) pruneExpired(now time.Time) {
+	if c.ttl <= 0 && c.negativeTTL <= 0 {
 		return
 	}
 	for {
-		back := c.ll.Back()
-		if back == nil {
+		victim := c.policy.peekVictim()
+		if victim == nil || !c.isExpired(victim, now) {
 			return
 		}
-		ent, ok := back.Value.(*entry)
-		if !ok {
-			c.removeElement(back)
-			metrics.CacheSize.Set(float64(c.ll.Len()))
-			continue
-		}
-		if now.After(ent.expiresAt) {
-			c.removeElement(back)
-			metrics.CacheOps.WithLabelValues("expired").Inc()
-			metrics.CacheSize.Set(float64(c.ll.Len()))
-			continue
-		}
-		return
+		c.removeEntry(victim)
+		metrics.CacheOps.WithLabelValues("expired").Inc()
 	}
 }
 
@@ -2,10 +2,15 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
 	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
 )
 
 func newOrder(id string) *domain.Order {
@@ -23,7 +28,7 @@ func mustSet(t *testing.T, c *LRUCacheTTL, order *domain.Order) {
 }
 
 func TestGetSet_HitMiss(t *testing.T) {
-	c := NewLRUCacheTTL(2, 5*time.Minute)
+	c := NewLRUCacheTTL(2, 0, 5*time.Minute, 0)
 	ctx := context.Background()
 
 	// miss
@@ -40,7 +45,7 @@ func TestGetSet_HitMiss(t *testing.T) {
 }
 
 func TestTTL_Expiry(t *testing.T) {
-	c := NewLRUCacheTTL(2, 100*time.Millisecond)
+	c := NewLRUCacheTTL(2, 0, 100*time.Millisecond, 0)
 	ctx := context.Background()
 
 	mustSet(t, c, newOrder("ttl"))
@@ -54,7 +59,7 @@ func TestTTL_Expiry(t *testing.T) {
 }
 
 func TestLRUEviction(t *testing.T) {
-	c := NewLRUCacheTTL(2, 0)
+	c := NewLRUCacheTTL(2, 0, 0, 0)
 	ctx := context.Background()
 
 	mustSet(t, c, newOrder("A"))
@@ -69,7 +74,7 @@ func TestLRUEviction(t *testing.T) {
 	if _, ok := c.Get(ctx, "B"); ok {
 		t.Fatalf("expected B to be evicted")
 	}
-	if _, ok := c.Get(ctx, "A"); !ok || c.ll.Len() != 2 {
+	if _, ok := c.Get(ctx, "A"); !ok || len(c.cache) != 2 {
 		t.Fatalf("expected A & C to stay in cache")
 	}
 }
@@ -77,7 +82,7 @@ func TestLRUEviction(t *testing.T) {
 func TestCloneImmutability(t *testing.T) {
 	const changedName = "changed"
 
-	c := NewLRUCacheTTL(1, 0)
+	c := NewLRUCacheTTL(1, 0, 0, 0)
 	ctx := context.Background()
 	orig := newOrder("Z")
 	mustSet(t, c, orig)
@@ -91,3 +96,217 @@ func TestCloneImmutability(t *testing.T) {
 		t.Fatalf("cache should return clones, not pointers to internal value")
 	}
 }
+
+func TestDeleteAndPurge(t *testing.T) {
+	c := NewLRUCacheTTL(10, 0, 0, 0)
+	ctx := context.Background()
+
+	mustSet(t, c, newOrder("A"))
+	mustSet(t, c, newOrder("B"))
+
+	c.Invalidate(ctx, "A")
+	if _, ok := c.Get(ctx, "A"); ok {
+		t.Fatalf("expected miss for deleted A")
+	}
+	if _, ok := c.Get(ctx, "B"); !ok {
+		t.Fatalf("B must stay after deleting A")
+	}
+	if err := c.Delete(ctx, "missing"); err != nil {
+		t.Fatalf("Delete of missing key must not fail: %v", err)
+	}
+
+	c.InvalidateAll(ctx)
+	if _, ok := c.Get(ctx, "B"); ok {
+		t.Fatalf("expected empty cache after purge")
+	}
+	mustSet(t, c, newOrder("C")) // после очистки кэш работает как обычно
+	if _, ok := c.Get(ctx, "C"); !ok {
+		t.Fatalf("expected hit after purge and Set")
+	}
+}
+
+func TestNegativeEntry(t *testing.T) {
+	c := NewLRUCacheTTL(10, 0, 0, 100*time.Millisecond)
+	ctx := context.Background()
+
+	if err := c.SetNegative(ctx, "missing"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	if got, ok := c.Get(ctx, "missing"); !ok || got != nil {
+		t.Fatalf("want negative hit, got ok=%v order=%+v", ok, got)
+	}
+
+	// негативная запись истекает даже без TTL у заказов и не продлевается чтением
+	time.Sleep(150 * time.Millisecond)
+	if _, ok := c.Get(ctx, "missing"); ok {
+		t.Fatalf("negative entry must expire")
+	}
+
+	// Set заменяет негативную запись, SetNegative не затирает заказ
+	if err := c.SetNegative(ctx, "A"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	mustSet(t, c, newOrder("A"))
+	if err := c.SetNegative(ctx, "A"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	if got, ok := c.Get(ctx, "A"); !ok || got == nil {
+		t.Fatalf("want order A, got ok=%v", ok)
+	}
+}
+
+func TestNegativeEntry_Disabled(t *testing.T) {
+	c := NewLRUCacheTTL(10, 0, time.Minute, 0)
+	ctx := context.Background()
+
+	if err := c.SetNegative(ctx, "missing"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	if _, ok := c.Get(ctx, "missing"); ok {
+		t.Fatalf("SetNegative must be a no-op when negativeTTL is 0")
+	}
+}
+
+// orderWithItems — заказ с n позициями (для проверки бюджета памяти).
+func orderWithItems(id string, n int) *domain.Order {
+	o := &domain.Order{OrderUID: id, Items: make([]domain.Item, n)}
+	for i := range o.Items {
+		o.Items[i] = domain.Item{Name: "item-name", Brand: "brand", RID: "rid-0000000000"}
+	}
+	return o
+}
+
+func TestEstimateSize_GrowsWithItems(t *testing.T) {
+	small := estimateSize("A", orderWithItems("A", 1))
+	big := estimateSize("A", orderWithItems("A", 100))
+	if small <= estimateSize("A", nil) || big-small < 99*itemStructSize {
+		t.Fatalf("size must reflect items: negative=%d small=%d big=%d", estimateSize("A", nil), small, big)
+	}
+}
+
+func TestByteBudget_EvictsLRUUntilUnderBudget(t *testing.T) {
+	one := estimateSize("A", orderWithItems("A", 1))
+	c := NewLRUCacheTTL(0, 4*one, 0, 0) // без лимита по количеству, бюджет ~4 маленьких заказа
+	ctx := context.Background()
+
+	for _, id := range []string{"A", "B", "C", "D"} {
+		mustSet(t, c, orderWithItems(id, 1))
+	}
+	if len(c.cache) != 4 || c.bytes > c.maxBytes {
+		t.Fatalf("4 small orders must fit: len=%d bytes=%d budget=%d", len(c.cache), c.bytes, c.maxBytes)
+	}
+	if _, ok := c.Get(ctx, "A"); !ok { // A — свежий
+		t.Fatalf("expected hit for A")
+	}
+
+	// заказ с тремя позициями вытесняет столько старых, сколько нужно (B, C), но не свежий A
+	mustSet(t, c, orderWithItems("E", 3))
+	if c.bytes > c.maxBytes {
+		t.Fatalf("cache over budget: %d > %d", c.bytes, c.maxBytes)
+	}
+	for id, want := range map[string]bool{"A": true, "B": false, "C": false, "E": true} {
+		if _, ok := c.Get(ctx, id); ok != want {
+			t.Fatalf("%s: hit=%v, want %v", id, ok, want)
+		}
+	}
+}
+
+func TestByteBudget_TooLargeAndGauge(t *testing.T) {
+	c := NewLRUCacheTTL(10, 4*estimateSize("A", orderWithItems("A", 1)), 0, 0)
+	ctx := context.Background()
+	before := testutil.ToFloat64(metrics.CacheBytes)
+
+	mustSet(t, c, orderWithItems("A", 1))
+	if got := testutil.ToFloat64(metrics.CacheBytes) - before; got != float64(c.bytes) || got <= 0 {
+		t.Fatalf("cache_bytes: want +%d, got %+v", c.bytes, got)
+	}
+
+	// заказ больше всего бюджета не кэшируется и убирает прежнюю версию
+	if err := c.Set(ctx, orderWithItems("A", 100)); !errors.Is(err, ErrOrderTooLarge) {
+		t.Fatalf("want ErrOrderTooLarge, got %v", err)
+	}
+	if _, ok := c.Get(ctx, "A"); ok {
+		t.Fatalf("stale version must be dropped")
+	}
+	if c.bytes != 0 || testutil.ToFloat64(metrics.CacheBytes) != before {
+		t.Fatalf("bytes must return to zero: cache=%d gauge=%v before=%v", c.bytes, testutil.ToFloat64(metrics.CacheBytes), before)
+	}
+
+	// обновление меняет оценку, Purge её обнуляет
+	mustSet(t, c, orderWithItems("B", 1))
+	mustSet(t, c, orderWithItems("B", 2))
+	if c.bytes != estimateSize("B", orderWithItems("B", 2)) {
+		t.Fatalf("update must resize entry: bytes=%d", c.bytes)
+	}
+	if err := c.Purge(ctx); err != nil || c.bytes != 0 || testutil.ToFloat64(metrics.CacheBytes) != before {
+		t.Fatalf("purge must reset bytes: err=%v bytes=%d", err, c.bytes)
+	}
+}
+
+// Заказ больше бюджета посреди пачки прогрева пропускается, остальные загружаются
+func TestWarmUp_SkipsTooLargeOrder(t *testing.T) {
+	ctx := context.Background()
+	budget := 4 * estimateSize("A", orderWithItems("A", 1))
+	batch := []*domain.Order{orderWithItems("A", 1), orderWithItems("huge", 100), orderWithItems("B", 1)}
+	before := testutil.ToFloat64(metrics.CacheOps.WithLabelValues("too_large"))
+
+	c := NewLRUCacheTTL(10, budget, 0, 0)
+	if err := c.WarmUp(ctx, batch); err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+	sharded := NewShardedLRUCache(2, PolicyLRU, 10, 2*budget, 0, 0)
+	if err := sharded.WarmUp(ctx, batch); err != nil {
+		t.Fatalf("sharded WarmUp: %v", err)
+	}
+
+	for _, id := range []string{"A", "B"} {
+		if _, ok := c.Get(ctx, id); !ok {
+			t.Fatalf("order %s after the oversized one must be warmed up", id)
+		}
+		if _, ok := sharded.Get(ctx, id); !ok {
+			t.Fatalf("sharded: order %s after the oversized one must be warmed up", id)
+		}
+	}
+	if _, ok := c.Get(ctx, "huge"); ok {
+		t.Fatalf("oversized order must not be cached")
+	}
+	if got := testutil.ToFloat64(metrics.CacheOps.WithLabelValues("too_large")) - before; got != 2 {
+		t.Fatalf("too_large: want +2, got %+v", got)
+	}
+	_ = c.Purge(ctx)
+	_ = sharded.Purge(ctx)
+}
+
+func TestKeysLenStats(t *testing.T) {
+	c := NewLRUCacheTTL(10, 0, time.Minute, time.Minute)
+	ctx := context.Background()
+
+	for _, id := range []string{"A", "B", "C"} {
+		mustSet(t, c, newOrder(id))
+	}
+	if err := c.SetNegative(ctx, "N"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	c.Get(ctx, "A")       // hit
+	c.Get(ctx, "N")       // негативная запись — тоже попадание
+	c.Get(ctx, "missing") // miss
+
+	if n, _ := c.Len(ctx); n != 4 {
+		t.Fatalf("Len = %d, want 4", n)
+	}
+	keys, _ := c.Keys(ctx, 0)
+	if want := []string{"N", "A", "C", "B"}; !slices.Equal(keys, want) {
+		t.Fatalf("Keys = %v, want LRU order %v", keys, want)
+	}
+	if keys, _ = c.Keys(ctx, 2); !slices.Equal(keys, []string{"N", "A"}) {
+		t.Fatalf("Keys(2) = %v", keys)
+	}
+
+	st, _ := c.Stats(ctx)
+	if st.Entries != 4 || st.Hits != 2 || st.Misses != 1 || st.Bytes != c.bytes || st.OldestAge <= 0 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+	if r := st.HitRatio(); r < 0.66 || r > 0.67 {
+		t.Fatalf("HitRatio = %v, want 2/3", r)
+	}
+}
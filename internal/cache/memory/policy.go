@@ -0,0 +1,170 @@
+package memory
+
+import (
+	"container/list"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Policy — политика вытеснения in-memory кэша.
+type Policy string
+
+// Поддерживаемые политики.
+const (
+	PolicyLRU      Policy = "lru"      // наименее давно использованный (по умолчанию)
+	PolicyLFU      Policy = "lfu"      // наименее часто использованный среди находящихся в кэше
+	PolicyWTinyLFU Policy = "wtinylfu" // W-TinyLFU: окно LRU + допуск по частоте (устойчив к сканированию)
+)
+
+// ParsePolicy — политика по имени из конфигурации (без учёта регистра; пустое — LRU).
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(strings.ToLower(strings.TrimSpace(s))); p {
+	case "":
+		return PolicyLRU, nil
+	case PolicyLRU, PolicyLFU, PolicyWTinyLFU:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown cache policy %q (want lru|lfu|wtinylfu)", s)
+	}
+}
+
+// evictionPolicy — порядок вытеснения записей кэша. Все методы вызываются под блокировкой кэша.
+type evictionPolicy interface {
+	add(ent *entry)                 // новая запись
+	touch(ent *entry)               // попадание или обновление записи
+	resize(ent *entry, delta int64) // изменилась оценка размера записи
+	remove(ent *entry)              // запись удалена (Delete, истечение TTL, вытеснение)
+	recordMiss(id string)           // промах по ключу (учёт частоты для допуска)
+	victim() *entry                 // запись на вытеснение, вызывается перед удалением (nil — кэш пуст)
+	peekVictim() *entry             // ближайший кандидат на вытеснение без изменения сегментов (nil — кэш пуст)
+	reset()                         // очистка (Purge)
+	walk(fn func(ent *entry) bool)  // обход от самых ценных записей к кандидатам на вытеснение (false — стоп)
+}
+
+// newPolicy — реализация политики. capacity/maxBytes — лимиты кэша: W-TinyLFU делит на сегменты
+// бюджет памяти, если он задан, иначе число элементов.
+func newPolicy(p Policy, capacity int, maxBytes int64) evictionPolicy {
+	switch p {
+	case PolicyLFU:
+		return newLFUPolicy()
+	case PolicyWTinyLFU:
+		return newWTinyLFU(capacity, maxBytes)
+	default:
+		return &lruPolicy{ll: list.New()}
+	}
+}
+
+// lruPolicy — классический LRU: попадание переносит запись в начало, вытесняется хвост.
+type lruPolicy struct {
+	ll *list.List
+}
+
+func (p *lruPolicy) add(ent *entry)            { ent.elem = p.ll.PushFront(ent) }
+func (p *lruPolicy) touch(ent *entry)          { p.ll.MoveToFront(ent.elem) }
+func (p *lruPolicy) resize(*entry, int64)      {}
+func (p *lruPolicy) remove(ent *entry)         { p.ll.Remove(ent.elem) }
+func (p *lruPolicy) recordMiss(string)         {}
+func (p *lruPolicy) reset()                    { p.ll.Init() }
+func (p *lruPolicy) victim() *entry            { return backEntry(p.ll) }
+func (p *lruPolicy) peekVictim() *entry        { return backEntry(p.ll) }
+func (p *lruPolicy) walk(fn func(*entry) bool) { walkList(p.ll, fn) }
+
+// lfuPolicy — LFU за O(1): списки записей по частоте обращений, внутри частоты — порядок LRU.
+// Частота считается только пока запись в кэше.
+type lfuPolicy struct {
+	buckets map[int]*list.List // частота → записи
+	minFreq int                // нижняя граница минимальной частоты (уточняется в victim)
+}
+
+func newLFUPolicy() *lfuPolicy {
+	return &lfuPolicy{buckets: make(map[int]*list.List)}
+}
+
+func (p *lfuPolicy) add(ent *entry) {
+	ent.freq = 1
+	ent.elem = p.bucket(1).PushFront(ent)
+	p.minFreq = 1
+}
+
+func (p *lfuPolicy) touch(ent *entry) {
+	p.remove(ent)
+	if p.minFreq == ent.freq && p.buckets[ent.freq] == nil {
+		p.minFreq++
+	}
+	ent.freq++
+	ent.elem = p.bucket(ent.freq).PushFront(ent)
+}
+
+func (p *lfuPolicy) resize(*entry, int64) {}
+
+func (p *lfuPolicy) remove(ent *entry) {
+	l := p.buckets[ent.freq]
+	l.Remove(ent.elem)
+	if l.Len() == 0 {
+		delete(p.buckets, ent.freq)
+	}
+}
+
+func (p *lfuPolicy) recordMiss(string) {}
+
+func (p *lfuPolicy) victim() *entry {
+	if len(p.buckets) == 0 {
+		return nil
+	}
+	for p.buckets[p.minFreq] == nil {
+		p.minFreq++
+	}
+	return backEntry(p.buckets[p.minFreq])
+}
+
+// peekVictim — тот же кандидат, что и victim: сдвиг minFreq лишь уточняет нижнюю границу.
+func (p *lfuPolicy) peekVictim() *entry { return p.victim() }
+
+func (p *lfuPolicy) reset() {
+	p.buckets = make(map[int]*list.List)
+	p.minFreq = 0
+}
+
+func (p *lfuPolicy) walk(fn func(*entry) bool) {
+	freqs := make([]int, 0, len(p.buckets))
+	for freq := range p.buckets {
+		freqs = append(freqs, freq)
+	}
+	slices.Sort(freqs)
+	for _, freq := range slices.Backward(freqs) {
+		if !walkList(p.buckets[freq], fn) {
+			return
+		}
+	}
+}
+
+// bucket — список записей с частотой freq (создаётся при необходимости).
+func (p *lfuPolicy) bucket(freq int) *list.List {
+	l, ok := p.buckets[freq]
+	if !ok {
+		l = list.New()
+		p.buckets[freq] = l
+	}
+	return l
+}
+
+// backEntry — запись в хвосте списка (nil — список пуст).
+func backEntry(l *list.List) *entry {
+	if back := l.Back(); back != nil {
+		if ent, ok := back.Value.(*entry); ok {
+			return ent
+		}
+	}
+	return nil
+}
+
+// walkList — обход записей списка от начала к хвосту; false — обход остановлен fn.
+func walkList(l *list.List, fn func(*entry) bool) bool {
+	for e := l.Front(); e != nil; e = e.Next() {
+		if ent, ok := e.Value.(*entry); ok && !fn(ent) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,311 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+)
+
+func TestParsePolicy(t *testing.T) {
+	cases := map[string]Policy{"": PolicyLRU, "lru": PolicyLRU, " LFU ": PolicyLFU, "WTinyLFU": PolicyWTinyLFU}
+	for in, want := range cases {
+		got, err := ParsePolicy(in)
+		if err != nil || got != want {
+			t.Fatalf("ParsePolicy(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParsePolicy("arc"); err == nil {
+		t.Fatalf("expected error for unknown policy")
+	}
+}
+
+func TestLFU_EvictsLeastFrequent(t *testing.T) {
+	c := NewCacheWithPolicy(PolicyLFU, 3, 0, 0, 0)
+	ctx := context.Background()
+
+	for _, id := range []string{"A", "B", "C"} {
+		mustSet(t, c, newOrder(id))
+	}
+	c.Get(ctx, "A")
+	c.Get(ctx, "A")
+	c.Get(ctx, "B")
+
+	// C — единственная запись с частотой 1: вытесняется она, хотя A добавлен раньше.
+	mustSet(t, c, newOrder("D"))
+	for id, want := range map[string]bool{"A": true, "B": true, "C": false, "D": true} {
+		if _, ok := c.cache[id]; ok != want {
+			t.Fatalf("%s present=%v, want %v", id, ok, want)
+		}
+	}
+
+	// Среди D (1) и B (2) вытесняется D.
+	mustSet(t, c, newOrder("E"))
+	if _, ok := c.cache["D"]; ok {
+		t.Fatalf("D must be evicted as least frequent")
+	}
+}
+
+// hotAfterScan — сколько из hot горячих заказов (по 5 чтений каждый) переживёт прогрев scan новыми заказами.
+func hotAfterScan(t *testing.T, policy Policy, capacity, hot, scan int) int {
+	t.Helper()
+	c := NewCacheWithPolicy(policy, capacity, 0, 0, 0)
+	ctx := context.Background()
+
+	for i := range hot {
+		mustSet(t, c, newOrder(fmt.Sprintf("hot-%d", i)))
+	}
+	for range 5 {
+		for i := range hot {
+			c.Get(ctx, fmt.Sprintf("hot-%d", i))
+		}
+	}
+
+	orders := make([]*domain.Order, 0, scan)
+	for i := range scan {
+		orders = append(orders, newOrder(fmt.Sprintf("scan-%d", i)))
+	}
+	if err := c.WarmUp(ctx, orders); err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+
+	left := 0
+	for i := range hot {
+		if _, ok := c.cache[fmt.Sprintf("hot-%d", i)]; ok {
+			left++
+		}
+	}
+	return left
+}
+
+func TestWTinyLFU_ScanResistant(t *testing.T) {
+	if left := hotAfterScan(t, PolicyLRU, 100, 50, 1000); left != 0 {
+		t.Fatalf("LRU: scan should flush hot orders, %d left", left)
+	}
+	if left := hotAfterScan(t, PolicyWTinyLFU, 100, 50, 1000); left < 45 {
+		t.Fatalf("W-TinyLFU: only %d of 50 hot orders survived the scan", left)
+	}
+}
+
+func TestWTinyLFU_ByteBudget(t *testing.T) {
+	maxBytes := 40 * estimateSize("order-00", orderWithItems("order-00", 1))
+	c := NewCacheWithPolicy(PolicyWTinyLFU, 0, maxBytes, 0, 0)
+	ctx := context.Background()
+
+	for i := range 500 {
+		id := fmt.Sprintf("order-%02d", i%60)
+		mustSet(t, c, orderWithItems(id, 1+i%3))
+		c.Get(ctx, fmt.Sprintf("order-%02d", i%7))
+	}
+
+	p, ok := c.policy.(*wTinyLFU)
+	if !ok {
+		t.Fatalf("unexpected policy %T", c.policy)
+	}
+	if c.bytes > maxBytes {
+		t.Fatalf("bytes %d exceed budget %d", c.bytes, maxBytes)
+	}
+	if p.windowWeight+p.mainWeight != c.bytes {
+		t.Fatalf("segment weights %d+%d != cache bytes %d", p.windowWeight, p.mainWeight, c.bytes)
+	}
+	if n := p.window.Len() + p.probation.Len() + p.protected.Len(); n != len(c.cache) {
+		t.Fatalf("segments hold %d entries, index %d", n, len(c.cache))
+	}
+
+	if err := c.Purge(ctx); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if p.windowWeight != 0 || p.mainWeight != 0 || p.window.Len()+p.probation.Len()+p.protected.Len() != 0 {
+		t.Fatalf("Purge must reset policy state")
+	}
+	mustSet(t, c, newOrder("after-purge"))
+}
+
+// Удаление истёкших записей, когда ничего не истекло, не переносит записи между сегментами W-TinyLFU
+func TestWTinyLFU_PruneWithoutExpiredKeepsSegments(t *testing.T) {
+	size := estimateSize("order-00", orderWithItems("order-00", 1))
+	c := NewCacheWithPolicy(PolicyWTinyLFU, 0, 100*size, time.Hour, 0)
+	ctx := context.Background()
+
+	for i := range 100 {
+		mustSet(t, c, orderWithItems(fmt.Sprintf("order-%02d", i), 1))
+	}
+	// Окно больше своей доли при заполненной основной области: место в основной области освобождено
+	// удалением, а запись окна выросла (в пределах общего бюджета, поэтому вытеснения нет).
+	if err := c.Delete(ctx, "order-00"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	mustSet(t, c, orderWithItems("order-99", 2))
+
+	p, ok := c.policy.(*wTinyLFU)
+	if !ok {
+		t.Fatalf("unexpected policy %T", c.policy)
+	}
+	if p.windowWeight <= p.windowMax || p.mainWeight+p.windowWeight <= p.mainMax {
+		t.Fatalf("setup: want window over its share and no room in main, window=%d/%d main=%d/%d",
+			p.windowWeight, p.windowMax, p.mainWeight, p.mainMax)
+	}
+	window, main, probationFront := p.windowWeight, p.mainWeight, p.probation.Front()
+
+	c.mu.Lock()
+	c.pruneExpired(time.Now())
+	c.mu.Unlock()
+
+	if p.windowWeight != window || p.mainWeight != main || p.probation.Front() != probationFront {
+		t.Fatalf("prune without expired entries changed segments: window %d→%d main %d→%d",
+			window, p.windowWeight, main, p.mainWeight)
+	}
+	if p.mainWeight > p.mainMax {
+		t.Fatalf("main area %d exceeds its share %d", p.mainWeight, p.mainMax)
+	}
+	if n := p.window.Len() + p.probation.Len() + p.protected.Len(); n != len(c.cache) {
+		t.Fatalf("segments hold %d entries, index %d", n, len(c.cache))
+	}
+}
+
+// traceOp — операция трассы обращений: чтение (при промахе заказ кладётся в кэш, как в GetOrder)
+// или запись без чтения (прогрев, сохранение из Kafka).
+type traceOp struct {
+	uid   string
+	write bool
+}
+
+// Параметры синтетической трассы: Zipf-популярность, периодический полный прогрев и всплески разовых UID.
+const (
+	traceOps       = 200_000
+	traceKeys      = 20_000
+	traceCapacity  = 1_000
+	traceScanEvery = 20_000 // прогрев WarmUpCache: traceScanSize записей подряд
+	traceScanSize  = 2_000
+	traceBurstEach = 5_000 // всплеск: traceBurstSize чтений несуществующих в кэше UID
+	traceBurstSize = 500
+)
+
+// syntheticTrace — воспроизводимая трасса (фиксированный seed).
+func syntheticTrace() []traceOp {
+	r := rand.New(rand.NewPCG(19, 19))
+	zipf := rand.NewZipf(r, 1.1, 1, traceKeys-1)
+
+	ops := make([]traceOp, 0, traceOps+traceOps/traceScanEvery*traceScanSize+traceOps/traceBurstEach*traceBurstSize)
+	scanned, once := 0, 0
+	for i := range traceOps {
+		if i > 0 && i%traceScanEvery == 0 {
+			for range traceScanSize {
+				ops = append(ops, traceOp{uid: fmt.Sprintf("scan-%d", scanned), write: true})
+				scanned++
+			}
+		}
+		if i > 0 && i%traceBurstEach == 0 {
+			for range traceBurstSize {
+				ops = append(ops, traceOp{uid: fmt.Sprintf("once-%d", once)})
+				once++
+			}
+		}
+		ops = append(ops, traceOp{uid: fmt.Sprintf("order-%d", zipf.Uint64())})
+	}
+	return ops
+}
+
+// loadTrace — записанная трасса из файла CACHE_TRACE: по order_uid на строку,
+// префикс "set " — запись без чтения; пустые строки и строки с '#' пропускаются.
+func loadTrace(tb testing.TB, path string) []traceOp {
+	tb.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		tb.Fatalf("open trace: %v", err)
+	}
+	defer f.Close()
+
+	var ops []traceOp
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if uid, ok := strings.CutPrefix(line, "set "); ok {
+			ops = append(ops, traceOp{uid: strings.TrimSpace(uid), write: true})
+			continue
+		}
+		ops = append(ops, traceOp{uid: line})
+	}
+	if err := sc.Err(); err != nil {
+		tb.Fatalf("read trace: %v", err)
+	}
+	return ops
+}
+
+// replay — проигрывает трассу на кэше и возвращает долю попаданий среди чтений.
+func replay(tb testing.TB, c *LRUCacheTTL, ops []traceOp) float64 {
+	tb.Helper()
+	ctx := context.Background()
+	hits, reads := 0, 0
+	for _, op := range ops {
+		if !op.write {
+			reads++
+			if _, ok := c.Get(ctx, op.uid); ok {
+				hits++
+				continue
+			}
+		}
+		if err := c.Set(ctx, newOrder(op.uid)); err != nil {
+			tb.Fatalf("Set(%q): %v", op.uid, err)
+		}
+	}
+	if reads == 0 {
+		return 0
+	}
+	return float64(hits) / float64(reads)
+}
+
+var allPolicies = []Policy{PolicyLRU, PolicyLFU, PolicyWTinyLFU}
+
+func TestPolicy_HitRatio_ScanTrace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("trace replay in -short mode")
+	}
+	ops := syntheticTrace()
+
+	ratio := make(map[Policy]float64, len(allPolicies))
+	for _, p := range allPolicies {
+		ratio[p] = replay(t, NewCacheWithPolicy(p, traceCapacity, 0, 0, 0), ops)
+		t.Logf("%-8s hit ratio %.2f%%", p, ratio[p]*100)
+	}
+	if ratio[PolicyWTinyLFU] <= ratio[PolicyLRU] {
+		t.Fatalf("W-TinyLFU hit ratio %.4f must beat LRU %.4f on a trace with scans", ratio[PolicyWTinyLFU], ratio[PolicyLRU])
+	}
+}
+
+// BenchmarkPolicy_HitRatio — доля попаданий политик на трассе (метрика hit%).
+// По умолчанию — синтетическая трасса; CACHE_TRACE=<файл> — записанная, CACHE_TRACE_CAPACITY — ёмкость кэша.
+//
+//	go test -run '^$' -bench BenchmarkPolicy_HitRatio ./internal/cache/memory
+func BenchmarkPolicy_HitRatio(b *testing.B) {
+	ops := syntheticTrace()
+	if path := os.Getenv("CACHE_TRACE"); path != "" {
+		ops = loadTrace(b, path)
+	}
+	capacity := traceCapacity
+	if v := os.Getenv("CACHE_TRACE_CAPACITY"); v != "" {
+		if _, err := fmt.Sscan(v, &capacity); err != nil {
+			b.Fatalf("CACHE_TRACE_CAPACITY: %v", err)
+		}
+	}
+
+	for _, p := range allPolicies {
+		b.Run(string(p), func(b *testing.B) {
+			var ratio float64
+			for i := 0; i < b.N; i++ {
+				ratio = replay(b, NewCacheWithPolicy(p, capacity, 0, 0, 0), ops)
+			}
+			b.ReportMetric(ratio*100, "hit%")
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(ops)), "ns/access")
+		})
+	}
+}
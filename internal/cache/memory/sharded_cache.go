@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+)
+
+// Проверка, что ShardedLRUCache удовлетворяет интерфейсам OrderCache и CacheInvalidator.
+var (
+	_ ports.OrderCache       = (*ShardedLRUCache)(nil)
+	_ ports.CacheInvalidator = (*ShardedLRUCache)(nil)
+)
+
+// ShardedLRUCache — N независимых LRUCacheTTL, шард выбирается по хэшу UID (FNV-1a).
+// Каждый шард под своим мьютексом, поэтому запросы разных UID не конкурируют за одну блокировку.
+// TTL, негативные записи и метрики — как у LRUCacheTTL; политика вытеснения работает внутри шарда,
+// т.е. глобальный порядок приближённый: для LRU вытесняется самый старый элемент заполненного шарда.
+type ShardedLRUCache struct {
+	shards    []*LRUCacheTTL
+	nextSweep atomic.Uint32 // номер следующего обхода SweepExpired (выбор первого шарда)
+}
+
+// NewShardedLRUCache — кэш из shards шардов с политикой вытеснения policy, общей ёмкостью capacity
+// и бюджетом памяти maxBytes (на шард — доля лимита с округлением вверх; 0 — без ограничения,
+// как у NewLRUCacheTTL). Если shards <= 0, используется 1. Заказ больше бюджета шарда не кэшируется
+// (ErrOrderTooLarge).
+func NewShardedLRUCache(shards int, policy Policy, capacity int, maxBytes int64, ttl, negativeTTL time.Duration) *ShardedLRUCache {
+	if shards <= 0 {
+		shards = 1
+	}
+	perShard := (max(capacity, 0) + shards - 1) / shards
+	perShardBytes := (max(maxBytes, 0) + int64(shards) - 1) / int64(shards)
+	if perShard == 0 && perShardBytes == 0 {
+		perShard = 1
+	}
+
+	c := &ShardedLRUCache{shards: make([]*LRUCacheTTL, shards)}
+	for i := range c.shards {
+		c.shards[i] = NewCacheWithPolicy(policy, perShard, perShardBytes, ttl, negativeTTL)
+	}
+	return c
+}
+
+// Get — вернуть заказ по id из его шарда (семантика — как у LRUCacheTTL.Get).
+func (c *ShardedLRUCache) Get(ctx context.Context, id string) (*domain.Order, bool) {
+	return c.shard(id).Get(ctx, id)
+}
+
+// Set — сохранить/обновить заказ в его шарде.
+func (c *ShardedLRUCache) Set(ctx context.Context, order *domain.Order) error {
+	if order == nil || order.OrderUID == "" {
+		return ErrInvalidOrder
+	}
+	return c.shard(order.OrderUID).Set(ctx, order)
+}
+
+// SetNegative — запомнить отсутствие заказа в его шарде.
+func (c *ShardedLRUCache) SetNegative(ctx context.Context, id string) error {
+	return c.shard(id).SetNegative(ctx, id)
+}
+
+// WarmUp — массовая загрузка кэша (например, при запуске); заказ больше бюджета шарда пропускается.
+func (c *ShardedLRUCache) WarmUp(ctx context.Context, orders []*domain.Order) error {
+	for _, order := range orders {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.Set(ctx, order); err != nil && !errors.Is(err, ErrOrderTooLarge) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete — удалить заказ из кэша (отсутствие записи — не ошибка).
+func (c *ShardedLRUCache) Delete(ctx context.Context, id string) error {
+	return c.shard(id).Delete(ctx, id)
+}
+
+// Purge — очистить все шарды.
+func (c *ShardedLRUCache) Purge(ctx context.Context) error {
+	errs := make([]error, 0, len(c.shards))
+	for _, s := range c.shards {
+		errs = append(errs, s.Purge(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Len — суммарное число записей шардов.
+func (c *ShardedLRUCache) Len(ctx context.Context) (int, error) {
+	n := 0
+	for _, s := range c.shards {
+		l, _ := s.Len(ctx)
+		n += l
+	}
+	return n, nil
+}
+
+// Keys — не более limit UID: шарды по очереди, внутри шарда — в порядке политики вытеснения
+// (глобального порядка у шардированного кэша нет). limit <= 0 — все.
+func (c *ShardedLRUCache) Keys(ctx context.Context, limit int) ([]string, error) {
+	var keys []string
+	for _, s := range c.shards {
+		rest := 0
+		if limit > 0 {
+			if rest = limit - len(keys); rest <= 0 {
+				break
+			}
+		}
+		shardKeys, _ := s.Keys(ctx, rest)
+		keys = append(keys, shardKeys...)
+	}
+	return keys, nil
+}
+
+// Stats — сумма по шардам; возраст — самой давней записи среди всех шардов.
+func (c *ShardedLRUCache) Stats(ctx context.Context) (ports.CacheStats, error) {
+	var total ports.CacheStats
+	for _, s := range c.shards {
+		st, _ := s.Stats(ctx)
+		total.Entries += st.Entries
+		total.Bytes += st.Bytes
+		total.Hits += st.Hits
+		total.Misses += st.Misses
+		total.OldestAge = max(total.OldestAge, st.OldestAge)
+	}
+	return total, nil
+}
+
+// Invalidate — сбросить локальную копию заказа, изменённого другим экземпляром сервиса.
+func (c *ShardedLRUCache) Invalidate(ctx context.Context, id string) { _ = c.Delete(ctx, id) }
+
+// InvalidateAll — сбросить все локальные копии (например, после пропуска уведомлений об изменениях).
+func (c *ShardedLRUCache) InvalidateAll(ctx context.Context) { _ = c.Purge(ctx) }
+
+// shard — шард для UID: FNV-1a (32 бита) без аллокаций.
+func (c *ShardedLRUCache) shard(id string) *LRUCacheTTL {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(id); i++ {
+		h ^= uint32(id[i])
+		h *= prime32
+	}
+	return c.shards[h%uint32(len(c.shards))]
+}
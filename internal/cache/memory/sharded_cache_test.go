@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+)
+
+func TestSharded_SpreadsKeysAndKeepsSemantics(t *testing.T) {
+	c := NewShardedLRUCache(8, PolicyLRU, 800, 0, time.Minute, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 200; i++ {
+		id := "uid-" + strconv.Itoa(i)
+		if err := c.Set(ctx, newOrder(id)); err != nil {
+			t.Fatalf("Set(%q): %v", id, err)
+		}
+	}
+	for i, s := range c.shards {
+		if len(s.cache) == 0 {
+			t.Fatalf("shard %d is empty: keys are not spread", i)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		if _, ok := c.Get(ctx, "uid-"+strconv.Itoa(i)); !ok {
+			t.Fatalf("expected hit for uid-%d", i)
+		}
+	}
+
+	if err := c.Set(ctx, nil); err != ErrInvalidOrder {
+		t.Fatalf("want ErrInvalidOrder, got %v", err)
+	}
+
+	c.Invalidate(ctx, "uid-0")
+	if _, ok := c.Get(ctx, "uid-0"); ok {
+		t.Fatalf("expected miss after Invalidate")
+	}
+	c.InvalidateAll(ctx)
+	if _, ok := c.Get(ctx, "uid-1"); ok {
+		t.Fatalf("expected empty cache after InvalidateAll")
+	}
+}
+
+func TestSharded_EvictionIsPerShard(t *testing.T) {
+	c := NewShardedLRUCache(4, PolicyLRU, 4, 0, 0, 0) // по одному элементу на шард
+	ctx := context.Background()
+
+	// два UID, попадающие в один шард
+	first := "uid-0"
+	second := ""
+	for i := 1; second == ""; i++ {
+		if id := "uid-" + strconv.Itoa(i); c.shard(id) == c.shard(first) {
+			second = id
+		}
+	}
+
+	mustSet(t, c.shard(first), newOrder(first))
+	if err := c.Set(ctx, newOrder(second)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, ok := c.Get(ctx, first); ok {
+		t.Fatalf("expected %s to be evicted by %s from the same shard", first, second)
+	}
+	if _, ok := c.Get(ctx, second); !ok {
+		t.Fatalf("expected hit for %s", second)
+	}
+}
+
+func TestSharded_CacheSizeGauge(t *testing.T) {
+	c := NewShardedLRUCache(4, PolicyLRU, 100, 0, time.Minute, 0)
+	ctx := context.Background()
+	before := testutil.ToFloat64(metrics.CacheSize)
+
+	for i := 0; i < 10; i++ {
+		mustSetSharded(t, c, "uid-"+strconv.Itoa(i))
+	}
+	if got := testutil.ToFloat64(metrics.CacheSize) - before; got != 10 {
+		t.Fatalf("cache_size must sum all shards: want +10, got %+v", got)
+	}
+
+	if err := c.Purge(ctx); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.CacheSize); got != before {
+		t.Fatalf("cache_size after purge: want %v, got %v", before, got)
+	}
+}
+
+func TestSharded_ConcurrentAccess(t *testing.T) {
+	c := NewShardedLRUCache(8, PolicyLRU, 64, 0, time.Minute, time.Minute)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				id := "uid-" + strconv.Itoa((g*31+i)%128)
+				switch i % 3 {
+				case 0:
+					_ = c.Set(ctx, newOrder(id))
+				case 1:
+					_ = c.SetNegative(ctx, id)
+				default:
+					c.Get(ctx, id)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, s := range c.shards {
+		total += len(s.cache)
+		if len(s.cache) > s.capacity {
+			t.Fatalf("shard exceeds its capacity: %d > %d", len(s.cache), s.capacity)
+		}
+	}
+	if total > 64 {
+		t.Fatalf("cache exceeds total capacity: %d", total)
+	}
+}
+
+func mustSetSharded(t *testing.T, c *ShardedLRUCache, id string) {
+	t.Helper()
+	if err := c.Set(context.Background(), newOrder(id)); err != nil {
+		t.Fatalf("Set(%q) error: %v", id, err)
+	}
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"container/list"
+	"unsafe"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+)
+
+// entryOverhead — оценка накладных расходов на запись помимо самого заказа:
+// элементы списков политики и очистки, entry и слот map (ключ-строка + указатель, с запасом на служебные байты бакета).
+const entryOverhead = int64(2*unsafe.Sizeof(list.Element{}) + unsafe.Sizeof(entry{}) + 48)
+
+// Размеры структур без содержимого строк и слайсов.
+const (
+	orderStructSize = int64(unsafe.Sizeof(domain.Order{}))
+	itemStructSize  = int64(unsafe.Sizeof(domain.Item{}))
+)
+
+// estimateSize — приблизительный объём памяти записи кэша в байтах: структуры, байты строк
+// и массив позиций (по cap, как он лежит в куче). Точность — десятки байт на заказ: этого достаточно,
+// чтобы бюджет отражал разницу между заказом с одной позицией и с сотнями.
+// order == nil — негативная запись (только ключ и накладные расходы).
+func estimateSize(id string, order *domain.Order) int64 {
+	n := entryOverhead + int64(len(id))
+	if order == nil {
+		return n
+	}
+
+	n += orderStructSize + strBytes(
+		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+		order.CustomerID, order.DeliveryService, order.ShardKey, order.OofShard,
+	)
+
+	d := &order.Delivery
+	n += strBytes(d.Name, d.Phone, d.Zip, d.City, d.Address, d.Region, d.Email)
+
+	p := &order.Payment
+	n += strBytes(p.Transaction, p.RequestID, p.Currency, p.Provider, p.Bank)
+
+	n += int64(cap(order.Items)) * itemStructSize
+	for i := range order.Items {
+		it := &order.Items[i]
+		n += strBytes(it.TrackNumber, it.RID, it.Name, it.Size, it.Brand)
+	}
+	return n
+}
+
+// strBytes — суммарная длина строк.
+func strBytes(ss ...string) int64 {
+	var n int64
+	for _, s := range ss {
+		n += int64(len(s))
+	}
+	return n
+}
@@ -0,0 +1,305 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+)
+
+// Снимок кэша: магическая строка, заголовок (версия формата, отпечаток схемы заказа, время записи)
+// и записи в gob — от самых ценных для политики вытеснения к кандидатам на вытеснение.
+const (
+	snapshotMagic   = "WBL0-CACHE-SNAPSHOT\n"
+	snapshotVersion = 1
+)
+
+// ErrSnapshotStale — снимок другой версии формата или схемы domain.Order: его нужно игнорировать.
+var ErrSnapshotStale = errors.New("cache snapshot is stale: format or order schema changed")
+
+// Snapshotter — кэш, который умеет сохранять и восстанавливать своё содержимое.
+type Snapshotter interface {
+	WriteSnapshot(w io.Writer) (int, error)
+	ReadSnapshot(r io.Reader) (int, error)
+}
+
+// Проверка, что кэши умеют снимки.
+var (
+	_ Snapshotter = (*LRUCacheTTL)(nil)
+	_ Snapshotter = (*ShardedLRUCache)(nil)
+)
+
+// snapshotHeader — заголовок снимка.
+type snapshotHeader struct {
+	Version   int
+	Schema    uint64
+	CreatedAt time.Time
+	Count     int
+}
+
+// snapshotRecord — запись снимка. Order == nil — негативная запись; TTL — остаток срока (0 — без срока).
+type snapshotRecord struct {
+	ID    string
+	Order *domain.Order
+	TTL   time.Duration
+}
+
+// orderSchema — отпечаток схемы domain.Order (имена, типы и json-теги полей, включая вложенные):
+// снимок, записанный до изменения модели, отбрасывается, а не восстанавливается с потерей полей.
+var orderSchema = schemaFingerprint(reflect.TypeOf(domain.Order{}))
+
+// SaveSnapshot — записать снимок кэша в файл path атомарно (временный файл + rename).
+// Возвращает число записанных записей.
+func SaveSnapshot(path string, c Snapshotter) (n int, err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return 0, fmt.Errorf("create snapshot: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(tmp)
+	if n, err = c.WriteSnapshot(w); err != nil {
+		return 0, err
+	}
+	if err = w.Flush(); err != nil {
+		return 0, fmt.Errorf("write snapshot: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return 0, fmt.Errorf("sync snapshot: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return 0, fmt.Errorf("close snapshot: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return 0, fmt.Errorf("rename snapshot: %w", err)
+	}
+	return n, nil
+}
+
+// LoadSnapshot — восстановить кэш из файла path и удалить файл: снимок одноразовый, чтобы после
+// аварийной остановки (без записи нового) не поднять ещё раз устаревшие данные.
+// Отсутствие файла — ошибка os.ErrNotExist, снимок старой версии — ErrSnapshotStale (файл тоже удаляется).
+func LoadSnapshot(path string, c Snapshotter) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	n, err := c.ReadSnapshot(bufio.NewReader(f))
+	_ = f.Close()
+	if rmErr := os.Remove(path); rmErr != nil && err == nil {
+		err = fmt.Errorf("remove snapshot: %w", rmErr)
+	}
+	return n, err
+}
+
+// WriteSnapshot — записать содержимое кэша (актуальные записи с остатком TTL) в порядке политики вытеснения.
+func (c *LRUCacheTTL) WriteSnapshot(w io.Writer) (int, error) {
+	now := time.Now()
+	recs := c.snapshotRecords(now)
+	return len(recs), writeSnapshot(w, recs, now)
+}
+
+// ReadSnapshot — загрузить записи снимка. Остаток TTL уменьшается на время, прошедшее с записи снимка,
+// и ограничивается текущими TTL/negativeTTL; истёкшие записи пропускаются. Возвращает число загруженных.
+func (c *LRUCacheTTL) ReadSnapshot(r io.Reader) (int, error) {
+	recs, age, err := readSnapshot(r, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return c.restore(recs, age, time.Now()), nil
+}
+
+// WriteSnapshot — записать содержимое всех шардов.
+func (c *ShardedLRUCache) WriteSnapshot(w io.Writer) (int, error) {
+	now := time.Now()
+	var recs []snapshotRecord
+	for _, s := range c.shards {
+		recs = append(recs, s.snapshotRecords(now)...)
+	}
+	return len(recs), writeSnapshot(w, recs, now)
+}
+
+// ReadSnapshot — загрузить записи снимка в их шарды (порядок внутри шарда сохраняется).
+func (c *ShardedLRUCache) ReadSnapshot(r io.Reader) (int, error) {
+	recs, age, err := readSnapshot(r, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	perShard := make(map[*LRUCacheTTL][]snapshotRecord, len(c.shards))
+	for _, rec := range recs {
+		s := c.shard(rec.ID)
+		perShard[s] = append(perShard[s], rec)
+	}
+	now, n := time.Now(), 0
+	for s, shardRecs := range perShard {
+		n += s.restore(shardRecs, age, now)
+	}
+	return n, nil
+}
+
+// snapshotRecords — актуальные записи в порядке политики (под блокировкой; заказы не копируются:
+// сохранённый заказ не меняется на месте).
+func (c *LRUCacheTTL) snapshotRecords(now time.Time) []snapshotRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recs := make([]snapshotRecord, 0, len(c.cache))
+	c.policy.walk(func(ent *entry) bool {
+		if c.isExpired(ent, now) {
+			return true
+		}
+		rec := snapshotRecord{ID: ent.id, Order: ent.order}
+		if !ent.expiresAt.IsZero() {
+			rec.TTL = max(ent.expiresAt.Sub(now), time.Nanosecond)
+		}
+		recs = append(recs, rec)
+		return true
+	})
+	return recs
+}
+
+// restore — вставка записей снимка: от кандидатов на вытеснение к самым ценным, чтобы порядок
+// политики совпал с сохранённым. Уже имеющиеся записи не трогаются (они новее снимка).
+func (c *LRUCacheTTL) restore(recs []snapshotRecord, age time.Duration, now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for i := len(recs) - 1; i >= 0; i-- {
+		rec := recs[i]
+		if rec.ID == "" {
+			continue
+		}
+		if _, found := c.cache[rec.ID]; found {
+			continue
+		}
+		expiresAt, ok := c.restoredExpiry(rec, age, now)
+		if !ok {
+			continue
+		}
+		size := estimateSize(rec.ID, rec.Order)
+		if c.maxBytes > 0 && size > c.maxBytes {
+			continue
+		}
+		c.insert(&entry{id: rec.ID, order: rec.Order, expiresAt: expiresAt, storedAt: now, size: size})
+		c.evictOverflow(now)
+		n++
+	}
+	return n
+}
+
+// restoredExpiry — срок записи снимка с учётом его возраста и текущих TTL (false — запись не нужна).
+func (c *LRUCacheTTL) restoredExpiry(rec snapshotRecord, age time.Duration, now time.Time) (time.Time, bool) {
+	limit := c.ttl
+	if rec.Order == nil {
+		if c.negativeTTL <= 0 {
+			return time.Time{}, false
+		}
+		limit = c.negativeTTL
+	}
+
+	left := limit
+	if rec.TTL > 0 {
+		if left = rec.TTL - age; left <= 0 {
+			return time.Time{}, false
+		}
+		if limit > 0 {
+			left = min(left, limit)
+		}
+	}
+	if limit <= 0 {
+		return time.Time{}, true
+	}
+	return now.Add(left), true
+}
+
+// writeSnapshot — магическая строка, заголовок и записи.
+func writeSnapshot(w io.Writer, recs []snapshotRecord, now time.Time) error {
+	if _, err := io.WriteString(w, snapshotMagic); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	enc := gob.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{
+		Version: snapshotVersion, Schema: orderSchema, CreatedAt: now, Count: len(recs),
+	}); err != nil {
+		return fmt.Errorf("write snapshot header: %w", err)
+	}
+	for i := range recs {
+		if err := enc.Encode(&recs[i]); err != nil {
+			return fmt.Errorf("write snapshot record %q: %w", recs[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// readSnapshot — записи снимка и его возраст. Чужой формат, другая версия или схема — ErrSnapshotStale.
+func readSnapshot(r io.Reader, now time.Time) ([]snapshotRecord, time.Duration, error) {
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, []byte(snapshotMagic)) {
+		return nil, 0, ErrSnapshotStale
+	}
+
+	dec := gob.NewDecoder(r)
+	var hdr snapshotHeader
+	if err := dec.Decode(&hdr); err != nil {
+		return nil, 0, fmt.Errorf("read snapshot header: %w", err)
+	}
+	if hdr.Version != snapshotVersion || hdr.Schema != orderSchema {
+		return nil, 0, ErrSnapshotStale
+	}
+
+	recs := make([]snapshotRecord, 0, min(max(hdr.Count, 0), 1<<20))
+	for range hdr.Count {
+		var rec snapshotRecord
+		if err := dec.Decode(&rec); err != nil {
+			return nil, 0, fmt.Errorf("read snapshot record: %w", err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, max(now.Sub(hdr.CreatedAt), 0), nil
+}
+
+// schemaFingerprint — FNV-1a по описанию типа: для структур пакета domain — поля с типами и тегами
+// (рекурсивно), для остальных типов — их имя.
+func schemaFingerprint(t reflect.Type) uint64 {
+	h := fnv.New64a()
+	describeType(h, t, domainPkg)
+	return h.Sum64()
+}
+
+// domainPkg — путь пакета доменных типов.
+var domainPkg = reflect.TypeOf(domain.Order{}).PkgPath()
+
+// describeType — текстовое описание типа для отпечатка схемы.
+func describeType(w io.Writer, t reflect.Type, pkg string) {
+	switch {
+	case t.Kind() == reflect.Struct && t.PkgPath() == pkg:
+		fmt.Fprintf(w, "%s{", t.Name())
+		for i := range t.NumField() {
+			f := t.Field(i)
+			fmt.Fprintf(w, "%s %q ", f.Name, f.Tag)
+			describeType(w, f.Type, pkg)
+			fmt.Fprint(w, ";")
+		}
+		fmt.Fprint(w, "}")
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Pointer:
+		fmt.Fprintf(w, "%s ", t.Kind())
+		describeType(w, t.Elem(), pkg)
+	default:
+		fmt.Fprint(w, t.String())
+	}
+}
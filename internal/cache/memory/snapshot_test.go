@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_RoundTripKeepsLRUOrder(t *testing.T) {
+	src := NewLRUCacheTTL(3, 0, time.Minute, time.Minute)
+	ctx := context.Background()
+	for _, id := range []string{"A", "B", "C"} {
+		mustSet(t, src, newOrder(id))
+	}
+	src.Get(ctx, "A") // порядок LRU: A, C, B
+	if err := src.SetNegative(ctx, "missing"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := src.WriteSnapshot(&buf)
+	if err != nil || n != 3 {
+		t.Fatalf("WriteSnapshot = %d, %v; want 3 entries", n, err)
+	}
+
+	dst := NewLRUCacheTTL(3, 0, time.Minute, time.Minute)
+	if n, err = dst.ReadSnapshot(&buf); err != nil || n != 3 {
+		t.Fatalf("ReadSnapshot = %d, %v; want 3 entries", n, err)
+	}
+	if _, ok := dst.cache["B"]; ok {
+		t.Fatalf("B was evicted by SetNegative in source and must not be restored")
+	}
+	if order, hit := dst.Get(ctx, "missing"); !hit || order != nil {
+		t.Fatalf("negative entry must survive snapshot: %v, %v", order, hit)
+	}
+	got, ok := dst.Get(ctx, "A")
+	if !ok || got.OrderUID != "A" || len(got.Items) != 1 {
+		t.Fatalf("restored order mismatch: %+v", got)
+	}
+
+	// Порядок восстановлен: наименее используемый — C.
+	mustSet(t, dst, newOrder("D"))
+	if _, ok := dst.cache["C"]; ok {
+		t.Fatalf("C must be evicted first after restore")
+	}
+}
+
+func TestSnapshot_AgeReducesTTL(t *testing.T) {
+	c := NewLRUCacheTTL(10, 0, time.Minute, 0)
+	now := time.Now()
+	recs := []snapshotRecord{
+		{ID: "fresh", Order: newOrder("fresh"), TTL: 50 * time.Second},
+		{ID: "stale", Order: newOrder("stale"), TTL: 5 * time.Second},
+		{ID: "long", Order: newOrder("long"), TTL: time.Hour}, // TTL уменьшили в конфигурации
+		{ID: "neg"}, // негативное кэширование выключено
+	}
+
+	var buf bytes.Buffer
+	if err := writeSnapshot(&buf, recs, now.Add(-10*time.Second)); err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+	if n, err := c.ReadSnapshot(&buf); err != nil || n != 2 {
+		t.Fatalf("ReadSnapshot = %d, %v; want 2", n, err)
+	}
+
+	if _, ok := c.cache["stale"]; ok {
+		t.Fatalf("entry expired while the service was down must be skipped")
+	}
+	if _, ok := c.cache["neg"]; ok {
+		t.Fatalf("negative entry must be skipped when negative caching is off")
+	}
+	if left := time.Until(c.cache["fresh"].expiresAt); left > 41*time.Second || left < 35*time.Second {
+		t.Fatalf("fresh TTL must be reduced by snapshot age, left %v", left)
+	}
+	if left := time.Until(c.cache["long"].expiresAt); left > time.Minute {
+		t.Fatalf("TTL must be capped by current config, left %v", left)
+	}
+}
+
+func TestSnapshot_StaleVersionOrSchema(t *testing.T) {
+	c := NewLRUCacheTTL(10, 0, time.Minute, 0)
+
+	if _, err := c.ReadSnapshot(bytes.NewBufferString("not a snapshot")); !errors.Is(err, ErrSnapshotStale) {
+		t.Fatalf("want ErrSnapshotStale for foreign file, got %v", err)
+	}
+
+	old := orderSchema
+	orderSchema = old + 1 // снимок записан со старой схемой заказа
+	var buf bytes.Buffer
+	err := writeSnapshot(&buf, []snapshotRecord{{ID: "A", Order: newOrder("A")}}, time.Now())
+	orderSchema = old
+	if err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+	if n, err := c.ReadSnapshot(&buf); !errors.Is(err, ErrSnapshotStale) || n != 0 {
+		t.Fatalf("ReadSnapshot = %d, %v; want ErrSnapshotStale", n, err)
+	}
+}
+
+func TestSchemaFingerprint_DetectsFieldChanges(t *testing.T) {
+	type v1 struct {
+		ID string `json:"id"`
+	}
+	type v2 struct {
+		ID   string `json:"id"`
+		Note string `json:"note"`
+	}
+	pkg := reflect.TypeOf(v1{}).PkgPath()
+	fp := func(t reflect.Type) string {
+		var b bytes.Buffer
+		describeType(&b, t, pkg)
+		return b.String()
+	}
+	if fp(reflect.TypeOf(v1{})) == fp(reflect.TypeOf(v2{})) {
+		t.Fatalf("added field must change fingerprint")
+	}
+	if schemaFingerprint(reflect.TypeOf(v1{})) != schemaFingerprint(reflect.TypeOf(v1{})) {
+		t.Fatalf("fingerprint must be stable")
+	}
+}
+
+func TestSnapshot_FileShardedRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := NewShardedLRUCache(4, PolicyWTinyLFU, 100, 0, time.Minute, 0)
+	for i := range 40 {
+		if err := src.Set(ctx, newOrder("uid-"+strconv.Itoa(i))); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	if n, err := SaveSnapshot(path, src); err != nil || n != 40 {
+		t.Fatalf("SaveSnapshot = %d, %v; want 40", n, err)
+	}
+
+	dst := NewShardedLRUCache(4, PolicyWTinyLFU, 100, 0, time.Minute, 0)
+	if n, err := LoadSnapshot(path, dst); err != nil || n != 40 {
+		t.Fatalf("LoadSnapshot = %d, %v; want 40", n, err)
+	}
+	for i := range 40 {
+		if _, ok := dst.Get(ctx, "uid-"+strconv.Itoa(i)); !ok {
+			t.Fatalf("uid-%d not restored", i)
+		}
+	}
+
+	// Снимок одноразовый: после загрузки файл удалён.
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("snapshot file must be removed after load, stat err: %v", err)
+	}
+	if _, err := LoadSnapshot(path, dst); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("want os.ErrNotExist for missing snapshot, got %v", err)
+	}
+}
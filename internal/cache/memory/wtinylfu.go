@@ -0,0 +1,290 @@
+package memory
+
+import (
+	"container/list"
+	"math/bits"
+)
+
+// Сегменты W-TinyLFU.
+const (
+	segWindow    uint8 = iota // окно: новые записи, чистый LRU
+	segProbation              // основная область, испытательный сегмент
+	segProtected              // основная область, записи с повторными обращениями
+)
+
+// Доли сегментов W-TinyLFU (как в Caffeine): окно — 1% лимита, защищённый — 80% основной области.
+const (
+	windowPercent    = 1
+	protectedPercent = 80
+)
+
+// wTinyLFU — W-TinyLFU: новые записи попадают в небольшое LRU-окно; вытесненная из окна запись
+// попадает в основную область (SLRU) только если встречалась чаще, чем кандидат на вытеснение оттуда.
+// Частоты — приблизительные (count-min sketch) и учитывают промахи, поэтому однократные обращения
+// (полный проход прогрева, всплеск случайных UID) не вытесняют часто читаемые заказы.
+// Сегменты меряются бюджетом памяти, если он задан, иначе числом записей.
+type wTinyLFU struct {
+	byBytes bool // вес записи — ent.size (иначе 1)
+
+	window, probation, protected *list.List
+	windowWeight, mainWeight     int64 // mainWeight — probation + protected
+	protectedWeight              int64
+	windowMax, mainMax           int64
+	protectedMax                 int64
+
+	sketch *countMinSketch
+}
+
+// newWTinyLFU — W-TinyLFU для кэша с лимитами capacity (записей) и maxBytes (байт).
+func newWTinyLFU(capacity int, maxBytes int64) *wTinyLFU {
+	total, expected := int64(capacity), capacity
+	p := &wTinyLFU{window: list.New(), probation: list.New(), protected: list.New()}
+	if maxBytes > 0 {
+		p.byBytes = true
+		total = maxBytes
+		expected = int(maxBytes / 1024) // порядок числа заказов в бюджете — для размера sketch
+	}
+	total = max(total, 1)
+
+	p.windowMax = max(total*windowPercent/100, 1)
+	p.mainMax = max(total-p.windowMax, 1)
+	p.protectedMax = p.mainMax * protectedPercent / 100
+	p.sketch = newCountMinSketch(expected)
+	return p
+}
+
+func (p *wTinyLFU) add(ent *entry) {
+	p.sketch.increment(hashKey(ent.id))
+	ent.segment = segWindow
+	ent.elem = p.window.PushFront(ent)
+	p.windowWeight += p.weight(ent)
+
+	// Пока основная область не заполнена, лишнее из окна переходит туда без соревнования
+	// (иначе при заполнении кэша всё оставалось бы в окне и не попадало в защищённый сегмент).
+	for p.windowWeight > p.windowMax && p.window.Len() > 1 {
+		candidate := backEntry(p.window)
+		if p.mainWeight+p.weight(candidate) > p.mainMax {
+			return
+		}
+		p.promote(candidate)
+	}
+}
+
+func (p *wTinyLFU) touch(ent *entry) {
+	p.sketch.increment(hashKey(ent.id))
+	switch ent.segment {
+	case segWindow:
+		p.window.MoveToFront(ent.elem)
+	case segProtected:
+		p.protected.MoveToFront(ent.elem)
+	default:
+		// Повторное обращение в испытательном сегменте — перевод в защищённый;
+		// при переполнении защищённого его хвост возвращается в испытательный.
+		p.probation.Remove(ent.elem)
+		ent.segment = segProtected
+		ent.elem = p.protected.PushFront(ent)
+		p.protectedWeight += p.weight(ent)
+		for p.protectedWeight > p.protectedMax && p.protected.Len() > 1 {
+			demoted := backEntry(p.protected)
+			p.protected.Remove(demoted.elem)
+			p.protectedWeight -= p.weight(demoted)
+			demoted.segment = segProbation
+			demoted.elem = p.probation.PushFront(demoted)
+		}
+	}
+}
+
+func (p *wTinyLFU) resize(ent *entry, delta int64) {
+	if !p.byBytes {
+		return
+	}
+	switch ent.segment {
+	case segWindow:
+		p.windowWeight += delta
+	case segProtected:
+		p.protectedWeight += delta
+		p.mainWeight += delta
+	default:
+		p.mainWeight += delta
+	}
+}
+
+func (p *wTinyLFU) remove(ent *entry) {
+	w := p.weight(ent)
+	switch ent.segment {
+	case segWindow:
+		p.window.Remove(ent.elem)
+		p.windowWeight -= w
+	case segProtected:
+		p.protected.Remove(ent.elem)
+		p.protectedWeight -= w
+		p.mainWeight -= w
+	default:
+		p.probation.Remove(ent.elem)
+		p.mainWeight -= w
+	}
+}
+
+func (p *wTinyLFU) recordMiss(id string) { p.sketch.increment(hashKey(id)) }
+
+// victim — запись на вытеснение; вызывается, только когда запись действительно будет удалена.
+// Пока окно больше своей доли, его хвост (кандидат) переходит в основную область: без соревнования,
+// если там есть место, иначе — против хвоста испытательного сегмента по оценке частоты
+// (при равенстве проигрывает кандидат). Вытесняется проигравший.
+func (p *wTinyLFU) victim() *entry {
+	for p.windowWeight > p.windowMax && p.window.Len() > 0 {
+		candidate := backEntry(p.window)
+		w := p.weight(candidate)
+		if p.mainWeight+w <= p.mainMax {
+			p.promote(candidate)
+			continue
+		}
+
+		victim := p.mainVictim()
+		if victim == nil {
+			return candidate
+		}
+		if p.sketch.estimate(hashKey(candidate.id)) > p.sketch.estimate(hashKey(victim.id)) {
+			p.promote(candidate)
+			return victim
+		}
+		return candidate
+	}
+
+	if victim := p.mainVictim(); victim != nil {
+		return victim
+	}
+	return backEntry(p.window)
+}
+
+// peekVictim — ближайший кандидат без переноса записей между сегментами (для удаления истёкших):
+// хвост окна, если окно больше своей доли, иначе хвост основной области, иначе хвост окна.
+func (p *wTinyLFU) peekVictim() *entry {
+	if p.windowWeight > p.windowMax && p.window.Len() > 0 {
+		return backEntry(p.window)
+	}
+	if victim := p.mainVictim(); victim != nil {
+		return victim
+	}
+	return backEntry(p.window)
+}
+
+func (p *wTinyLFU) reset() {
+	p.window.Init()
+	p.probation.Init()
+	p.protected.Init()
+	p.windowWeight, p.mainWeight, p.protectedWeight = 0, 0, 0
+}
+
+// walk — защищённый, испытательный сегменты, затем окно (каждый — от начала к хвосту).
+func (p *wTinyLFU) walk(fn func(*entry) bool) {
+	_ = walkList(p.protected, fn) && walkList(p.probation, fn) && walkList(p.window, fn)
+}
+
+// promote — перенос записи из окна в начало испытательного сегмента.
+func (p *wTinyLFU) promote(ent *entry) {
+	w := p.weight(ent)
+	p.window.Remove(ent.elem)
+	p.windowWeight -= w
+	ent.segment = segProbation
+	ent.elem = p.probation.PushFront(ent)
+	p.mainWeight += w
+}
+
+// mainVictim — хвост испытательного сегмента, если он пуст — хвост защищённого.
+func (p *wTinyLFU) mainVictim() *entry {
+	if victim := backEntry(p.probation); victim != nil {
+		return victim
+	}
+	return backEntry(p.protected)
+}
+
+// weight — вес записи в сегментах.
+func (p *wTinyLFU) weight(ent *entry) int64 {
+	if p.byBytes {
+		return ent.size
+	}
+	return 1
+}
+
+// countMinSketch — приблизительный счётчик частот: 4 строки 8-битных счётчиков (насыщение на 15).
+// После 10×width увеличений все счётчики делятся пополам — старая популярность «забывается».
+type countMinSketch struct {
+	rows      [4][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+// sketchMaxCount — насыщение счётчика (как у 4-битных счётчиков TinyLFU).
+const sketchMaxCount = 15
+
+// newCountMinSketch — sketch на expected ключей: ширина — степень двойки не меньше 2×expected
+// (16…1M, до 4 МиБ счётчиков), чтобы коллизии редко завышали частоту разовых ключей.
+func newCountMinSketch(expected int) *countMinSketch {
+	width := 1 << bits.Len(uint(min(max(2*expected, 16), 1<<20)-1))
+	s := &countMinSketch{mask: uint64(width - 1), resetAt: 10 * width}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// increment — +1 к счётчикам ключа (только к минимальным — «консервативное» обновление).
+func (s *countMinSketch) increment(h uint64) {
+	est := s.estimate(h)
+	if est >= sketchMaxCount {
+		return
+	}
+	for i := range s.rows {
+		if c := &s.rows[i][s.index(h, i)]; *c == est {
+			*c++
+		}
+	}
+	if s.additions++; s.additions >= s.resetAt {
+		s.halve()
+	}
+}
+
+// estimate — оценка частоты ключа (минимум по строкам).
+func (s *countMinSketch) estimate(h uint64) uint8 {
+	est := uint8(sketchMaxCount)
+	for i := range s.rows {
+		est = min(est, s.rows[i][s.index(h, i)])
+	}
+	return est
+}
+
+// index — позиция ключа в строке i (двойное хэширование).
+func (s *countMinSketch) index(h uint64, i int) uint64 {
+	h1, h2 := h&0xffffffff, h>>32|1
+	return (h1 + uint64(i)*h2) & s.mask
+}
+
+// halve — старение: все счётчики пополам.
+func (s *countMinSketch) halve() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// hashKey — 64-битный хэш UID для sketch: FNV-1a без аллокаций с финальным перемешиванием
+// (младшие биты FNV у похожих UID коррелируют, а индексы строк берутся именно из них).
+func hashKey(id string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for i := 0; i < len(id); i++ {
+		h ^= uint64(id[i])
+		h *= prime64
+	}
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	return h
+}
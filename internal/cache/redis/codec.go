@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+)
+
+// codecVersion — версия бинарного формата; при изменении состава полей увеличивается,
+// старые значения в Redis тогда не декодируются и считаются промахом.
+const codecVersion byte = 1
+
+// negativeMarker — первый байт негативной записи (отличается от codecVersion).
+const negativeMarker byte = 0
+
+// errCorrupted — значение в Redis не удалось декодировать (чужой формат, другая версия, обрезано).
+var errCorrupted = errors.New("corrupted cache value")
+
+// encodeNegative — негативная запись: маркер и момент истечения (UnixMilli, zigzag varint).
+// Срок хранится в самом значении: GETEX при чтении продлевает TTL ключа до TTL заказов.
+func encodeNegative(expiresAt time.Time) []byte {
+	return binary.AppendVarint([]byte{negativeMarker}, expiresAt.UnixMilli())
+}
+
+// decodeNegative — срок негативной записи; ok=false, если значение — не негативная запись.
+func decodeNegative(b []byte) (expiresAt time.Time, ok bool) {
+	if len(b) < 2 || b[0] != negativeMarker {
+		return time.Time{}, false
+	}
+	ms, n := binary.Varint(b[1:])
+	if n <= 0 || n != len(b)-1 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms), true
+}
+
+// encodeOrder — компактная сериализация заказа: байт версии, затем поля в фиксированном порядке.
+// Строки — uvarint-длина + байты, целые — zigzag varint, время — UnixNano (UTC).
+// Без имён полей значение в 2–3 раза меньше JSON.
+func encodeOrder(o *domain.Order) []byte {
+	w := writer{buf: make([]byte, 0, 512)}
+	w.buf = append(w.buf, codecVersion)
+
+	w.str(o.OrderUID)
+	w.str(o.TrackNumber)
+	w.str(o.Entry)
+	w.str(o.Locale)
+	w.str(o.InternalSignature)
+	w.str(o.CustomerID)
+	w.str(o.DeliveryService)
+	w.str(o.ShardKey)
+	w.int(int64(o.SmID))
+	w.time(o.DateCreated)
+	w.str(o.OofShard)
+
+	d := &o.Delivery
+	w.str(d.Name)
+	w.str(d.Phone)
+	w.str(d.Zip)
+	w.str(d.City)
+	w.str(d.Address)
+	w.str(d.Region)
+	w.str(d.Email)
+
+	p := &o.Payment
+	w.str(p.Transaction)
+	w.str(p.RequestID)
+	w.str(p.Currency)
+	w.str(p.Provider)
+	w.int(int64(p.Amount))
+	w.int(p.PaymentDT)
+	w.str(p.Bank)
+	w.int(int64(p.DeliveryCost))
+	w.int(int64(p.GoodsTotal))
+	w.int(int64(p.CustomFee))
+
+	w.uint(uint64(len(o.Items)))
+	for i := range o.Items {
+		it := &o.Items[i]
+		w.int(int64(it.ChrtID))
+		w.str(it.TrackNumber)
+		w.int(int64(it.Price))
+		w.str(it.RID)
+		w.str(it.Name)
+		w.int(int64(it.Sale))
+		w.str(it.Size)
+		w.int(int64(it.TotalPrice))
+		w.int(int64(it.NmID))
+		w.str(it.Brand)
+		w.int(int64(it.Status))
+	}
+	return w.buf
+}
+
+// decodeOrder — обратная операция к encodeOrder; errCorrupted при любом несоответствии формата.
+func decodeOrder(b []byte) (*domain.Order, error) {
+	if len(b) == 0 || b[0] != codecVersion {
+		return nil, fmt.Errorf("%w: unsupported version", errCorrupted)
+	}
+	r := reader{buf: b[1:]}
+	o := &domain.Order{}
+
+	o.OrderUID = r.str()
+	o.TrackNumber = r.str()
+	o.Entry = r.str()
+	o.Locale = r.str()
+	o.InternalSignature = r.str()
+	o.CustomerID = r.str()
+	o.DeliveryService = r.str()
+	o.ShardKey = r.str()
+	o.SmID = int(r.int())
+	o.DateCreated = r.time()
+	o.OofShard = r.str()
+
+	d := &o.Delivery
+	d.Name = r.str()
+	d.Phone = r.str()
+	d.Zip = r.str()
+	d.City = r.str()
+	d.Address = r.str()
+	d.Region = r.str()
+	d.Email = r.str()
+
+	p := &o.Payment
+	p.Transaction = r.str()
+	p.RequestID = r.str()
+	p.Currency = r.str()
+	p.Provider = r.str()
+	p.Amount = int(r.int())
+	p.PaymentDT = r.int()
+	p.Bank = r.str()
+	p.DeliveryCost = int(r.int())
+	p.GoodsTotal = int(r.int())
+	p.CustomFee = int(r.int())
+
+	n := r.uint()
+	if r.err == nil && n > uint64(len(r.buf)) { // каждая позиция занимает хотя бы байт — защита от огромной аллокации
+		r.err = errCorrupted
+	}
+	if r.err == nil {
+		o.Items = make([]domain.Item, n)
+	}
+	for i := 0; r.err == nil && i < int(n); i++ {
+		it := &o.Items[i]
+		it.ChrtID = int(r.int())
+		it.TrackNumber = r.str()
+		it.Price = int(r.int())
+		it.RID = r.str()
+		it.Name = r.str()
+		it.Sale = int(r.int())
+		it.Size = r.str()
+		it.TotalPrice = int(r.int())
+		it.NmID = int(r.int())
+		it.Brand = r.str()
+		it.Status = int(r.int())
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.buf) != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes", errCorrupted)
+	}
+	return o, nil
+}
+
+// writer — накопитель бинарного представления.
+type writer struct{ buf []byte }
+
+func (w *writer) uint(v uint64) { w.buf = binary.AppendUvarint(w.buf, v) }
+func (w *writer) int(v int64)   { w.buf = binary.AppendVarint(w.buf, v) }
+
+func (w *writer) str(s string) {
+	w.uint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+// time — нулевое время кодируется отдельно, т.к. его UnixNano не представимо в int64.
+func (w *writer) time(t time.Time) {
+	if t.IsZero() {
+		w.buf = append(w.buf, 0)
+		return
+	}
+	w.buf = append(w.buf, 1)
+	w.int(t.UnixNano())
+}
+
+// reader — последовательное чтение; первая ошибка запоминается, дальнейшие чтения возвращают нули.
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.err = errCorrupted
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *reader) int() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.err = errCorrupted
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *reader) str() string {
+	n := r.uint()
+	if r.err != nil {
+		return ""
+	}
+	if n > uint64(len(r.buf)) {
+		r.err = errCorrupted
+		return ""
+	}
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+	return s
+}
+
+func (r *reader) time() time.Time {
+	if r.err != nil {
+		return time.Time{}
+	}
+	if len(r.buf) == 0 {
+		r.err = errCorrupted
+		return time.Time{}
+	}
+	flag := r.buf[0]
+	r.buf = r.buf[1:]
+	switch flag {
+	case 0:
+		return time.Time{}
+	case 1:
+		return time.Unix(0, r.int()).UTC()
+	default:
+		r.err = errCorrupted
+		return time.Time{}
+	}
+}
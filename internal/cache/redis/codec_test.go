@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/testutil"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	full := testutil.MakeOrder(testutil.WithItems(3))
+	full.DateCreated = time.Date(2025, 8, 1, 12, 30, 45, 123456000, time.UTC)
+	full.SmID = -7 // отрицательные значения — через zigzag
+	full.Delivery.Name = "Тест Тестов"
+
+	empty := domain.Order{OrderUID: "only-uid"} // нулевые время и срез items
+
+	for _, o := range []domain.Order{full, empty} {
+		got, err := decodeOrder(encodeOrder(&o))
+		if err != nil {
+			t.Fatalf("decode %s: %v", o.OrderUID, err)
+		}
+		if len(o.Items) == 0 {
+			o.Items = []domain.Item{} // декодер всегда создаёт срез
+		}
+		if !reflect.DeepEqual(&o, got) {
+			t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, &o)
+		}
+	}
+}
+
+func TestCodec_SmallerThanJSON(t *testing.T) {
+	t.Parallel()
+
+	o := testutil.MakeOrder(testutil.WithItems(5))
+	raw, err := json.Marshal(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bin := encodeOrder(&o); len(bin)*2 > len(raw) {
+		t.Fatalf("binary form %d bytes is not at least 2x smaller than JSON %d bytes", len(bin), len(raw))
+	}
+}
+
+func TestCodec_Corrupted(t *testing.T) {
+	t.Parallel()
+
+	o := testutil.MakeOrder(testutil.WithItems(2))
+	valid := encodeOrder(&o)
+
+	cases := map[string][]byte{
+		"empty":         nil,
+		"other version": append([]byte{codecVersion + 1}, valid[1:]...),
+		"json":          []byte(`{"order_uid":"x"}`),
+		"truncated":     valid[:len(valid)/2],
+		"trailing":      append(append([]byte(nil), valid...), 0),
+	}
+	for name, b := range cases {
+		if _, err := decodeOrder(b); !errors.Is(err, errCorrupted) {
+			t.Fatalf("%s: want errCorrupted, got %v", name, err)
+		}
+	}
+}
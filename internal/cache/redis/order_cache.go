@@ -0,0 +1,279 @@
+// Package redis — реализация ports.OrderCache поверх Redis: общий кэш для всех реплик сервиса.
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/Gunvolt24/wb_l0/pkg/telemetry"
+	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracerName — имя инструментирующей библиотеки для спанов кэша.
+const tracerName = "github.com/Gunvolt24/wb_l0/internal/cache/redis"
+
+// Атрибуты спанов кэша.
+const (
+	attrOrderUID = attribute.Key("order.uid")
+	attrCacheHit = attribute.Key("cache.hit")
+	attrOrders   = attribute.Key("orders.count")
+)
+
+// warmUpChunk — команд SET в одном pipeline при прогреве.
+const warmUpChunk = 500
+
+// scanCount — подсказка COUNT для SCAN (ключей за одну итерацию).
+const scanCount = 500
+
+// Проверка, что OrderCache удовлетворяет интерфейсу OrderCache.
+var _ ports.OrderCache = (*OrderCache)(nil)
+
+// ErrInvalidOrder — некорректные данные для сохранения в кэше.
+var ErrInvalidOrder = errors.New("invalid order: nil or empty order_uid")
+
+// OrderCache — кэш заказов в Redis: ключ <prefix><order_uid>, значение — компактная бинарная
+// сериализация (см. encodeOrder) или негативная запись (см. encodeNegative).
+// TTL скользящий, как у LRUCacheTTL: попадание продлевает срок (GETEX); у негативной записи срок фиксированный.
+// Вытеснение при нехватке памяти — политикой maxmemory-policy самого Redis (рекомендуется allkeys-lru).
+// Ошибки Redis на чтении считаются промахом: кэш не должен ронять чтение из БД.
+type OrderCache struct {
+	client      goredis.UniversalClient
+	ttl         time.Duration // 0 — без истечения
+	negativeTTL time.Duration // время жизни негативной записи (0 — выключено)
+	prefix      string        // пространство имён ключей
+
+	hits, misses atomic.Uint64 // обращения этой реплики с запуска (для Stats)
+}
+
+// NewOrderCache — DI-конструктор. Пустой prefix — "order:"; negativeTTL <= 0 выключает негативное кэширование.
+func NewOrderCache(client goredis.UniversalClient, ttl, negativeTTL time.Duration, prefix string) *OrderCache {
+	if prefix == "" {
+		prefix = "order:"
+	}
+	return &OrderCache{client: client, ttl: ttl, negativeTTL: max(negativeTTL, 0), prefix: prefix}
+}
+
+// NewClient — клиент Redis с проверкой соединения (PING).
+func NewClient(ctx context.Context, addr, password string, db int) (*goredis.Client, error) {
+	client := goredis.NewClient(&goredis.Options{Addr: addr, Password: password, DB: db})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("redis ping %s: %w", addr, err)
+	}
+	return client, nil
+}
+
+// Get — вернуть заказ по id.
+// (order, true) при попадании; (nil, true) при негативной записи;
+// (nil, false) при промахе, истёкшем TTL, ошибке Redis или битом значении.
+func (c *OrderCache) Get(ctx context.Context, id string) (order *domain.Order, hit bool) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "RedisCache.Get", attrOrderUID.String(id))
+	defer func() {
+		span.SetAttributes(attrCacheHit.Bool(hit))
+		span.End()
+		if hit {
+			c.hits.Add(1)
+		} else {
+			c.misses.Add(1)
+		}
+	}()
+
+	if id == "" {
+		metrics.CacheOps.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+
+	var cmd *goredis.StringCmd
+	if c.ttl > 0 {
+		cmd = c.client.GetEx(ctx, c.key(id), c.ttl) // чтение + продление TTL одной командой
+	} else {
+		cmd = c.client.Get(ctx, c.key(id))
+	}
+	raw, err := cmd.Bytes()
+	switch {
+	case errors.Is(err, goredis.Nil):
+		metrics.CacheOps.WithLabelValues("miss").Inc()
+		return nil, false
+	case err != nil:
+		metrics.CacheOps.WithLabelValues("error").Inc()
+		span.RecordError(err)
+		return nil, false
+	}
+
+	if expiresAt, ok := decodeNegative(raw); ok {
+		if time.Now().Before(expiresAt) {
+			metrics.CacheOps.WithLabelValues("miss_negative").Inc()
+			return nil, true
+		}
+		// Ключ пережил срок из-за продления GETEX — удаляем, иначе SetNegative (NX) не сможет его обновить.
+		metrics.CacheOps.WithLabelValues("expired").Inc()
+		_ = c.client.Del(ctx, c.key(id)).Err()
+		return nil, false
+	}
+
+	order, err = decodeOrder(raw)
+	if err != nil {
+		// Значение чужого формата/версии — удаляем, чтобы следующий Set записал актуальное.
+		metrics.CacheOps.WithLabelValues("error").Inc()
+		span.RecordError(err)
+		_ = c.client.Del(ctx, c.key(id)).Err()
+		return nil, false
+	}
+
+	metrics.CacheOps.WithLabelValues("hit").Inc()
+	return order, true
+}
+
+// Set — сохранить/обновить заказ (SET с TTL).
+// Возвращает ErrInvalidOrder при пустом UID или nil-значении.
+func (c *OrderCache) Set(ctx context.Context, order *domain.Order) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "RedisCache.Set")
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if order == nil || order.OrderUID == "" {
+		return ErrInvalidOrder
+	}
+	span.SetAttributes(attrOrderUID.String(order.OrderUID))
+
+	if err := c.client.Set(ctx, c.key(order.OrderUID), encodeOrder(order), c.ttl).Err(); err != nil {
+		metrics.CacheOps.WithLabelValues("error").Inc()
+		return fmt.Errorf("redis set %s: %w", order.OrderUID, err)
+	}
+	return nil
+}
+
+// SetNegative — запомнить отсутствие заказа на negativeTTL (SET NX: запись с заказом не затирается).
+func (c *OrderCache) SetNegative(ctx context.Context, id string) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "RedisCache.SetNegative", attrOrderUID.String(id))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	if id == "" {
+		return ErrInvalidOrder
+	}
+	if c.negativeTTL <= 0 {
+		return nil
+	}
+
+	value := encodeNegative(time.Now().Add(c.negativeTTL))
+	if err := c.client.SetNX(ctx, c.key(id), value, c.negativeTTL).Err(); err != nil {
+		metrics.CacheOps.WithLabelValues("error").Inc()
+		return fmt.Errorf("redis set negative %s: %w", id, err)
+	}
+	return nil
+}
+
+// WarmUp — массовая загрузка кэша: SET пачками через pipeline (один round trip на warmUpChunk заказов).
+func (c *OrderCache) WarmUp(ctx context.Context, orders []*domain.Order) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "RedisCache.WarmUp", attrOrders.Int(len(orders)))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	for start := 0; start < len(orders); start += warmUpChunk {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		end := min(start+warmUpChunk, len(orders))
+
+		pipe := c.client.Pipeline()
+		for _, order := range orders[start:end] {
+			if order == nil || order.OrderUID == "" {
+				return ErrInvalidOrder
+			}
+			pipe.Set(ctx, c.key(order.OrderUID), encodeOrder(order), c.ttl)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			metrics.CacheOps.WithLabelValues("error").Inc()
+			return fmt.Errorf("redis warm-up: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delete — удалить заказ из кэша (отсутствие ключа — не ошибка).
+func (c *OrderCache) Delete(ctx context.Context, id string) error {
+	if err := c.client.Del(ctx, c.key(id)).Err(); err != nil {
+		metrics.CacheOps.WithLabelValues("error").Inc()
+		return fmt.Errorf("redis del %s: %w", id, err)
+	}
+	return nil
+}
+
+// Purge — удалить все ключи с префиксом кэша (SCAN + UNLINK пачками; затрагивает все реплики).
+func (c *OrderCache) Purge(ctx context.Context) error {
+	return c.scan(ctx, func(keys []string) (bool, error) {
+		if err := c.client.Unlink(ctx, keys...).Err(); err != nil {
+			metrics.CacheOps.WithLabelValues("error").Inc()
+			return false, fmt.Errorf("redis unlink: %w", err)
+		}
+		return true, nil
+	})
+}
+
+// Len — число ключей с префиксом кэша (SCAN по всему keyspace — O(N), только для диагностики).
+func (c *OrderCache) Len(ctx context.Context) (int, error) {
+	n := 0
+	err := c.scan(ctx, func(keys []string) (bool, error) {
+		n += len(keys)
+		return true, nil
+	})
+	return n, err
+}
+
+// Keys — не более limit UID в порядке SCAN (порядка LRU у Redis нет). limit <= 0 — все.
+func (c *OrderCache) Keys(ctx context.Context, limit int) ([]string, error) {
+	var ids []string
+	err := c.scan(ctx, func(keys []string) (bool, error) {
+		for _, key := range keys {
+			if limit > 0 && len(ids) >= limit {
+				return false, nil
+			}
+			ids = append(ids, strings.TrimPrefix(key, c.prefix))
+		}
+		return limit <= 0 || len(ids) < limit, nil
+	})
+	return ids, err
+}
+
+// Stats — число ключей и обращения этой реплики; память и возраст записей Redis не отдаёт дёшево (0).
+func (c *OrderCache) Stats(ctx context.Context) (ports.CacheStats, error) {
+	n, err := c.Len(ctx)
+	if err != nil {
+		return ports.CacheStats{}, err
+	}
+	return ports.CacheStats{Entries: n, Hits: c.hits.Load(), Misses: c.misses.Load()}, nil
+}
+
+// scan — обход ключей кэша пачками SCAN MATCH <prefix>*; fn возвращает false, чтобы остановить обход.
+func (c *OrderCache) scan(ctx context.Context, fn func(keys []string) (bool, error)) error {
+	match := globEscaper.Replace(c.prefix) + "*"
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, match, scanCount).Result()
+		if err != nil {
+			metrics.CacheOps.WithLabelValues("error").Inc()
+			return fmt.Errorf("redis scan: %w", err)
+		}
+		if len(keys) > 0 {
+			more, err := fn(keys)
+			if err != nil || !more {
+				return err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// globEscaper — экранирование спецсимволов glob-шаблона Redis в префиксе.
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// key — ключ Redis для заказа.
+func (c *OrderCache) key(id string) string { return c.prefix + id }
@@ -0,0 +1,237 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/testutil"
+)
+
+// newTestCache — кэш поверх in-process Redis (miniredis); время в miniredis двигается через FastForward.
+func newTestCache(t *testing.T, ttl time.Duration) (*OrderCache, *miniredis.Miniredis) {
+	t.Helper()
+	return newTestCacheNegative(t, ttl, 0)
+}
+
+// newTestCacheNegative — то же, с негативным кэшированием.
+func newTestCacheNegative(t *testing.T, ttl, negativeTTL time.Duration) (*OrderCache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return NewOrderCache(client, ttl, negativeTTL, "test:"), mr
+}
+
+func TestRedisCache_GetSet_HitMiss(t *testing.T) {
+	c, mr := newTestCache(t, 5*time.Minute)
+	ctx := context.Background()
+
+	if _, ok := c.Get(ctx, "id-1"); ok {
+		t.Fatalf("expected miss before Set")
+	}
+
+	o := testutil.MakeOrder(testutil.WithOrderUID("id-1"), testutil.WithItems(2))
+	if err := c.Set(ctx, &o); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if !mr.Exists("test:id-1") {
+		t.Fatalf("expected key with prefix, keys=%v", mr.Keys())
+	}
+
+	got, ok := c.Get(ctx, "id-1")
+	if !ok || got.OrderUID != "id-1" || len(got.Items) != 2 || got.Payment.Transaction != o.Payment.Transaction {
+		t.Fatalf("unexpected hit: ok=%v order=%+v", ok, got)
+	}
+
+	if err := c.Set(ctx, nil); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("want ErrInvalidOrder, got %v", err)
+	}
+}
+
+func TestRedisCache_SlidingTTL(t *testing.T) {
+	c, mr := newTestCache(t, time.Minute)
+	ctx := context.Background()
+
+	o := testutil.MakeOrder(testutil.WithOrderUID("ttl"))
+	if err := c.Set(ctx, &o); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// попадание на 40-й секунде продлевает срок ещё на минуту
+	mr.FastForward(40 * time.Second)
+	if _, ok := c.Get(ctx, "ttl"); !ok {
+		t.Fatalf("expected hit before TTL")
+	}
+	mr.FastForward(40 * time.Second)
+	if _, ok := c.Get(ctx, "ttl"); !ok {
+		t.Fatalf("expected hit: TTL must be extended by previous Get")
+	}
+
+	mr.FastForward(61 * time.Second)
+	if _, ok := c.Get(ctx, "ttl"); ok {
+		t.Fatalf("expected miss after TTL")
+	}
+}
+
+func TestRedisCache_CorruptedValueIsMissAndDropped(t *testing.T) {
+	c, mr := newTestCache(t, 0)
+	ctx := context.Background()
+
+	if err := mr.Set("test:bad", `{"order_uid":"bad"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get(ctx, "bad"); ok {
+		t.Fatalf("expected miss for value in foreign format")
+	}
+	if mr.Exists("test:bad") {
+		t.Fatalf("corrupted value must be deleted")
+	}
+}
+
+func TestRedisCache_WarmUp(t *testing.T) {
+	c, mr := newTestCache(t, time.Minute)
+	ctx := context.Background()
+
+	orders := make([]*domain.Order, 0, warmUpChunk+10) // больше одной пачки pipeline
+	for i := 0; i < warmUpChunk+10; i++ {
+		o := testutil.MakeOrder()
+		orders = append(orders, &o)
+	}
+	if err := c.WarmUp(ctx, orders); err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+	if n := len(mr.Keys()); n != len(orders) {
+		t.Fatalf("want %d keys, got %d", len(orders), n)
+	}
+	if ttl := mr.TTL("test:" + orders[0].OrderUID); ttl != time.Minute {
+		t.Fatalf("warm-up must set TTL, got %v", ttl)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := c.WarmUp(canceled, orders); !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+}
+
+func TestRedisCache_Unavailable(t *testing.T) {
+	c, mr := newTestCache(t, time.Minute)
+	ctx := context.Background()
+	mr.Close() // Redis недоступен
+
+	if _, ok := c.Get(ctx, "any"); ok {
+		t.Fatalf("unavailable redis must be a miss")
+	}
+	o := testutil.MakeOrder()
+	if err := c.Set(ctx, &o); err == nil {
+		t.Fatalf("Set must report redis error")
+	}
+}
+
+func TestRedisCache_NegativeEntry(t *testing.T) {
+	c, mr := newTestCacheNegative(t, time.Minute, 30*time.Second)
+	ctx := context.Background()
+
+	if err := c.SetNegative(ctx, "missing"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	if got, ok := c.Get(ctx, "missing"); !ok || got != nil {
+		t.Fatalf("want negative hit, got ok=%v order=%+v", ok, got)
+	}
+	if ttl := mr.TTL("test:missing"); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("negative key must expire, ttl=%v", ttl)
+	}
+
+	// Set заменяет негативную запись, SetNegative не затирает заказ
+	o := testutil.MakeOrder(testutil.WithOrderUID("missing"))
+	if err := c.Set(ctx, &o); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := c.SetNegative(ctx, "missing"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	if got, ok := c.Get(ctx, "missing"); !ok || got == nil || got.OrderUID != "missing" {
+		t.Fatalf("want order, got ok=%v order=%+v", ok, got)
+	}
+}
+
+func TestRedisCache_NegativeEntry_ExpiresDespiteGetEx(t *testing.T) {
+	c, mr := newTestCacheNegative(t, time.Minute, 50*time.Millisecond)
+	ctx := context.Background()
+
+	if err := c.SetNegative(ctx, "missing"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	if _, ok := c.Get(ctx, "missing"); !ok { // GETEX продлевает ключ до минуты
+		t.Fatalf("want negative hit")
+	}
+
+	time.Sleep(80 * time.Millisecond)
+	if got, ok := c.Get(ctx, "missing"); ok {
+		t.Fatalf("negative entry must expire after its own TTL, got order=%+v", got)
+	}
+	if mr.Exists("test:missing") {
+		t.Fatalf("expired negative key must be deleted")
+	}
+}
+
+func TestRedisCache_NegativeDisabled(t *testing.T) {
+	c, mr := newTestCache(t, time.Minute)
+
+	if err := c.SetNegative(context.Background(), "missing"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+	if mr.Exists("test:missing") {
+		t.Fatalf("SetNegative must be a no-op when negativeTTL is 0")
+	}
+}
+
+func TestRedisCache_KeysLenPurgeOnlyOwnPrefix(t *testing.T) {
+	c, mr := newTestCache(t, time.Minute)
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c"} {
+		o := testutil.MakeOrder(testutil.WithOrderUID(id))
+		if err := c.Set(ctx, &o); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := mr.Set("other:x", "1"); err != nil { // чужой ключ в той же БД
+		t.Fatalf("mr.Set: %v", err)
+	}
+	c.Get(ctx, "a")
+	c.Get(ctx, "missing")
+
+	if n, err := c.Len(ctx); err != nil || n != 3 {
+		t.Fatalf("Len = %d, %v; want 3", n, err)
+	}
+	keys, err := c.Keys(ctx, 2)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("Keys(2) = %v, %v", keys, err)
+	}
+	for _, k := range keys {
+		if k != "a" && k != "b" && k != "c" {
+			t.Fatalf("Keys must return UIDs without prefix, got %q", k)
+		}
+	}
+	st, err := c.Stats(ctx)
+	if err != nil || st.Entries != 3 || st.Hits != 1 || st.Misses != 1 {
+		t.Fatalf("Stats = %+v, %v", st, err)
+	}
+
+	if err := c.Purge(ctx); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if n, _ := c.Len(ctx); n != 0 {
+		t.Fatalf("Len after Purge = %d", n)
+	}
+	if !mr.Exists("other:x") {
+		t.Fatalf("Purge must not touch keys outside the prefix")
+	}
+}
@@ -0,0 +1,129 @@
+// Package tiered — двухуровневый кэш заказов: L1 в памяти процесса и общий для реплик L2.
+package tiered
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+)
+
+// Проверка, что OrderCache удовлетворяет интерфейсам OrderCache и CacheInvalidator.
+var (
+	_ ports.OrderCache       = (*OrderCache)(nil)
+	_ ports.CacheInvalidator = (*OrderCache)(nil)
+)
+
+// Local — L1: кэш в памяти процесса, умеющий сбрасывать устаревшие копии (memory.LRUCacheTTL).
+type Local interface {
+	ports.OrderCache
+	ports.CacheInvalidator
+}
+
+// Shared — L2: общий для всех реплик кэш (redis.OrderCache).
+type Shared interface {
+	ports.OrderCache
+}
+
+// OrderCache — L1 → L2 → (промах, чтение из БД выполняет сервис).
+// Попадание в L2 заполняет L1; Set и WarmUp пишут в оба уровня (сначала L2).
+// Изменения приходят через Invalidate (см. postgres.ChangeListener): сбрасывается L1 и ключ в L2.
+// Уведомление доставляется при COMMIT — раньше, чем писатель запишет новое значение в L2, поэтому
+// без удаления ключа реплика успела бы перечитать из L2 старое значение в свой L1.
+type OrderCache struct {
+	l1 Local
+	l2 Shared
+}
+
+// NewOrderCache — DI-конструктор.
+func NewOrderCache(l1 Local, l2 Shared) *OrderCache {
+	return &OrderCache{l1: l1, l2: l2}
+}
+
+// Get — вернуть заказ по UID: сначала L1, затем L2 (с заполнением L1).
+// Негативная запись любого уровня — (nil, true), из L2 она тоже переносится в L1.
+func (c *OrderCache) Get(ctx context.Context, orderUID string) (*domain.Order, bool) {
+	if order, ok := c.l1.Get(ctx, orderUID); ok {
+		metrics.CacheTierLookups.WithLabelValues("l1").Inc()
+		return order, true
+	}
+
+	order, ok := c.l2.Get(ctx, orderUID)
+	if !ok {
+		metrics.CacheTierLookups.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	metrics.CacheTierLookups.WithLabelValues("l2").Inc()
+	// L1 — лишь ускорение, его ошибка не мешает отдать заказ
+	if order == nil {
+		_ = c.l1.SetNegative(ctx, orderUID)
+	} else {
+		_ = c.l1.Set(ctx, order)
+	}
+	return order, true
+}
+
+// Set — записать заказ в L2 и L1. Ошибка L2 возвращается, но L1 всё равно обновляется.
+func (c *OrderCache) Set(ctx context.Context, order *domain.Order) error {
+	errL2 := c.l2.Set(ctx, order)
+	errL1 := c.l1.Set(ctx, order)
+	return errors.Join(errL2, errL1)
+}
+
+// SetNegative — негативная запись в L2 и L1 (по тем же правилам, что и Set).
+func (c *OrderCache) SetNegative(ctx context.Context, orderUID string) error {
+	errL2 := c.l2.SetNegative(ctx, orderUID)
+	errL1 := c.l1.SetNegative(ctx, orderUID)
+	return errors.Join(errL2, errL1)
+}
+
+// WarmUp — прогрев обоих уровней; недоступность L2 не мешает прогреть L1.
+func (c *OrderCache) WarmUp(ctx context.Context, orders []*domain.Order) error {
+	errL2 := c.l2.WarmUp(ctx, orders)
+	errL1 := c.l1.WarmUp(ctx, orders)
+	return errors.Join(errL2, errL1)
+}
+
+// Delete — удалить запись из обоих уровней.
+func (c *OrderCache) Delete(ctx context.Context, orderUID string) error {
+	errL2 := c.l2.Delete(ctx, orderUID)
+	errL1 := c.l1.Delete(ctx, orderUID)
+	return errors.Join(errL2, errL1)
+}
+
+// Purge — очистить оба уровня (L2 общий: очищается для всех реплик).
+func (c *OrderCache) Purge(ctx context.Context) error {
+	errL2 := c.l2.Purge(ctx)
+	errL1 := c.l1.Purge(ctx)
+	return errors.Join(errL2, errL1)
+}
+
+// Len — число записей L1 (локальный уровень этой реплики).
+func (c *OrderCache) Len(ctx context.Context) (int, error) { return c.l1.Len(ctx) }
+
+// Keys — UID из L1 в порядке его политики вытеснения.
+func (c *OrderCache) Keys(ctx context.Context, limit int) ([]string, error) {
+	return c.l1.Keys(ctx, limit)
+}
+
+// Stats — состояние L1; обращения L1 считают промахом и то, что потом нашлось в L2
+// (распределение по уровням — метрика cache_tier_lookups_total).
+func (c *OrderCache) Stats(ctx context.Context) (ports.CacheStats, error) { return c.l1.Stats(ctx) }
+
+// Invalidate — заказ изменён (возможно, другой репликой): сбросить ключ в L2 и копию в L1.
+// Следующее чтение возьмёт актуальные данные из БД и заполнит оба уровня; удаление ключа каждой
+// репликой стоит не больше одного лишнего чтения из БД, зато устаревшее значение не переживёт уведомление,
+// даже если Set писателя в L2 не удался.
+func (c *OrderCache) Invalidate(ctx context.Context, orderUID string) {
+	_ = c.l2.Delete(ctx, orderUID) // недоступный L2 — значение истечёт по TTL
+	c.l1.Invalidate(ctx, orderUID)
+}
+
+// InvalidateAll — уведомления могли быть пропущены (переподключение): сбрасываем оба уровня.
+// L2 общий: его очистка заставит все реплики перечитать заказы из БД.
+func (c *OrderCache) InvalidateAll(ctx context.Context) {
+	_ = c.l2.Purge(ctx)
+	c.l1.InvalidateAll(ctx)
+}
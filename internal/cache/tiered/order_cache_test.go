@@ -0,0 +1,210 @@
+package tiered
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+
+	"github.com/Gunvolt24/wb_l0/internal/cache/memory"
+	cacheredis "github.com/Gunvolt24/wb_l0/internal/cache/redis"
+	"github.com/Gunvolt24/wb_l0/internal/testutil"
+)
+
+// replica — экземпляр сервиса: свой L1 и общий L2.
+type replica struct {
+	l1    *memory.LRUCacheTTL
+	cache *OrderCache
+}
+
+// newReplicas — n реплик поверх одного in-process Redis.
+func newReplicas(t *testing.T, n int) ([]replica, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	out := make([]replica, 0, n)
+	for i := 0; i < n; i++ {
+		l1 := memory.NewLRUCacheTTL(10, 0, time.Minute, time.Minute)
+		out = append(out, replica{l1: l1, cache: NewOrderCache(l1, cacheredis.NewOrderCache(client, time.Minute, time.Minute, "test:"))})
+	}
+	return out, mr
+}
+
+func TestTiered_L2HitFillsL1(t *testing.T) {
+	rs, _ := newReplicas(t, 2)
+	ctx := context.Background()
+
+	o := testutil.MakeOrder(testutil.WithOrderUID("uid-1"))
+	if err := rs[0].cache.Set(ctx, &o); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// вторая реплика: L1 пуст, L2 общий
+	if _, ok := rs[1].l1.Get(ctx, "uid-1"); ok {
+		t.Fatalf("replica L1 must be empty before first read")
+	}
+	got, ok := rs[1].cache.Get(ctx, "uid-1")
+	if !ok || got.OrderUID != "uid-1" {
+		t.Fatalf("expected L2 hit, got ok=%v", ok)
+	}
+	if _, ok := rs[1].l1.Get(ctx, "uid-1"); !ok {
+		t.Fatalf("L2 hit must fill L1")
+	}
+}
+
+func TestTiered_InvalidateDropsStaleCopies(t *testing.T) {
+	rs, mr := newReplicas(t, 2)
+	ctx := context.Background()
+
+	old := testutil.MakeOrder(testutil.WithOrderUID("uid-1"))
+	if err := rs[0].cache.Set(ctx, &old); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, ok := rs[1].cache.Get(ctx, "uid-1"); !ok { // реплика 1 держит копию в L1
+		t.Fatalf("expected hit")
+	}
+
+	// Реплика 0 сохранила новую версию; уведомление доходит до реплики 1
+	rs[1].cache.Invalidate(ctx, "uid-1")
+
+	if _, ok := rs[1].l1.Get(ctx, "uid-1"); ok {
+		t.Fatalf("L1 copy must be dropped")
+	}
+	if mr.Exists("test:uid-1") {
+		t.Fatalf("L2 key must be dropped so the replica re-reads from the database")
+	}
+	if _, ok := rs[1].cache.Get(ctx, "uid-1"); ok {
+		t.Fatalf("expected miss after invalidation")
+	}
+}
+
+// Уведомление приходит при COMMIT — раньше, чем писатель обновит L2:
+// чтение в этом окне не должно вернуть старое значение из L2 в L1
+func TestTiered_NotificationBeforeWriterSet(t *testing.T) {
+	rs, _ := newReplicas(t, 2)
+	ctx := context.Background()
+
+	old := testutil.MakeOrder(testutil.WithOrderUID("uid-1"))
+	if err := rs[0].cache.Set(ctx, &old); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// Коммит новой версии: уведомление дошло до обеих реплик, Set писателя ещё не выполнен
+	for _, r := range rs {
+		r.cache.Invalidate(ctx, "uid-1")
+	}
+	if got, ok := rs[1].cache.Get(ctx, "uid-1"); ok {
+		t.Fatalf("stale order served after notification: %+v", got)
+	}
+
+	// Set писателя (или чтение из БД) записывает новую версию
+	updated := testutil.MakeOrder(testutil.WithOrderUID("uid-1"))
+	updated.TrackNumber = "UPDATED"
+	if err := rs[0].cache.Set(ctx, &updated); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, ok := rs[1].cache.Get(ctx, "uid-1"); !ok || got.TrackNumber != "UPDATED" {
+		t.Fatalf("want fresh order after writer Set, got ok=%v order=%+v", ok, got)
+	}
+}
+
+func TestTiered_InvalidateAllDropsBothLevels(t *testing.T) {
+	rs, mr := newReplicas(t, 1)
+	ctx := context.Background()
+
+	o := testutil.MakeOrder(testutil.WithOrderUID("uid-1"))
+	if err := rs[0].cache.Set(ctx, &o); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	rs[0].cache.InvalidateAll(ctx)
+
+	if _, ok := rs[0].l1.Get(ctx, "uid-1"); ok {
+		t.Fatalf("L1 must be purged")
+	}
+	if mr.Exists("test:uid-1") {
+		t.Fatalf("L2 must be purged: notifications may have been missed")
+	}
+}
+
+func TestTiered_L2DownStillServesL1(t *testing.T) {
+	rs, mr := newReplicas(t, 1)
+	ctx := context.Background()
+	mr.Close()
+
+	o := testutil.MakeOrder(testutil.WithOrderUID("uid-1"))
+	if err := rs[0].cache.Set(ctx, &o); err == nil {
+		t.Fatalf("Set must report L2 failure")
+	}
+	if _, ok := rs[0].cache.Get(ctx, "uid-1"); !ok {
+		t.Fatalf("L1 must still be written and served when L2 is down")
+	}
+}
+
+func TestTiered_NegativeEntrySharedAndReplacedBySet(t *testing.T) {
+	rs, _ := newReplicas(t, 2)
+	ctx := context.Background()
+
+	// реплика 0 не нашла заказ в БД
+	if err := rs[0].cache.SetNegative(ctx, "uid-404"); err != nil {
+		t.Fatalf("SetNegative: %v", err)
+	}
+
+	// реплика 1 видит негативную запись из L2 и переносит её в L1
+	if got, ok := rs[1].cache.Get(ctx, "uid-404"); !ok || got != nil {
+		t.Fatalf("want negative hit from L2, got ok=%v order=%+v", ok, got)
+	}
+	if got, ok := rs[1].l1.Get(ctx, "uid-404"); !ok || got != nil {
+		t.Fatalf("L2 negative hit must fill L1, got ok=%v order=%+v", ok, got)
+	}
+
+	// заказ пришёл в реплику 1 — Set заменяет негативную запись в её L1 и в L2
+	o := testutil.MakeOrder(testutil.WithOrderUID("uid-404"))
+	if err := rs[1].cache.Set(ctx, &o); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, ok := rs[1].cache.Get(ctx, "uid-404"); !ok || got == nil {
+		t.Fatalf("want order after Set, got ok=%v", ok)
+	}
+
+	// негативную копию в L1 реплики 0 сбрасывает уведомление об изменении
+	rs[0].cache.Invalidate(ctx, "uid-404")
+	if _, ok := rs[0].l1.Get(ctx, "uid-404"); ok {
+		t.Fatalf("negative L1 entry must be dropped by Invalidate")
+	}
+}
+
+func TestTiered_DeleteAndPurgeBothLevels(t *testing.T) {
+	rs, mr := newReplicas(t, 2)
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b"} {
+		o := testutil.MakeOrder(testutil.WithOrderUID(id))
+		if err := rs[0].cache.Set(ctx, &o); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if n, _ := rs[0].cache.Len(ctx); n != 2 {
+		t.Fatalf("Len (L1) = %d, want 2", n)
+	}
+
+	if err := rs[0].cache.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := rs[0].l1.Get(ctx, "a"); ok || mr.Exists("test:a") {
+		t.Fatalf("Delete must drop both L1 and L2 copies")
+	}
+
+	if err := rs[0].cache.Purge(ctx); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if keys, _ := rs[0].cache.Keys(ctx, 0); len(keys) != 0 || len(mr.Keys()) != 0 {
+		t.Fatalf("Purge must clear both levels: l1=%v l2=%v", keys, mr.Keys())
+	}
+	if _, ok := rs[1].cache.Get(ctx, "b"); ok {
+		t.Fatalf("purged L2 must not serve other replicas")
+	}
+}
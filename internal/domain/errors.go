@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel-ошибки хранилища: позволяют верхним слоям (consumer) отличать
+// постоянные отказы от временных, не завися от драйвера БД.
+var (
+	// ErrConstraintViolation — данные нарушают ограничения схемы (CHECK/NOT NULL/FK/UNIQUE); повтор не поможет.
+	ErrConstraintViolation = errors.New("constraint violation")
+
+	// ErrDuplicatePaymentTransaction — payments.transaction уже принадлежит другому заказу.
+	// Частный случай ErrConstraintViolation (errors.Is срабатывает для обоих).
+	ErrDuplicatePaymentTransaction = fmt.Errorf("%w: duplicate payment transaction", ErrConstraintViolation)
+
+	// ErrTransient — временный сбой (соединение, сериализация, deadlock); операцию можно повторить.
+	ErrTransient = errors.New("transient storage failure")
+)
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// Типы событий outbox.
+const (
+	EventOrderSaved   = "order.saved"   // заказ сохранён впервые
+	EventOrderUpdated = "order.updated" // существующий заказ перезаписан
+)
+
+// OutboxEvent — событие, записанное в outbox в одной транзакции с заказом.
+type OutboxEvent struct {
+	ID          int64     // монотонный идентификатор (порядок записи)
+	AggregateID string    // order_uid
+	EventType   string    // EventOrderSaved | EventOrderUpdated
+	Payload     []byte    // JSON заказа
+	CreatedAt   time.Time // момент записи
+}
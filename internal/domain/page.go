@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor — курсор пагинации не удалось разобрать (подделан, обрезан или от другой версии).
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// OrderCursor — позиция keyset-пагинации: последний отданный заказ в порядке
+// (date_created DESC, order_uid DESC). Следующая страница начинается строго после него.
+type OrderCursor struct {
+	DateCreated time.Time `json:"d"`
+	OrderUID    string    `json:"u"`
+}
+
+// OrderPage — страница заказов; NextCursor пуст, если страница последняя.
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor string   `json:"next_cursor"`
+}
+
+// CursorAfter — курсор, указывающий на заказ order.
+func CursorAfter(order *Order) OrderCursor {
+	return OrderCursor{DateCreated: order.DateCreated, OrderUID: order.OrderUID}
+}
+
+// Encode — непрозрачное представление курсора для клиента (base64url без паддинга поверх JSON).
+func (c OrderCursor) Encode() string {
+	raw, _ := json.Marshal(c) // структура из time.Time и string — ошибки маршалинга невозможны
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeOrderCursor — разбор курсора, полученного от клиента.
+// Пустая строка — начало списка (nil, nil); мусор — ErrInvalidCursor.
+func DecodeOrderCursor(s string) (*OrderCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c OrderCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.OrderUID == "" || c.DateCreated.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidFilter — фильтр поиска пуст или противоречив.
+var ErrInvalidFilter = errors.New("invalid search filter")
+
+// OrderFilter — критерии поиска заказов; пустые поля не участвуют, заданные объединяются через AND.
+type OrderFilter struct {
+	TrackNumber     string    // orders.track_number (точное совпадение)
+	RID             string    // items.rid хотя бы одной позиции
+	Email           string    // deliveries.email (без учёта регистра)
+	Phone           string    // deliveries.phone (точное совпадение)
+	DeliveryService string    // orders.delivery_service
+	Brand           string    // items.brand хотя бы одной позиции
+	CreatedFrom     time.Time // date_created >= CreatedFrom (нулевое — без нижней границы)
+	CreatedTo       time.Time // date_created <  CreatedTo   (нулевое — без верхней границы)
+}
+
+// IsEmpty — ни один критерий не задан.
+func (f OrderFilter) IsEmpty() bool {
+	return f.TrackNumber == "" && f.RID == "" && f.Email == "" && f.Phone == "" &&
+		f.DeliveryService == "" && f.Brand == "" && f.CreatedFrom.IsZero() && f.CreatedTo.IsZero()
+}
+
+// Validate — поиск без критериев (полный перебор таблицы) и пустой интервал дат запрещены.
+func (f OrderFilter) Validate() error {
+	if f.IsEmpty() {
+		return fmt.Errorf("%w: at least one criterion required", ErrInvalidFilter)
+	}
+	if !f.CreatedFrom.IsZero() && !f.CreatedTo.IsZero() && !f.CreatedFrom.Before(f.CreatedTo) {
+		return fmt.Errorf("%w: date_created range is empty", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// ItemSearchHit — заказ, найденный полнотекстовым поиском по названиям и брендам позиций.
+type ItemSearchHit struct {
+	OrderUID string   `json:"order_uid"`
+	Rank     float64  `json:"rank"`     // лучший ранг среди совпавших позиций заказа
+	Snippets []string `json:"snippets"` // совпавшие позиции «name · brand», совпадения обёрнуты в <mark></mark>
+}
@@ -2,8 +2,11 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"math/rand"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Gunvolt24/wb_l0/internal/ports"
@@ -23,22 +26,44 @@ type reader interface {
 	Close() error
 }
 
+// writer — минимальный контракт над kafka.Writer для публикации в служебные топики (DLQ, parking).
+type writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
 // messageSaver — зависимость на бизнес-логику,
 // которая парсит/валидирует/сохраняет сообщение.
 type messageSaver interface {
 	SaveFromMessage(ctx context.Context, raw []byte) error
 }
 
+// batchSaver — опциональное расширение messageSaver для пакетного режима:
+// results[i] — ошибка i-го сообщения, err — ошибка общей транзакции.
+type batchSaver interface {
+	SaveBatchFromMessages(ctx context.Context, raws [][]byte) (results []error, err error)
+}
+
 // Consumer — обёртка над kafka.Reader + зависимостями (usecase, logger).
 type Consumer struct {
-	reader         reader
-	service        messageSaver
-	log            ports.Logger
-	processTimeout time.Duration
-	retryInitial   time.Duration
-	retryMax       time.Duration
-	jitterRand     *rand.Rand
-	closeOnce      sync.Once
+	reader          reader
+	writer          writer // nil, если служебные топики не настроены
+	service         messageSaver
+	batchService    batchSaver // nil — пакетный режим недоступен
+	log             ports.Logger
+	processTimeout  time.Duration
+	retryInitial    time.Duration
+	retryMax        time.Duration
+	maxAttempts     int // <= 0 — без ограничения (оффсет не коммитится до успешной обработки)
+	deadLetterTopic string
+	parkingTopic    string
+	batchSize       int           // > 1 — пакетный режим
+	batchLinger     time.Duration // сколько ждать добора пакета после первого сообщения
+	workerCount     int           // > 1 — параллельная обработка пулом воркеров
+	jitterRand      *rand.Rand
+	jitterMu        sync.Mutex
+	closeOnce       sync.Once
+	lastFetch       atomic.Int64 // unix-наносекунды последнего успешного FetchMessage (до первого — запуска Run)
 }
 
 // NewConsumer — конструктор. readerConfig() настроен на ручной коммит оффсетов.
@@ -61,55 +86,85 @@ func NewConsumer(cfg *ConsumerConfig, service messageSaver, log ports.Logger) *C
 		rMax = 30 * time.Second
 	}
 
-	return &Consumer{
+	consumer := &Consumer{
 		reader:         reader,
 		service:        service,
 		log:            log,
 		processTimeout: pt,
 		retryInitial:   rInit,
 		retryMax:       rMax,
+		maxAttempts:    cfg.MaxAttempts,
+		workerCount:    cfg.WorkerCount,
 		// jitterRand — источник случайности, чтобы рассинхронизировать экспоненциальный backoff.
 		jitterRand: rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
+
+	// Служебные топики — опционально: без DLQ невалидные сообщения просто пропускаются,
+	// parking-топик по умолчанию совпадает с DLQ.
+	consumer.deadLetterTopic = strings.TrimSpace(cfg.DeadLetterTopic)
+	consumer.parkingTopic = strings.TrimSpace(cfg.ParkingTopic)
+	if consumer.parkingTopic == "" {
+		consumer.parkingTopic = consumer.deadLetterTopic
+	}
+	if consumer.deadLetterTopic != "" || consumer.parkingTopic != "" {
+		consumer.writer = cfg.writerConfig()
+	}
+
+	// Пакетный режим включается, только если сервис умеет сохранять пакетом.
+	if bs, ok := service.(batchSaver); ok && cfg.BatchSize > 1 {
+		consumer.batchService = bs
+		consumer.batchSize = cfg.BatchSize
+		consumer.batchLinger = cfg.BatchLinger
+		if consumer.batchLinger <= 0 {
+			consumer.batchLinger = 100 * time.Millisecond
+		}
+	}
+
+	return consumer
 }
 
 // Run — основной цикл:
 // 1) читаем сообщение без авто-коммита;
 // 2) успешная обработка → CommitMessages;
-// 3) невалидные данные → лог и CommitMessages (пропускаем навсегда);
-// 4) временная ошибка → без коммита (повторная обработка, at-least-once).
+// 3) постоянный отказ (невалидные данные, ограничения БД) → dead-letter топик (если настроен) и CommitMessages;
+// 4) временная ошибка → повтор на месте до MaxAttempts, затем parking-топик и CommitMessages;
+// без MaxAttempts — без коммита (повторная обработка, at-least-once).
+// При BatchSize > 1 работает пакетный цикл (см. runBatches), при WorkerCount > 1 — пул воркеров (см. runWorkers).
 func (c *Consumer) Run(ctx context.Context) error {
 	rc := c.reader.Config()
+	c.markFetched()
 	c.log.Infof(ctx, "kafka consumer started topic=%s group_id=%s brokers=%v", rc.Topic, rc.GroupID, rc.Brokers)
 
 	// Экспоненциальный backoff на ошибках FetchMessage с equal-jitter
 	retry := c.retryInitial
 
+	// Пакетный режим: N сообщений → одна транзакция → коммит максимальных оффсетов.
+	if c.batchSize > 1 {
+		return c.runBatches(ctx, rc.Topic)
+	}
+
+	// Параллельный режим: порядок сохраняется в пределах ключа/order_uid.
+	if c.workerCount > 1 {
+		return c.runWorkers(ctx, rc.Topic)
+	}
+
 	for {
 		// Читаем сообщение (без автокоммита)
 		msg, fetchErr := c.reader.FetchMessage(ctx)
 		if fetchErr != nil {
-			// Если контекст отменен -> выходим
-			if ctx.Err() != nil {
+			if !c.waitAfterFetchError(ctx, fetchErr, &retry) {
 				return ctx.Err()
 			}
-			// Иначе - временная ошибка брокера/сети. Ожидаем и повторяем
-			sleep := c.withJitterEqual(retry)
-			c.log.Warnf(ctx, "fetch failed: %v (will retry in %s)", fetchErr, sleep)
-			if !c.sleepWithBackoff(ctx, sleep) {
-				return ctx.Err()
-			}
-			// nextBackoff возвращает следующее время ожидания повтора с учетом retryMax.
-			retry = c.nextBackoff(retry)
 			continue
 		}
 
 		// Успешный FetchMessage -> сбрасываем интервал ожидания и инкрементим метрики
+		c.markFetched()
 		retry = c.retryInitial
 		metrics.KafkaMessagesConsumed.WithLabelValues(rc.Topic).Inc()
 
-		// Обрабатываем сообщение(с таймаутом внутри)
-		if shouldCommit := c.handleMessage(ctx, rc.Topic, &msg); shouldCommit {
+		// Обрабатываем сообщение (с таймаутом и ограниченными повторами внутри)
+		if shouldCommit := c.processMessage(ctx, rc.Topic, &msg); shouldCommit {
 			// Успешная обработка -> коммитим оффсет
 			c.commitSafely(ctx, &msg)
 		} else {
@@ -120,10 +175,23 @@ func (c *Consumer) Run(ctx context.Context) error {
 	}
 }
 
-// Close - закрывает reader. Вызывается при остановке приложения.
+// LastFetch — время последнего успешного FetchMessage (до первого сообщения — время запуска Run;
+// нулевое — Run не запускался). Для проверки готовности: на пустом топике FetchMessage просто ждёт.
+func (c *Consumer) LastFetch() time.Time {
+	ns := c.lastFetch.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// Close - закрывает reader (и writer dead-letter топика). Вызывается при остановке приложения.
 func (c *Consumer) Close() (retErr error) {
 	c.closeOnce.Do(func() {
 		retErr = c.reader.Close()
+		if c.writer != nil {
+			retErr = errors.Join(retErr, c.writer.Close())
+		}
 	})
 	return retErr
 }
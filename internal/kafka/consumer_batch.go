@@ -0,0 +1,146 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// runBatches — пакетный цикл:
+// 1) собираем до batchSize сообщений, ожидая добора не дольше batchLinger;
+// 2) валидные сообщения сохраняем одной транзакцией, невалидные — в dead-letter топик;
+// 3) если транзакция не прошла — обрабатываем пакет по одному (изолируем проблемную запись);
+// 4) коммитим максимальный обработанный оффсет каждой партиции.
+func (c *Consumer) runBatches(ctx context.Context, topic string) error {
+	retry := c.retryInitial
+
+	for {
+		batch, fetchErr := c.fetchBatch(ctx)
+		if len(batch) == 0 {
+			if !c.waitAfterFetchError(ctx, fetchErr, &retry) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		retry = c.retryInitial
+		metrics.KafkaMessagesConsumed.WithLabelValues(topic).Add(float64(len(batch)))
+
+		done := c.processBatch(ctx, topic, batch)
+		if msgs := commitPoints(batch, done); len(msgs) > 0 {
+			if commitErr := c.reader.CommitMessages(ctx, msgs...); commitErr != nil {
+				c.log.Warnf(ctx, "batch commit failed size=%d: %v", len(batch), commitErr)
+			}
+		}
+	}
+}
+
+// fetchBatch — читает первое сообщение (блокирующе), затем добирает пакет до batchSize,
+// пока не истечёт batchLinger. Пустой пакет возвращается только вместе с ошибкой.
+func (c *Consumer) fetchBatch(ctx context.Context) ([]kafka.Message, error) {
+	first, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.markFetched()
+
+	batch := make([]kafka.Message, 1, c.batchSize)
+	batch[0] = first
+
+	lingerCtx, cancel := context.WithTimeout(ctx, c.batchLinger)
+	defer cancel()
+
+	for len(batch) < c.batchSize {
+		msg, err := c.reader.FetchMessage(lingerCtx)
+		if err != nil {
+			// Истёк linger или отменён контекст — отдаём то, что успели собрать.
+			if lingerCtx.Err() == nil {
+				c.log.Warnf(ctx, "fetch failed while filling batch: %v (batch size=%d)", err, len(batch))
+			}
+			break
+		}
+		batch = append(batch, msg)
+	}
+	return batch, nil
+}
+
+// processBatch — обрабатывает пакет и возвращает, какие сообщения можно коммитить.
+func (c *Consumer) processBatch(ctx context.Context, topic string, batch []kafka.Message) []bool {
+	done := make([]bool, len(batch))
+
+	raws := make([][]byte, len(batch))
+	for i := range batch {
+		raws[i] = batch[i].Value
+	}
+
+	spanCtx, span := startBatchSpan(ctx, topic, batch)
+	ctxTimeout, cancel := context.WithTimeout(spanCtx, c.processTimeout)
+	start := time.Now()
+	results, err := c.batchService.SaveBatchFromMessages(ctxTimeout, raws)
+	observeProcessing(topic, "batch", start)
+	cancel()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+
+	if err != nil {
+		// Транзакция пакета не прошла: обрабатываем по одному (с повторами, DLQ и parking).
+		c.log.Warnf(ctx, "batch save failed size=%d: %v (falling back to per-message processing)", len(batch), err)
+		for i := range batch {
+			if ctx.Err() != nil {
+				break
+			}
+			done[i] = c.processMessage(ctx, topic, &batch[i])
+		}
+		return done
+	}
+
+	for i := range batch {
+		msgErr := results[i]
+		switch {
+		case msgErr == nil:
+			metrics.KafkaMessagesProcessed.WithLabelValues(topic).Inc()
+			done[i] = true
+		case isPermanent(msgErr):
+			done[i] = c.rejectMessage(ctx, topic, &batch[i], msgErr)
+		default:
+			// Неклассифицированная ошибка отдельного сообщения — обычный путь с повторами.
+			done[i] = c.processMessage(ctx, topic, &batch[i])
+		}
+	}
+	return done
+}
+
+// commitPoints — для каждой партиции выбирает последнее сообщение непрерывного
+// обработанного префикса: оффсеты за первым необработанным сообщением не коммитятся.
+func commitPoints(batch []kafka.Message, done []bool) []kafka.Message {
+	last := make(map[partitionKey]int)
+	blocked := make(map[partitionKey]bool)
+	order := make([]partitionKey, 0, 1)
+
+	for i := range batch {
+		key := partitionKey{topic: batch[i].Topic, partition: batch[i].Partition}
+		if blocked[key] {
+			continue
+		}
+		if !done[i] {
+			blocked[key] = true
+			continue
+		}
+		if _, seen := last[key]; !seen {
+			order = append(order, key)
+		}
+		last[key] = i
+	}
+
+	msgs := make([]kafka.Message, 0, len(order))
+	for _, key := range order {
+		msgs = append(msgs, batch[last[key]])
+	}
+	return msgs
+}
@@ -17,6 +17,16 @@ type ConsumerConfig struct {
 	ProcessTimeout time.Duration // таймаут обработки одного сообщения
 	RetryInitial   time.Duration // начальное время повтора
 	RetryMax       time.Duration // максимальное время повтора
+
+	DeadLetterTopic string // топик для отклонённых валидацией сообщений (пусто — отключено)
+
+	MaxAttempts  int    // максимум попыток обработки одного сообщения (<= 0 — без ограничения)
+	ParkingTopic string // топик для сообщений, исчерпавших попытки (пусто — используется DeadLetterTopic)
+
+	BatchSize   int           // максимум сообщений в пакете (<= 1 — обработка по одному)
+	BatchLinger time.Duration // ожидание добора пакета после первого сообщения
+
+	WorkerCount int // число воркеров (<= 1 — обработка в одной горутине; пакетный режим приоритетнее)
 }
 
 // ReaderConfig — враппер для kafka.ReaderConfig
@@ -46,3 +56,15 @@ func (c *ConsumerConfig) readerConfig() kafka.ReaderConfig {
 
 	return rc
 }
+
+// writerConfig — сборка writer'а для служебных топиков (dead-letter, parking).
+// Topic не задаём: он указывается в каждом сообщении.
+// Hash-балансировщик сохраняет исходное распределение по ключу.
+func (c *ConsumerConfig) writerConfig() *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(c.Brokers...),
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+	}
+}
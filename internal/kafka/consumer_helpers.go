@@ -5,35 +5,105 @@ import (
 	"errors"
 	"time"
 
+	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/pkg/metrics"
 	"github.com/Gunvolt24/wb_l0/pkg/validate"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// processMessage — обработка сообщения с ограниченным числом попыток.
+// При maxAttempts <= 0 ведёт себя как раньше: временная ошибка → без коммита.
+// Иначе временные ошибки повторяются на месте с backoff, а после исчерпания попыток
+// сообщение паркуется в отдельный топик и коммитится, чтобы не блокировать партицию.
+// Без parking-топика сообщение не отбрасывается: повторы продолжаются (с backoff до retryMax),
+// пока обработка не пройдёт или не отменят контекст.
+// Вся обработка (включая повторы) идёт в consumer-спане, продолжающем трейс продюсера.
+func (c *Consumer) processMessage(ctx context.Context, topic string, msg *kafka.Message) bool {
+	ctx, span := startProcessSpan(ctx, topic, msg)
+	defer span.End()
+
+	delay := c.retryInitial
+	for attempt := 1; ; attempt++ {
+		metrics.KafkaMessageAttempts.WithLabelValues(topic).Inc()
+
+		shouldCommit, err := c.handleMessage(ctx, topic, msg)
+		if err != nil {
+			span.RecordError(err, trace.WithAttributes(attribute.Int("attempt", attempt)))
+		}
+		if shouldCommit || c.maxAttempts <= 0 || ctx.Err() != nil {
+			if err != nil {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return shouldCommit
+		}
+		if attempt >= c.maxAttempts && c.canPark() {
+			span.SetStatus(codes.Error, err.Error())
+			return c.parkMessage(ctx, topic, msg, attempt, err)
+		}
+		if attempt == c.maxAttempts {
+			c.log.Errorf(ctx, "attempts exhausted offset=%d attempts=%d: %v (parking topic not configured, retrying in place)",
+				msg.Offset, attempt, err)
+		}
+
+		if attempt < c.maxAttempts {
+			c.log.Infof(ctx, "retrying offset=%d attempt=%d/%d", msg.Offset, attempt+1, c.maxAttempts)
+		}
+		if !c.sleepWithBackoff(ctx, c.withJitterEqual(delay)) {
+			return false
+		}
+		delay = c.nextBackoff(delay)
+	}
+}
+
+// observeProcessing — записать время одной попытки обработки сообщения или пакета (mode: message | batch).
+// Паузы между повторами не входят: гистограмма показывает время обработки, а не политику повторов.
+func observeProcessing(topic, mode string, start time.Time) {
+	metrics.KafkaProcessingDuration.WithLabelValues(topic, mode).Observe(time.Since(start).Seconds())
+}
+
 // handleMessage обрабатывает одно сообщение и определяет нужно ли коммитить оффсет.
-func (c *Consumer) handleMessage(ctx context.Context, topic string, msg *kafka.Message) bool {
+// Вместе с решением возвращает ошибку обработки (nil при успехе).
+func (c *Consumer) handleMessage(ctx context.Context, topic string, msg *kafka.Message) (bool, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, c.processTimeout)
+	start := time.Now()
 	err := c.service.SaveFromMessage(ctxTimeout, msg.Value)
+	observeProcessing(topic, "message", start)
 	cancel()
 
 	switch {
 	case err == nil:
 		// Успешная обработка: фиксируем метрику и коммитим оффсет
 		metrics.KafkaMessagesProcessed.WithLabelValues(topic).Inc()
-		return true
-	case errors.Is(err, validate.ErrInvalidOrder):
-		// Невалидные данные: логируем и коммитим, чтобы не обрабатывать повторно
-		metrics.KafkaMessagesFailed.WithLabelValues(topic).Inc()
-		c.log.Warnf(ctx, "invalid message offset=%d: %v (skipped)", msg.Offset, err)
-		return true
+		return true, nil
+	case isPermanent(err):
+		// Постоянный отказ (невалидные данные, нарушение ограничений БД)
+		return c.rejectMessage(ctx, topic, msg, err), err
 	default:
-		// Временная ошибка(БД/сеть/таймаут): НЕ коммитим - будем обрабатывать повторно
+		// Временная (domain.ErrTransient, сеть, таймаут) или неклассифицированная ошибка:
+		// НЕ коммитим - будем обрабатывать повторно
 		metrics.KafkaMessagesFailed.WithLabelValues(topic).Inc()
 		c.log.Warnf(ctx, "process failed offset=%d: %v (will retry without commit)", msg.Offset, err)
-		return false
+		return false, err
 	}
 }
 
+// rejectMessage — постоянный отказ: откладываем сообщение в dead-letter топик
+// и коммитим, чтобы не обрабатывать повторно.
+// Если публикация не удалась — НЕ коммитим, иначе сообщение будет потеряно.
+func (c *Consumer) rejectMessage(ctx context.Context, topic string, msg *kafka.Message, err error) bool {
+	metrics.KafkaMessagesFailed.WithLabelValues(topic).Inc()
+	c.log.Warnf(ctx, "rejected message offset=%d: %v (skipped)", msg.Offset, err)
+	return c.publishDeadLetter(ctx, topic, msg, err)
+}
+
+// isPermanent — ошибка, которую повтор не исправит: невалидный заказ или нарушение ограничений БД.
+func isPermanent(err error) bool {
+	return errors.Is(err, validate.ErrInvalidOrder) || errors.Is(err, domain.ErrConstraintViolation)
+}
+
 // commitSafely пытается закоммитить оффсет и залогировать ошибку.
 func (c *Consumer) commitSafely(ctx context.Context, msg *kafka.Message) {
 	if commitErr := c.reader.CommitMessages(ctx, *msg); commitErr != nil {
@@ -41,6 +111,29 @@ func (c *Consumer) commitSafely(ctx context.Context, msg *kafka.Message) {
 	}
 }
 
+// markFetched — запомнить время успешного чтения (см. LastFetch).
+func (c *Consumer) markFetched() {
+	c.lastFetch.Store(time.Now().UnixNano())
+}
+
+// waitAfterFetchError — пауза после ошибки FetchMessage с экспоненциальным backoff.
+// Возвращает false, если контекст отменён (цикл нужно завершить).
+func (c *Consumer) waitAfterFetchError(ctx context.Context, fetchErr error, retry *time.Duration) bool {
+	// Если контекст отменен -> выходим
+	if ctx.Err() != nil {
+		return false
+	}
+	// Иначе - временная ошибка брокера/сети. Ожидаем и повторяем
+	sleep := c.withJitterEqual(*retry)
+	c.log.Warnf(ctx, "fetch failed: %v (will retry in %s)", fetchErr, sleep)
+	if !c.sleepWithBackoff(ctx, sleep) {
+		return false
+	}
+	// nextBackoff возвращает следующее время ожидания повтора с учетом retryMax.
+	*retry = c.nextBackoff(*retry)
+	return true
+}
+
 // sleepWithBackoff ждет backoff или останавливается по контексту.
 func (c *Consumer) sleepWithBackoff(ctx context.Context, d time.Duration) bool {
 	select {
@@ -67,7 +160,10 @@ func (c *Consumer) withJitterEqual(d time.Duration) time.Duration {
 		return 0
 	}
 	half := d / 2
+	// rand.Rand не потокобезопасен, а в режиме воркеров backoff считают несколько горутин.
+	c.jitterMu.Lock()
 	jitter := time.Duration(c.jitterRand.Int63n(int64(d-half) + 1))
+	c.jitterMu.Unlock()
 	return half + jitter
 }
 
@@ -3,14 +3,23 @@ package kafka
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/segmentio/kafka-go"
 
+	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/internal/kafka/mocks"
+	portmocks "github.com/Gunvolt24/wb_l0/internal/ports/mocks"
+	"github.com/Gunvolt24/wb_l0/internal/usecase"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
 	"github.com/Gunvolt24/wb_l0/pkg/validate"
 )
 
@@ -75,6 +84,109 @@ func TestRun_OK_Commits(t *testing.T) {
 	}
 }
 
+// LastFetch: нулевое до запуска, затем обновляется успешным чтением.
+func TestRun_LastFetchUpdated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	r.EXPECT().Config().Return(kafka.ReaderConfig{Topic: "orders"}).AnyTimes()
+	c := newTestConsumer(r, s)
+	if !c.LastFetch().IsZero() {
+		t.Fatalf("LastFetch must be zero before Run")
+	}
+
+	started := time.Now()
+	fetched := make(chan time.Time, 1)
+	r.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(func(context.Context) (kafka.Message, error) {
+		time.Sleep(5 * time.Millisecond) // чтение позже запуска Run
+		return kafka.Message{Offset: 1, Value: []byte("ok")}, nil
+	})
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("ok")).Return(nil)
+	r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil)
+	r.EXPECT().FetchMessage(gomock.Any()).DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+		fetched <- c.LastFetch()
+		<-ctx.Done()
+		return kafka.Message{}, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	select {
+	case last := <-fetched:
+		if last.Before(started.Add(5 * time.Millisecond)) {
+			t.Fatalf("LastFetch must be updated by the successful fetch: started=%v last=%v", started, last)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for second fetch")
+	}
+	cancel()
+	<-errCh
+}
+
+// Время обработки сообщения попадает в гистограмму с mode=message.
+func TestProcessMessage_ObservesDuration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mocks.NewMockmessageSaver(ctrl)
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("ok")).Return(nil)
+
+	c := newTestConsumer(mocks.NewMockreader(ctrl), s)
+	series := testutil.CollectAndCount(metrics.KafkaProcessingDuration)
+
+	if !c.processMessage(context.Background(), "orders-duration-test", &kafka.Message{Value: []byte("ok")}) {
+		t.Fatalf("successful message must be committed")
+	}
+	if got := testutil.CollectAndCount(metrics.KafkaProcessingDuration); got != series+1 {
+		t.Fatalf("processing duration must be observed, series %d -> %d", series, got)
+	}
+}
+
+// Каждая попытка — отдельное наблюдение; паузы backoff между повторами в гистограмму не входят
+func TestProcessMessage_ObservesEachAttemptWithoutBackoff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s := mocks.NewMockmessageSaver(ctrl)
+	gomock.InOrder(
+		s.EXPECT().SaveFromMessage(gomock.Any(), gomock.Any()).Return(domain.ErrTransient),
+		s.EXPECT().SaveFromMessage(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	c := newTestConsumer(mocks.NewMockreader(ctrl), s)
+	c.maxAttempts = 3
+	c.retryInitial, c.retryMax = 40*time.Millisecond, 40*time.Millisecond // пауза не меньше 20ms
+
+	const topic = "orders-attempt-duration-test"
+	if !c.processMessage(context.Background(), topic, &kafka.Message{Value: []byte("ok")}) {
+		t.Fatalf("message must be committed after a successful retry")
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(metrics.KafkaProcessingDuration)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	found := false
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			if m.GetLabel()[1].GetValue() != topic { // лейблы отсортированы: mode, topic
+				continue
+			}
+			found = true
+			h := m.GetHistogram()
+			if h.GetSampleCount() != 2 {
+				t.Fatalf("want one observation per attempt (2), got %d", h.GetSampleCount())
+			}
+			if h.GetSampleSum() >= 0.02 {
+				t.Fatalf("backoff must not be observed, sum=%vs", h.GetSampleSum())
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("no observations for topic %s", topic)
+	}
+}
+
 // Невалидное сообщение => тоже коммитим (чтобы не ретраить мусор)
 func TestRun_InvalidOrder_Commits(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -238,3 +350,676 @@ func TestClose_DelegatesToReader(t *testing.T) {
 		t.Fatalf("expected nil from Close, got %v", err)
 	}
 }
+
+// Невалидное сообщение при настроенном DLQ => публикуем в dead-letter топик и только потом коммитим
+func TestRun_InvalidOrder_DeadLetterThenCommit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	w := mocks.NewMockwriter(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	src := kafka.Message{
+		Partition: 2, Offset: 7, Key: []byte("k"), Value: []byte("bad"),
+		Headers: []kafka.Header{{Key: "traceparent", Value: []byte("tp")}},
+	}
+	r.EXPECT().FetchMessage(gomock.Any()).Return(src, nil)
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("bad")).Return(validate.ErrInvalidOrder)
+
+	var published kafka.Message
+	gomock.InOrder(
+		w.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+				published = msgs[0]
+				return nil
+			}),
+		r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+			<-ctx.Done()
+			return kafka.Message{}, ctx.Err()
+		})
+
+	c := newTestConsumer(r, s)
+	c.writer, c.deadLetterTopic = w, "orders-dlq"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+
+	if published.Topic != "orders-dlq" || string(published.Key) != "k" || string(published.Value) != "bad" {
+		t.Fatalf("unexpected dead-letter message: %+v", published)
+	}
+	headers := make(map[string]string, len(published.Headers))
+	for _, h := range published.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	want := map[string]string{
+		"traceparent":         "tp",
+		HeaderSourceTopic:     "orders",
+		HeaderSourcePartition: "2",
+		HeaderSourceOffset:    "7",
+		HeaderError:           validate.ErrInvalidOrder.Error(),
+	}
+	for k, v := range want {
+		if headers[k] != v {
+			t.Fatalf("header %s: want %q, got %q", k, v, headers[k])
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, headers[HeaderFailedAt]); err != nil {
+		t.Fatalf("header %s must be RFC3339Nano, got %q", HeaderFailedAt, headers[HeaderFailedAt])
+	}
+}
+
+// Битый JSON и лишние данные после объекта — постоянная ошибка сервиса:
+// сообщение уходит в DLQ с первой попытки, без повторов и parking
+func TestRun_MalformedJSON_DeadLetterOnFirstAttempt(t *testing.T) {
+	for name, raw := range map[string]string{
+		"malformed": `{"order_uid":`,
+		"trailing":  `{"order_uid":"o1"} {}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			r := mocks.NewMockreader(ctrl)
+			w := mocks.NewMockwriter(ctrl)
+
+			// Настоящий сервис: до репозитория, кэша и валидатора дело не доходит
+			svc := usecase.NewOrderService(
+				portmocks.NewMockOrderRepository(ctrl), portmocks.NewMockOrderCache(ctrl),
+				nopLogger{}, portmocks.NewMockOrderValidator(ctrl),
+			)
+
+			rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+			r.EXPECT().Config().Return(rc).AnyTimes()
+			r.EXPECT().FetchMessage(gomock.Any()).Return(kafka.Message{Offset: 1, Value: []byte(raw)}, nil)
+			gomock.InOrder(
+				w.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+						if len(msgs) != 1 || msgs[0].Topic != "orders-dlq" {
+							t.Errorf("want one dead-letter message, got %+v", msgs)
+						}
+						return nil
+					}),
+				r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil),
+			)
+			r.EXPECT().FetchMessage(gomock.Any()).
+				DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+					<-ctx.Done()
+					return kafka.Message{}, ctx.Err()
+				})
+
+			c := newTestConsumer(r, svc)
+			c.writer, c.deadLetterTopic, c.parkingTopic = w, "orders-dlq", "orders-parking"
+			c.maxAttempts = 3
+			before := testutil.ToFloat64(metrics.KafkaMessageAttempts.WithLabelValues("orders"))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := runAsync(ctx, c)
+
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+
+			select {
+			case err := <-errCh:
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("want context.Canceled, got %v", err)
+				}
+			case <-time.After(200 * time.Millisecond):
+				t.Fatal("timeout waiting for Run to stop")
+			}
+			if got := testutil.ToFloat64(metrics.KafkaMessageAttempts.WithLabelValues("orders")) - before; got != 1 {
+				t.Fatalf("want a single attempt, got %v", got)
+			}
+		})
+	}
+}
+
+// Публикация в DLQ не удалась => НЕ коммитим (сообщение не должно потеряться)
+func TestRun_InvalidOrder_DeadLetterFailed_NoCommit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	w := mocks.NewMockwriter(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		Return(kafka.Message{Offset: 8, Value: []byte("bad")}, nil)
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("bad")).Return(validate.ErrInvalidOrder)
+	w.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).Return(errors.New("broker down"))
+	// CommitMessages не ожидаем
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+			<-ctx.Done()
+			return kafka.Message{}, ctx.Err()
+		})
+
+	c := newTestConsumer(r, s)
+	c.writer, c.deadLetterTopic = w, "orders-dlq"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+}
+
+// Close закрывает и reader, и writer dead-letter топика
+func TestClose_ClosesDeadLetterWriter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	w := mocks.NewMockwriter(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	r.EXPECT().Close().Return(nil)
+	w.EXPECT().Close().Return(nil)
+
+	c := newTestConsumer(r, s)
+	c.writer = w
+	if err := c.Close(); err != nil {
+		t.Fatalf("expected nil from Close, got %v", err)
+	}
+}
+
+// Временная ошибка при MaxAttempts > 0 => повтор на месте; после успеха — один коммит
+func TestRun_TemporaryFailure_RetriedInPlace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		Return(kafka.Message{Offset: 4, Value: []byte("x")}, nil)
+	gomock.InOrder(
+		s.EXPECT().SaveFromMessage(gomock.Any(), []byte("x")).Return(errors.New("db down")).Times(2),
+		s.EXPECT().SaveFromMessage(gomock.Any(), []byte("x")).Return(nil),
+		r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+			<-ctx.Done()
+			return kafka.Message{}, ctx.Err()
+		})
+
+	c := newTestConsumer(r, s)
+	c.maxAttempts = 3
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(60 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+}
+
+// Попытки исчерпаны => сообщение уходит в parking-топик с числом попыток и коммитится
+func TestRun_AttemptsExhausted_ParkedThenCommit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	w := mocks.NewMockwriter(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		Return(kafka.Message{Offset: 5, Key: []byte("k"), Value: []byte("poison")}, nil)
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("poison")).Return(errors.New("check violation")).Times(3)
+
+	var parked kafka.Message
+	gomock.InOrder(
+		w.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+				parked = msgs[0]
+				return nil
+			}),
+		r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil),
+	)
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+			<-ctx.Done()
+			return kafka.Message{}, ctx.Err()
+		})
+
+	c := newTestConsumer(r, s)
+	c.writer, c.parkingTopic, c.maxAttempts = w, "orders-parking", 3
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(60 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+
+	if parked.Topic != "orders-parking" || string(parked.Value) != "poison" {
+		t.Fatalf("unexpected parked message: %+v", parked)
+	}
+	headers := make(map[string]string, len(parked.Headers))
+	for _, h := range parked.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if headers[HeaderAttempts] != "3" || headers[HeaderError] != "check violation" || headers[HeaderSourceOffset] != "5" {
+		t.Fatalf("unexpected parked headers: %v", headers)
+	}
+}
+
+// Конфигурация по умолчанию (MaxAttempts > 0, parking/DLQ не заданы): после исчерпания попыток
+// сообщение не отбрасывается — повторы продолжаются до успеха, и только затем коммит.
+func TestRun_AttemptsExhausted_NoParkingTopic_RetriesUntilSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		Return(kafka.Message{Offset: 7, Value: []byte("order")}, nil)
+	gomock.InOrder(
+		s.EXPECT().SaveFromMessage(gomock.Any(), []byte("order")).Return(errors.New("connection refused")).Times(5),
+		s.EXPECT().SaveFromMessage(gomock.Any(), []byte("order")).Return(nil),
+		r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil),
+	)
+	r.EXPECT().FetchMessage(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+			<-ctx.Done()
+			return kafka.Message{}, ctx.Err()
+		})
+
+	c := newTestConsumer(r, s)
+	c.maxAttempts = 3 // writer и parkingTopic не заданы
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(150 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+}
+
+// Постоянная ошибка БД (нарушение ограничения) => без повторов, коммитим сразу
+func TestRun_ConstraintViolation_CommitsWithoutRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		Return(kafka.Message{Offset: 9, Value: []byte("dup")}, nil)
+	// Ровно один вызов: повторов быть не должно, даже при maxAttempts > 1
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("dup")).
+		Return(fmt.Errorf("failed to save order: %w", domain.ErrDuplicatePaymentTransaction))
+	r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil)
+
+	r.EXPECT().FetchMessage(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+			<-ctx.Done()
+			return kafka.Message{}, ctx.Err()
+		})
+
+	c := newTestConsumer(r, s)
+	c.maxAttempts = 3
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+}
+
+// Пакетный режим: одна транзакция на пакет, невалидное — в DLQ, коммит максимального оффсета
+func TestRunBatches_SavesBatchAndCommitsMaxOffset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	w := mocks.NewMockwriter(ctrl)
+	bs := mocks.NewMockbatchSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	gomock.InOrder(
+		r.EXPECT().FetchMessage(gomock.Any()).Return(kafka.Message{Topic: "orders", Offset: 1, Value: []byte("a")}, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).Return(kafka.Message{Topic: "orders", Offset: 2, Value: []byte("bad")}, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).Return(kafka.Message{Topic: "orders", Offset: 3, Value: []byte("c")}, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).
+			DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+				<-ctx.Done()
+				return kafka.Message{}, ctx.Err()
+			}),
+	)
+
+	bs.EXPECT().SaveBatchFromMessages(gomock.Any(), [][]byte{[]byte("a"), []byte("bad"), []byte("c")}).
+		Return([]error{nil, fmt.Errorf("%w: empty order_uid", validate.ErrInvalidOrder), nil}, nil)
+	w.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).Return(nil)
+
+	var committed []kafka.Message
+	r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+			committed = msgs
+			return nil
+		})
+
+	c := newTestConsumer(r, mocks.NewMockmessageSaver(ctrl))
+	c.writer, c.deadLetterTopic = w, "orders-dlq"
+	c.batchService, c.batchSize, c.batchLinger = bs, 3, 10*time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+
+	if len(committed) != 1 || committed[0].Offset != 3 {
+		t.Fatalf("want single commit of offset 3, got %+v", committed)
+	}
+}
+
+// Пакетный режим: транзакция пакета упала => обработка по одному
+func TestRunBatches_TxFailure_FallsBackToPerMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+	bs := mocks.NewMockbatchSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	gomock.InOrder(
+		r.EXPECT().FetchMessage(gomock.Any()).Return(kafka.Message{Topic: "orders", Offset: 1, Value: []byte("a")}, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).Return(kafka.Message{Topic: "orders", Offset: 2, Value: []byte("b")}, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).
+			DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+				<-ctx.Done()
+				return kafka.Message{}, ctx.Err()
+			}),
+	)
+
+	bs.EXPECT().SaveBatchFromMessages(gomock.Any(), gomock.Any()).
+		Return(nil, errors.New("failed to save batch: connection reset"))
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("a")).Return(nil)
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("b")).Return(nil)
+
+	var committed []kafka.Message
+	r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+			committed = msgs
+			return nil
+		})
+
+	c := newTestConsumer(r, s)
+	c.batchService, c.batchSize, c.batchLinger = bs, 2, 10*time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+
+	if len(committed) != 1 || committed[0].Offset != 2 {
+		t.Fatalf("want single commit of offset 2, got %+v", committed)
+	}
+}
+
+// Оффсеты за первым необработанным сообщением партиции не коммитятся
+func TestCommitPoints_StopsAtFirstUnprocessed(t *testing.T) {
+	batch := []kafka.Message{
+		{Topic: "orders", Partition: 0, Offset: 10},
+		{Topic: "orders", Partition: 1, Offset: 20},
+		{Topic: "orders", Partition: 0, Offset: 11},
+		{Topic: "orders", Partition: 1, Offset: 21},
+		{Topic: "orders", Partition: 0, Offset: 12},
+	}
+	done := []bool{true, false, false, true, true}
+
+	got := commitPoints(batch, done)
+	if len(got) != 1 || got[0].Partition != 0 || got[0].Offset != 10 {
+		t.Fatalf("want only partition 0 offset 10, got %+v", got)
+	}
+}
+
+// Пул воркеров: один ключ обрабатывается по порядку, оффсет не коммитится раньше предыдущих
+func TestRunWorkers_OrderedByKey_CommitsContiguousPrefix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	gomock.InOrder(
+		r.EXPECT().FetchMessage(gomock.Any()).
+			Return(kafka.Message{Topic: "orders", Offset: 1, Key: []byte("a"), Value: []byte("a1")}, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).
+			Return(kafka.Message{Topic: "orders", Offset: 2, Key: []byte("b"), Value: []byte("b1")}, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).
+			Return(kafka.Message{Topic: "orders", Offset: 3, Key: []byte("a"), Value: []byte("a2")}, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).
+			DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+				<-ctx.Done()
+				return kafka.Message{}, ctx.Err()
+			}),
+	)
+
+	var (
+		mu        sync.Mutex
+		processed []string
+		firstDone bool
+		committed int64
+	)
+	s.EXPECT().SaveFromMessage(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, raw []byte) error {
+			if string(raw) == "a1" {
+				time.Sleep(20 * time.Millisecond) // медленное первое сообщение
+			}
+			mu.Lock()
+			processed = append(processed, string(raw))
+			if string(raw) == "a1" {
+				firstDone = true
+			}
+			mu.Unlock()
+			return nil
+		}).Times(3)
+	r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, m := range msgs {
+				if !firstDone {
+					t.Errorf("offset %d committed before offset 1 was processed", m.Offset)
+				}
+				if m.Offset > committed {
+					committed = m.Offset
+				}
+			}
+			return nil
+		}).AnyTimes()
+
+	c := newTestConsumer(r, s)
+	c.workerCount = 4
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(60 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if committed != 3 {
+		t.Fatalf("want committed offset 3, got %d", committed)
+	}
+	posA1, posA2 := -1, -1
+	for i, v := range processed {
+		switch v {
+		case "a1":
+			posA1 = i
+		case "a2":
+			posA2 = i
+		}
+	}
+	if posA1 < 0 || posA2 < 0 || posA1 > posA2 {
+		t.Fatalf("messages with the same key processed out of order: %v", processed)
+	}
+}
+
+// Пул воркеров: сообщение, которое никогда не обрабатывается, повторяется на месте,
+// а более поздние оффсеты партиции не коммитятся в обход него
+func TestRunWorkers_NeverSucceeds_RetriesInPlaceWithoutCommit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	r := mocks.NewMockreader(ctrl)
+	s := mocks.NewMockmessageSaver(ctrl)
+
+	rc := kafka.ReaderConfig{Topic: "orders", GroupID: "g1", Brokers: []string{"b:9092"}}
+	r.EXPECT().Config().Return(rc).AnyTimes()
+
+	stuck := kafka.Message{Topic: "orders", Offset: 1, Key: []byte("a"), Value: []byte("bad")}
+	next := kafka.Message{Topic: "orders", Offset: 2, Key: []byte("b"), Value: []byte("ok")}
+	if workerIndex(&stuck, 4) == workerIndex(&next, 4) {
+		t.Fatal("test keys must be routed to different workers")
+	}
+
+	gomock.InOrder(
+		r.EXPECT().FetchMessage(gomock.Any()).Return(stuck, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).Return(next, nil),
+		r.EXPECT().FetchMessage(gomock.Any()).
+			DoAndReturn(func(ctx context.Context) (kafka.Message, error) {
+				<-ctx.Done()
+				return kafka.Message{}, ctx.Err()
+			}),
+	)
+
+	var attempts atomic.Int32
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("bad")).
+		DoAndReturn(func(context.Context, []byte) error {
+			attempts.Add(1)
+			return domain.ErrTransient
+		}).MinTimes(2)
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("ok")).Return(nil)
+	// Оффсет 1 не обработан — коммитить нечего, в том числе оффсет 2
+	r.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Times(0)
+
+	c := newTestConsumer(r, s)
+	c.workerCount = 4
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := runAsync(ctx, c)
+
+	time.Sleep(80 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("want context.Canceled, got %v", err)
+		}
+	case <-time.After(200 * time.Millisecond):
+		t.Fatal("timeout waiting for Run to stop")
+	}
+	if attempts.Load() < 2 {
+		t.Fatalf("want stuck message retried in place, got %d attempts", attempts.Load())
+	}
+}
+
+// Без ключа воркер выбирается по order_uid из payload
+func TestWorkerIndex_FallsBackToOrderUID(t *testing.T) {
+	keyed := kafka.Message{Key: []byte("order-42")}
+	keyless := kafka.Message{Value: []byte(`{"order_uid":"order-42","track_number":"T"}`)}
+
+	for _, n := range []int{2, 3, 8} {
+		if workerIndex(&keyed, n) != workerIndex(&keyless, n) {
+			t.Fatalf("workers=%d: keyless message must be routed by order_uid", n)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/segmentio/kafka-go"
+)
+
+// workerQueueSize — ёмкость очереди одного воркера. Полная очередь тормозит чтение (backpressure).
+const workerQueueSize = 16
+
+// partitionInFlight — максимум незакоммиченных оффсетов одной партиции: дальше чтение ждёт,
+// пока не обработается самое раннее сообщение.
+const partitionInFlight = 1024
+
+// runWorkers — параллельный цикл:
+// 1) сообщение направляется воркеру по хэшу ключа (или order_uid из payload) — один ключ всегда
+// обрабатывается одним воркером, поэтому порядок по заказу сохраняется;
+// 2) воркер обрабатывает сообщение так же, как последовательный цикл (повторы, DLQ, parking),
+// но не пропускает его: пока сообщение не обработано, воркер повторяет его на месте;
+// 3) коммитится только непрерывный обработанный префикс каждой партиции (см. offsetTracker),
+// а число незакоммиченных оффсетов партиции ограничено partitionInFlight.
+func (c *Consumer) runWorkers(ctx context.Context, topic string) error {
+	tracker := newOffsetTracker(partitionInFlight)
+	commitSignal := make(chan struct{}, 1)
+
+	queues := make([]chan kafka.Message, c.workerCount)
+	var workers sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, workerQueueSize)
+		workers.Add(1)
+		go func(queue <-chan kafka.Message) {
+			defer workers.Done()
+			c.runWorker(ctx, topic, queue, tracker, commitSignal)
+		}(queues[i])
+	}
+
+	// Коммиты — из одной горутины, чтобы оффсеты партиции не откатывались назад.
+	committerDone := make(chan struct{})
+	go func() {
+		defer close(committerDone)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-commitSignal:
+				c.commitTracked(ctx, tracker)
+			}
+		}
+	}()
+
+	err := c.dispatch(ctx, topic, queues, tracker)
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	workers.Wait()
+	<-committerDone
+
+	// Дофиксируем то, что воркеры успели обработать до остановки.
+	ctxFinal, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.processTimeout)
+	c.commitTracked(ctxFinal, tracker)
+	cancel()
+
+	return err
+}
+
+// dispatch — читает сообщения и раскладывает их по очередям воркеров до отмены контекста.
+func (c *Consumer) dispatch(ctx context.Context, topic string, queues []chan kafka.Message, tracker *offsetTracker) error {
+	retry := c.retryInitial
+
+	for {
+		msg, fetchErr := c.reader.FetchMessage(ctx)
+		if fetchErr != nil {
+			if !c.waitAfterFetchError(ctx, fetchErr, &retry) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		c.markFetched()
+		retry = c.retryInitial
+		metrics.KafkaMessagesConsumed.WithLabelValues(topic).Inc()
+
+		if err := tracker.track(ctx, &msg); err != nil {
+			return err
+		}
+		queue := queues[workerIndex(&msg, len(queues))]
+
+		metrics.KafkaWorkerQueueDepth.WithLabelValues(topic).Inc()
+		select {
+		case queue <- msg:
+		case <-ctx.Done():
+			metrics.KafkaWorkerQueueDepth.WithLabelValues(topic).Dec()
+			return ctx.Err()
+		}
+	}
+}
+
+// runWorker — обрабатывает очередь одного воркера до её закрытия.
+// Сообщение, которое не удалось обработать, повторяется на месте (с backoff до retryMax):
+// следующие оффсеты партиции всё равно не закоммитятся раньше него, а пропуск оставил бы дыру в префиксе.
+func (c *Consumer) runWorker(
+	ctx context.Context, topic string, queue <-chan kafka.Message, tracker *offsetTracker, commitSignal chan<- struct{},
+) {
+	for msg := range queue {
+		metrics.KafkaWorkerQueueDepth.WithLabelValues(topic).Dec()
+		// После отмены остаток очереди не обрабатываем: оффсеты не закоммичены, сообщения будут перечитаны.
+		if ctx.Err() != nil {
+			continue
+		}
+
+		metrics.KafkaWorkersBusy.WithLabelValues(topic).Inc()
+		done := c.processUntilDone(ctx, topic, &msg)
+		metrics.KafkaWorkersBusy.WithLabelValues(topic).Dec()
+
+		if done && tracker.markDone(&msg) {
+			select {
+			case commitSignal <- struct{}{}:
+			default: // сигнал уже ждёт коммиттера
+			}
+		}
+	}
+}
+
+// processUntilDone — processMessage до успеха (или DLQ/parking) либо отмены контекста.
+func (c *Consumer) processUntilDone(ctx context.Context, topic string, msg *kafka.Message) bool {
+	delay := c.retryInitial
+	for {
+		if c.processMessage(ctx, topic, msg) {
+			return true
+		}
+		c.log.Warnf(ctx, "worker keeps offset=%d partition=%d (will retry in place)", msg.Offset, msg.Partition)
+		if !c.sleepWithBackoff(ctx, c.withJitterEqual(delay)) {
+			return false
+		}
+		delay = c.nextBackoff(delay)
+	}
+}
+
+// commitTracked — коммитит накопленный обработанный префикс всех партиций.
+func (c *Consumer) commitTracked(ctx context.Context, tracker *offsetTracker) {
+	msgs := tracker.drain()
+	if len(msgs) == 0 {
+		return
+	}
+	if commitErr := c.reader.CommitMessages(ctx, msgs...); commitErr != nil {
+		c.log.Warnf(ctx, "commit failed partitions=%d: %v", len(msgs), commitErr)
+	}
+}
+
+// workerIndex — выбирает воркера по ключу сообщения; без ключа — по order_uid из payload,
+// без него — по партиции (тогда порядок сохраняется в пределах партиции).
+func workerIndex(msg *kafka.Message, workers int) int {
+	key := msg.Key
+	if len(key) == 0 {
+		var probe struct {
+			OrderUID string `json:"order_uid"`
+		}
+		if json.Unmarshal(msg.Value, &probe) == nil && probe.OrderUID != "" {
+			key = []byte(probe.OrderUID)
+		} else {
+			key = []byte(msg.Topic + "/" + strconv.Itoa(msg.Partition))
+		}
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+	return int(h.Sum32() % uint32(workers))
+}
@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/segmentio/kafka-go"
+)
+
+// Заголовки, которыми помечается сообщение в dead-letter/parking топике.
+const (
+	HeaderSourceTopic     = "x-source-topic"     // исходный топик
+	HeaderSourcePartition = "x-source-partition" // исходная партиция
+	HeaderSourceOffset    = "x-source-offset"    // исходный оффсет
+	HeaderError           = "x-error"            // текст ошибки обработки
+	HeaderFailedAt        = "x-failed-at"        // момент отклонения (RFC3339Nano, UTC)
+	HeaderAttempts        = "x-attempts"         // число попыток обработки (только parking)
+)
+
+// publishDeadLetter — публикует отклонённое сообщение в dead-letter топик.
+// Возвращает true, если оффсет можно коммитить: DLQ не настроен или публикация прошла успешно.
+func (c *Consumer) publishDeadLetter(ctx context.Context, topic string, msg *kafka.Message, reason error) bool {
+	if c.writer == nil || c.deadLetterTopic == "" {
+		return true
+	}
+
+	dlqMsg := deadLetterMessage(c.deadLetterTopic, topic, msg, reason, time.Now())
+	if err := c.writer.WriteMessages(ctx, dlqMsg); err != nil {
+		c.log.Warnf(ctx, "dead-letter publish failed topic=%s offset=%d: %v (will retry without commit)",
+			c.deadLetterTopic, msg.Offset, err)
+		return false
+	}
+
+	metrics.KafkaMessagesDeadLettered.WithLabelValues(topic).Inc()
+	c.log.Infof(ctx, "message moved to dead-letter topic=%s partition=%d offset=%d",
+		c.deadLetterTopic, msg.Partition, msg.Offset)
+	return true
+}
+
+// canPark — parking-топик настроен (сообщение, исчерпавшее попытки, есть куда перенести).
+func (c *Consumer) canPark() bool {
+	return c.writer != nil && c.parkingTopic != ""
+}
+
+// parkMessage — переносит сообщение, исчерпавшее попытки, в parking-топик.
+// Вызывается только при настроенном parking-топике (см. canPark). Возвращает true, если оффсет
+// можно коммитить; при ошибке публикации — false: сообщение нельзя терять.
+func (c *Consumer) parkMessage(ctx context.Context, topic string, msg *kafka.Message, attempts int, reason error) bool {
+	parked := deadLetterMessage(c.parkingTopic, topic, msg, reason, time.Now())
+	parked.Headers = append(parked.Headers, kafka.Header{Key: HeaderAttempts, Value: []byte(strconv.Itoa(attempts))})
+	if err := c.writer.WriteMessages(ctx, parked); err != nil {
+		c.log.Warnf(ctx, "parking publish failed topic=%s offset=%d: %v (will retry without commit)",
+			c.parkingTopic, msg.Offset, err)
+		return false
+	}
+
+	metrics.KafkaMessagesParked.WithLabelValues(topic).Inc()
+	c.log.Warnf(ctx, "message parked after %d attempts topic=%s partition=%d offset=%d: %v",
+		attempts, c.parkingTopic, msg.Partition, msg.Offset, reason)
+	return true
+}
+
+// deadLetterMessage — собирает сообщение для DLQ/parking: исходные ключ, payload и заголовки
+// плюс заголовки с координатами источника, текстом ошибки и временем отклонения.
+func deadLetterMessage(dlqTopic, srcTopic string, msg *kafka.Message, reason error, now time.Time) kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+6)
+	headers = append(headers, msg.Headers...)
+
+	errText := ""
+	if reason != nil {
+		errText = reason.Error()
+	}
+
+	headers = append(headers,
+		kafka.Header{Key: HeaderSourceTopic, Value: []byte(srcTopic)},
+		kafka.Header{Key: HeaderSourcePartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderSourceOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderError, Value: []byte(errText)},
+		kafka.Header{Key: HeaderFailedAt, Value: []byte(now.UTC().Format(time.RFC3339Nano))},
+	)
+
+	return kafka.Message{
+		Topic:   dlqTopic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
@@ -62,7 +62,7 @@ func TestKafka_Valid_Saved_TC(t *testing.T) {
 	t.Cleanup(func() { _ = cleanup() })
 
 	repo := pgrepo.NewOrderRepository(pool)
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 
 	consumer := ikafka.NewConsumer(&ikafka.ConsumerConfig{
 		Brokers:        kf.Brokers,
@@ -123,7 +123,7 @@ func TestKafka_Skip_InvalidJSON_Then_SaveValid_TC(t *testing.T) {
 	require.NoError(t, testutil.EnsureTopic(ctx, kf.Brokers[0], topic))
 
 	// Консьюмер с обычным сервисом
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 	consumer := ikafka.NewConsumer(&ikafka.ConsumerConfig{
 		Brokers:        kf.Brokers,
 		Topic:          topic,
@@ -174,7 +174,7 @@ func TestKafka_Skip_ValidationError_Then_SaveValid_TC(t *testing.T) {
 	topic, group := testutil.UniqueTopicAndGroup(kf.BaseTopic + "-invalid-order-" + safe(t))
 	require.NoError(t, testutil.EnsureTopic(ctx, kf.Brokers[0], topic))
 
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 	consumer := ikafka.NewConsumer(&ikafka.ConsumerConfig{
 		Brokers:        kf.Brokers,
 		Topic:          topic,
@@ -237,7 +237,7 @@ func TestKafka_StartOffset_Last_IgnoresOld_TC(t *testing.T) {
 	writeMsg(t, ctx, kf.Brokers, topic, rold)
 
 	// 2) Запускаем консьюмера с StartOffset="last"
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 	consumer := ikafka.NewConsumer(&ikafka.ConsumerConfig{
 		Brokers:     kf.Brokers,
 		Topic:       topic,
@@ -331,7 +331,7 @@ func TestKafka_Redelivery_AfterRestart_NoCommit_TC(t *testing.T) {
 	defer pool.Close()
 
 	repo := pgrepo.NewOrderRepository(pool)
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 
 	consumerOK := ikafka.NewConsumer(&ikafka.ConsumerConfig{
 		Brokers:     kf.Brokers,
@@ -369,7 +369,7 @@ func TestKafka_Idempotent_DuplicateMessage_TC(t *testing.T) {
 	topic, group := testutil.UniqueTopicAndGroup(kf.BaseTopic + "-dup-" + safe(t))
 	require.NoError(t, testutil.EnsureTopic(ctx, kf.Brokers[0], topic))
 
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 	consumer := ikafka.NewConsumer(&ikafka.ConsumerConfig{
 		Brokers:     kf.Brokers,
 		Topic:       topic,
@@ -97,6 +97,62 @@ func (mr *MockreaderMockRecorder) FetchMessage(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchMessage", reflect.TypeOf((*Mockreader)(nil).FetchMessage), ctx)
 }
 
+// Mockwriter is a mock of writer interface.
+type Mockwriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockwriterMockRecorder
+}
+
+// MockwriterMockRecorder is the mock recorder for Mockwriter.
+type MockwriterMockRecorder struct {
+	mock *Mockwriter
+}
+
+// NewMockwriter creates a new mock instance.
+func NewMockwriter(ctrl *gomock.Controller) *Mockwriter {
+	mock := &Mockwriter{ctrl: ctrl}
+	mock.recorder = &MockwriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockwriter) EXPECT() *MockwriterMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *Mockwriter) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockwriterMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*Mockwriter)(nil).Close))
+}
+
+// WriteMessages mocks base method.
+func (m *Mockwriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range msgs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WriteMessages", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteMessages indicates an expected call of WriteMessages.
+func (mr *MockwriterMockRecorder) WriteMessages(ctx interface{}, msgs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, msgs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteMessages", reflect.TypeOf((*Mockwriter)(nil).WriteMessages), varargs...)
+}
+
 // MockmessageSaver is a mock of messageSaver interface.
 type MockmessageSaver struct {
 	ctrl     *gomock.Controller
@@ -133,3 +189,41 @@ func (mr *MockmessageSaverMockRecorder) SaveFromMessage(ctx, raw interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFromMessage", reflect.TypeOf((*MockmessageSaver)(nil).SaveFromMessage), ctx, raw)
 }
+
+// MockbatchSaver is a mock of batchSaver interface.
+type MockbatchSaver struct {
+	ctrl     *gomock.Controller
+	recorder *MockbatchSaverMockRecorder
+}
+
+// MockbatchSaverMockRecorder is the mock recorder for MockbatchSaver.
+type MockbatchSaverMockRecorder struct {
+	mock *MockbatchSaver
+}
+
+// NewMockbatchSaver creates a new mock instance.
+func NewMockbatchSaver(ctrl *gomock.Controller) *MockbatchSaver {
+	mock := &MockbatchSaver{ctrl: ctrl}
+	mock.recorder = &MockbatchSaverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockbatchSaver) EXPECT() *MockbatchSaverMockRecorder {
+	return m.recorder
+}
+
+// SaveBatchFromMessages mocks base method.
+func (m *MockbatchSaver) SaveBatchFromMessages(ctx context.Context, raws [][]byte) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBatchFromMessages", ctx, raws)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveBatchFromMessages indicates an expected call of SaveBatchFromMessages.
+func (mr *MockbatchSaverMockRecorder) SaveBatchFromMessages(ctx, raws interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBatchFromMessages", reflect.TypeOf((*MockbatchSaver)(nil).SaveBatchFromMessages), ctx, raws)
+}
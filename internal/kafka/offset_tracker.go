@@ -0,0 +1,103 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// offsetTracker — учёт оффсетов в режиме воркеров: сообщения завершаются в произвольном порядке,
+// а коммитить можно только непрерывный обработанный префикс каждой партиции.
+// Число оффсетов партиции вне префикса ограничено limit: застрявшее сообщение тормозит чтение,
+// а не раздувает pending.
+type offsetTracker struct {
+	mu         sync.Mutex
+	limit      int
+	partitions map[partitionKey]*partitionOffsets
+}
+
+// partitionKey — идентификатор партиции.
+type partitionKey struct {
+	topic     string
+	partition int
+}
+
+// partitionOffsets — состояние одной партиции.
+type partitionOffsets struct {
+	pending []int64                 // оффсеты в порядке чтения, ещё не вошедшие в префикс
+	done    map[int64]kafka.Message // обработанные, но ожидающие более ранних оффсетов
+	ready   *kafka.Message          // последнее сообщение префикса, ещё не отданное на коммит
+	slots   chan struct{}           // занятые места в pending (ёмкость — limit)
+}
+
+// newOffsetTracker — limit: максимум оффсетов партиции вне закоммиченного префикса.
+func newOffsetTracker(limit int) *offsetTracker {
+	return &offsetTracker{limit: limit, partitions: make(map[partitionKey]*partitionOffsets)}
+}
+
+// track — регистрирует прочитанное сообщение (вызывается в порядке FetchMessage).
+// Если в партиции уже limit необработанных оффсетов, ждёт, пока префикс сдвинется, или отмены ctx.
+func (t *offsetTracker) track(ctx context.Context, msg *kafka.Message) error {
+	t.mu.Lock()
+	key := partitionKey{topic: msg.Topic, partition: msg.Partition}
+	p, ok := t.partitions[key]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]kafka.Message), slots: make(chan struct{}, t.limit)}
+		t.partitions[key] = p
+	}
+	t.mu.Unlock()
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	t.mu.Lock()
+	p.pending = append(p.pending, msg.Offset)
+	t.mu.Unlock()
+	return nil
+}
+
+// markDone — отмечает сообщение обработанным и сдвигает префикс партиции.
+// Возвращает true, если появилось что коммитить.
+func (t *offsetTracker) markDone(msg *kafka.Message) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.partitions[partitionKey{topic: msg.Topic, partition: msg.Partition}]
+	if !ok {
+		return false
+	}
+	p.done[msg.Offset] = *msg
+
+	advanced := false
+	for len(p.pending) > 0 {
+		head, ok := p.done[p.pending[0]]
+		if !ok {
+			break
+		}
+		delete(p.done, p.pending[0])
+		p.pending = p.pending[1:]
+		<-p.slots
+		p.ready = &head
+		advanced = true
+	}
+	return advanced
+}
+
+// drain — забирает сообщения для коммита: по одному (максимальному) на партицию.
+func (t *offsetTracker) drain() []kafka.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var msgs []kafka.Message
+	for _, p := range t.partitions {
+		if p.ready != nil {
+			msgs = append(msgs, *p.ready)
+			p.ready = nil
+		}
+	}
+	return msgs
+}
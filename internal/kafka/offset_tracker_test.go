@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Коммитится только непрерывный обработанный префикс партиции
+func TestOffsetTracker_CommitsContiguousPrefix(t *testing.T) {
+	tr := newOffsetTracker(8)
+	msgs := []kafka.Message{
+		{Topic: "orders", Partition: 0, Offset: 1},
+		{Topic: "orders", Partition: 0, Offset: 2},
+		{Topic: "orders", Partition: 0, Offset: 3},
+		{Topic: "orders", Partition: 1, Offset: 7},
+	}
+	for i := range msgs {
+		if err := tr.track(context.Background(), &msgs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Оффсеты 2 и 3 готовы раньше 1 — коммитить нечего
+	if tr.markDone(&msgs[1]) || tr.markDone(&msgs[2]) {
+		t.Fatal("prefix must not advance before offset 1 is done")
+	}
+	if got := tr.drain(); len(got) != 0 {
+		t.Fatalf("want nothing to commit, got %+v", got)
+	}
+
+	// Партиции независимы
+	if !tr.markDone(&msgs[3]) {
+		t.Fatal("partition 1 prefix must advance")
+	}
+
+	// Оффсет 1 готов — префикс сдвигается сразу до 3
+	if !tr.markDone(&msgs[0]) {
+		t.Fatal("partition 0 prefix must advance")
+	}
+	got := tr.drain()
+	offsets := make(map[int]int64, len(got))
+	for _, m := range got {
+		offsets[m.Partition] = m.Offset
+	}
+	if len(got) != 2 || offsets[0] != 3 || offsets[1] != 7 {
+		t.Fatalf("want partition 0 → 3 and partition 1 → 7, got %+v", got)
+	}
+	if again := tr.drain(); len(again) != 0 {
+		t.Fatalf("drain must be idempotent, got %+v", again)
+	}
+}
+
+// Партиция с limit необработанными оффсетами не принимает новые, пока префикс не сдвинется
+func TestOffsetTracker_LimitsInFlightPerPartition(t *testing.T) {
+	tr := newOffsetTracker(2)
+	ctx := context.Background()
+	msgs := []kafka.Message{
+		{Topic: "orders", Partition: 0, Offset: 1},
+		{Topic: "orders", Partition: 0, Offset: 2},
+		{Topic: "orders", Partition: 0, Offset: 3},
+	}
+	for i := range msgs[:2] {
+		if err := tr.track(ctx, &msgs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Другая партиция не затронута
+	if err := tr.track(ctx, &kafka.Message{Topic: "orders", Partition: 1, Offset: 1}); err != nil {
+		t.Fatalf("other partition must not be blocked: %v", err)
+	}
+
+	// Оффсет 2 готов, но префикс стоит на 1 — места не освобождаются
+	tr.markDone(&msgs[1])
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := tr.track(short, &msgs[2]); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want DeadlineExceeded while partition is full, got %v", err)
+	}
+
+	// Оффсет 1 готов — префикс сдвигается, место появляется
+	tr.markDone(&msgs[0])
+	if err := tr.track(ctx, &msgs[2]); err != nil {
+		t.Fatalf("want slot after prefix advanced, got %v", err)
+	}
+}
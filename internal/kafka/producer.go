@@ -0,0 +1,49 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/segmentio/kafka-go"
+)
+
+// Проверка, что Producer удовлетворяет интерфейсу MessageProducer.
+var _ ports.MessageProducer = (*Producer)(nil)
+
+// Producer — обёртка над kafka.Writer, реализующая ports.MessageProducer.
+type Producer struct {
+	writer    writer
+	closeOnce sync.Once
+}
+
+// NewProducer — конструктор.
+func NewProducer(cfg *ProducerConfig) *Producer {
+	return &Producer{writer: cfg.writerConfig()}
+}
+
+// Publish — синхронно публикует сообщения; nil — все сообщения подтверждены брокером.
+func (p *Producer) Publish(ctx context.Context, msgs ...ports.OutgoingMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	kmsgs := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		headers := make([]kafka.Header, 0, len(msg.Headers))
+		for k, v := range msg.Headers {
+			headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		kmsgs = append(kmsgs, kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers})
+	}
+	return p.writer.WriteMessages(ctx, kmsgs...)
+}
+
+// Close — закрывает writer (однократно).
+func (p *Producer) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		err = p.writer.Close()
+	})
+	return err
+}
@@ -0,0 +1,21 @@
+package kafka
+
+import "github.com/segmentio/kafka-go"
+
+// ProducerConfig — конфигурация Kafka producer.
+type ProducerConfig struct {
+	Brokers []string // список брокеров kafka
+	Topic   string   // топик публикации
+}
+
+// writerConfig — сборка writer'а для публикации событий.
+// RequireAll — подтверждение всеми ISR (at-least-once), Hash — события одного ключа в одну партицию.
+func (c *ProducerConfig) writerConfig() *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(c.Brokers...),
+		Topic:                  c.Topic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: true,
+	}
+}
@@ -0,0 +1,49 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/Gunvolt24/wb_l0/internal/kafka/mocks"
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+)
+
+// Publish переносит ключ, payload и заголовки в kafka.Message
+func TestProducer_Publish_MapsMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	w := mocks.NewMockwriter(ctrl)
+
+	w.EXPECT().WriteMessages(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, msgs ...kafka.Message) error {
+			if len(msgs) != 1 || string(msgs[0].Key) != "order-1" || string(msgs[0].Value) != "{}" {
+				t.Fatalf("unexpected messages: %+v", msgs)
+			}
+			if len(msgs[0].Headers) != 1 || msgs[0].Headers[0].Key != "x-event-type" ||
+				string(msgs[0].Headers[0].Value) != "order.saved" {
+				t.Fatalf("unexpected headers: %+v", msgs[0].Headers)
+			}
+			return nil
+		})
+	w.EXPECT().Close().Return(nil).Times(1)
+
+	p := &Producer{writer: w}
+	err := p.Publish(context.Background(), ports.OutgoingMessage{
+		Key:     []byte("order-1"),
+		Value:   []byte("{}"),
+		Headers: map[string]string{"x-event-type": "order.saved"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Close — однократный
+	if err := p.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName — имя инструментирующей библиотеки для спанов консьюмера.
+const tracerName = "github.com/Gunvolt24/wb_l0/internal/kafka"
+
+// headerCarrier — propagation.TextMapCarrier поверх заголовков kafka.Message.
+// Позволяет извлечь W3C traceparent/tracestate и baggage, проставленные продюсером.
+type headerCarrier struct {
+	msg *kafka.Message
+}
+
+var _ propagation.TextMapCarrier = headerCarrier{}
+
+// Get — значение заголовка (имена сравниваются без учёта регистра).
+func (c headerCarrier) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if strings.EqualFold(h.Key, key) {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set — заменяет заголовок или добавляет новый.
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if strings.EqualFold(h.Key, key) {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+// Keys — имена всех заголовков.
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
+
+// startProcessSpan — извлекает контекст трассировки из заголовков и открывает consumer-спан
+// обработки сообщения (атрибуты — по messaging semantic conventions).
+func startProcessSpan(ctx context.Context, topic string, msg *kafka.Message) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{msg: msg})
+
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystem("kafka"),
+		semconv.MessagingOperationProcess,
+		semconv.MessagingSourceName(topic),
+		semconv.MessagingKafkaSourcePartition(msg.Partition),
+		semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+		semconv.MessagingMessagePayloadSizeBytes(len(msg.Value)),
+	}
+	if len(msg.Key) > 0 {
+		attrs = append(attrs, semconv.MessagingKafkaMessageKey(string(msg.Key)))
+	}
+
+	return otel.Tracer(tracerName).Start(ctx, topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// startBatchSpan — consumer-спан обработки пакета: ссылки (links) на контексты
+// всех сообщений пакета, т.к. у пакета нет единственного родителя.
+func startBatchSpan(ctx context.Context, topic string, batch []kafka.Message) (context.Context, trace.Span) {
+	propagator := otel.GetTextMapPropagator()
+	links := make([]trace.Link, 0, len(batch))
+	for i := range batch {
+		msgCtx := propagator.Extract(ctx, headerCarrier{msg: &batch[i]})
+		if sc := trace.SpanContextFromContext(msgCtx); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+	}
+
+	return otel.Tracer(tracerName).Start(ctx, topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			semconv.MessagingSystem("kafka"),
+			semconv.MessagingOperationProcess,
+			semconv.MessagingSourceName(topic),
+			semconv.MessagingBatchMessageCount(len(batch)),
+		),
+	)
+}
@@ -0,0 +1,103 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Gunvolt24/wb_l0/internal/kafka/mocks"
+)
+
+// Контекст продюсера из traceparent продолжается в consumer-спане и доходит до SaveFromMessage
+func TestProcessMessage_ContinuesTraceFromHeaders(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	const (
+		traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentSpanID = "00f067aa0ba902b7"
+	)
+
+	ctrl := gomock.NewController(t)
+	s := mocks.NewMockmessageSaver(ctrl)
+	s.EXPECT().SaveFromMessage(gomock.Any(), []byte("ok")).
+		DoAndReturn(func(ctx context.Context, _ []byte) error {
+			if got := trace.SpanContextFromContext(ctx).TraceID().String(); got != traceID {
+				t.Errorf("SaveFromMessage trace_id=%s, want %s", got, traceID)
+			}
+			return nil
+		})
+
+	c := newTestConsumer(mocks.NewMockreader(ctrl), s)
+	msg := kafka.Message{
+		Topic: "orders", Partition: 2, Offset: 42, Key: []byte("order-1"), Value: []byte("ok"),
+		Headers: []kafka.Header{
+			{Key: "traceparent", Value: []byte("00-" + traceID + "-" + parentSpanID + "-01")},
+			{Key: "baggage", Value: []byte("tenant=wb")},
+		},
+	}
+
+	if !c.processMessage(context.Background(), "orders", &msg) {
+		t.Fatal("message must be committed")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("want 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "orders process" || span.SpanKind() != trace.SpanKindConsumer {
+		t.Fatalf("unexpected span name/kind: %s %v", span.Name(), span.SpanKind())
+	}
+	if span.Parent().TraceID().String() != traceID || span.Parent().SpanID().String() != parentSpanID || !span.Parent().IsRemote() {
+		t.Fatalf("span must continue remote parent, got %+v", span.Parent())
+	}
+
+	attrs := make(map[string]string)
+	for _, kv := range span.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	want := map[string]string{
+		"messaging.system":                 "kafka",
+		"messaging.operation":              "process",
+		"messaging.source.name":            "orders",
+		"messaging.kafka.source.partition": "2",
+		"messaging.kafka.message.offset":   "42",
+		"messaging.kafka.message.key":      "order-1",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Fatalf("attribute %s=%q, want %q (all: %v)", k, attrs[k], v, attrs)
+		}
+	}
+}
+
+// headerCarrier: Get без учёта регистра, Set заменяет существующий заголовок
+func TestHeaderCarrier_GetSet(t *testing.T) {
+	msg := kafka.Message{Headers: []kafka.Header{{Key: "Traceparent", Value: []byte("a")}}}
+	carrier := headerCarrier{msg: &msg}
+
+	if got := carrier.Get("traceparent"); got != "a" {
+		t.Fatalf("Get: want a, got %q", got)
+	}
+	carrier.Set("traceparent", "b")
+	carrier.Set("baggage", "k=v")
+	if len(msg.Headers) != 2 || carrier.Get("traceparent") != "b" || carrier.Get("baggage") != "k=v" {
+		t.Fatalf("unexpected headers: %+v", msg.Headers)
+	}
+}
@@ -0,0 +1,13 @@
+package ports
+
+import "context"
+
+// CacheInvalidator — получатель уведомлений об изменении заказов другими экземплярами сервиса.
+// Реализация сбрасывает локальные (in-process) копии, чтобы следующее чтение взяло актуальные данные.
+type CacheInvalidator interface {
+	// Invalidate — заказ orderUID изменён; локальная копия устарела.
+	Invalidate(ctx context.Context, orderUID string)
+
+	// InvalidateAll — уведомления могли быть пропущены (переподключение); сбросить всё локальное.
+	InvalidateAll(ctx context.Context)
+}
@@ -0,0 +1,17 @@
+package ports
+
+import "context"
+
+// OutgoingMessage — сообщение для публикации.
+type OutgoingMessage struct {
+	Key     []byte            // ключ партиционирования
+	Value   []byte            // payload
+	Headers map[string]string // заголовки
+}
+
+// MessageProducer — абстракция публикации сообщений (Kafka).
+// Требование: Publish возвращает nil только после подтверждения брокером.
+type MessageProducer interface {
+	Publish(ctx context.Context, msgs ...OutgoingMessage) error // Publish — публикует сообщения.
+	Close() error                                               // Close — освобождает ресурсы (writer и т.п.).
+}
@@ -3,6 +3,9 @@
 //go:generate mockgen -source=../validator.go        -destination=./mock_validator.go        -package=mocks
 //go:generate mockgen -source=../logger.go           -destination=./mock_logger.go           -package=mocks
 //go:generate mockgen -source=../message_consumer.go -destination=./mock_message_consumer.go -package=mocks
+//go:generate mockgen -source=../message_producer.go -destination=./mock_message_producer.go -package=mocks
+//go:generate mockgen -source=../outbox_repository.go -destination=./mock_outbox_repository.go -package=mocks
 //go:generate mockgen -source=../order_read_service.go -destination=mock_order_read_service.go -package=mocks
+//go:generate mockgen -source=../cache_invalidator.go -destination=./mock_cache_invalidator.go -package=mocks
 
 package mocks
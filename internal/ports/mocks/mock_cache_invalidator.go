@@ -0,0 +1,59 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../cache_invalidator.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCacheInvalidator is a mock of CacheInvalidator interface.
+type MockCacheInvalidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheInvalidatorMockRecorder
+}
+
+// MockCacheInvalidatorMockRecorder is the mock recorder for MockCacheInvalidator.
+type MockCacheInvalidatorMockRecorder struct {
+	mock *MockCacheInvalidator
+}
+
+// NewMockCacheInvalidator creates a new mock instance.
+func NewMockCacheInvalidator(ctrl *gomock.Controller) *MockCacheInvalidator {
+	mock := &MockCacheInvalidator{ctrl: ctrl}
+	mock.recorder = &MockCacheInvalidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheInvalidator) EXPECT() *MockCacheInvalidatorMockRecorder {
+	return m.recorder
+}
+
+// Invalidate mocks base method.
+func (m *MockCacheInvalidator) Invalidate(ctx context.Context, orderUID string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Invalidate", ctx, orderUID)
+}
+
+// Invalidate indicates an expected call of Invalidate.
+func (mr *MockCacheInvalidatorMockRecorder) Invalidate(ctx, orderUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invalidate", reflect.TypeOf((*MockCacheInvalidator)(nil).Invalidate), ctx, orderUID)
+}
+
+// InvalidateAll mocks base method.
+func (m *MockCacheInvalidator) InvalidateAll(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateAll", ctx)
+}
+
+// InvalidateAll indicates an expected call of InvalidateAll.
+func (mr *MockCacheInvalidatorMockRecorder) InvalidateAll(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateAll", reflect.TypeOf((*MockCacheInvalidator)(nil).InvalidateAll), ctx)
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../message_producer.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	ports "github.com/Gunvolt24/wb_l0/internal/ports"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMessageProducer is a mock of MessageProducer interface.
+type MockMessageProducer struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageProducerMockRecorder
+}
+
+// MockMessageProducerMockRecorder is the mock recorder for MockMessageProducer.
+type MockMessageProducerMockRecorder struct {
+	mock *MockMessageProducer
+}
+
+// NewMockMessageProducer creates a new mock instance.
+func NewMockMessageProducer(ctrl *gomock.Controller) *MockMessageProducer {
+	mock := &MockMessageProducer{ctrl: ctrl}
+	mock.recorder = &MockMessageProducerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageProducer) EXPECT() *MockMessageProducerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockMessageProducer) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockMessageProducerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockMessageProducer)(nil).Close))
+}
+
+// Publish mocks base method.
+func (m *MockMessageProducer) Publish(ctx context.Context, msgs ...ports.OutgoingMessage) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range msgs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Publish", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockMessageProducerMockRecorder) Publish(ctx interface{}, msgs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, msgs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockMessageProducer)(nil).Publish), varargs...)
+}
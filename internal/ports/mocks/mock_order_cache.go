@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	domain "github.com/Gunvolt24/wb_l0/internal/domain"
+	ports "github.com/Gunvolt24/wb_l0/internal/ports"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,6 +36,20 @@ func (m *MockOrderCache) EXPECT() *MockOrderCacheMockRecorder {
 	return m.recorder
 }
 
+// Delete mocks base method.
+func (m *MockOrderCache) Delete(ctx context.Context, orderUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, orderUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockOrderCacheMockRecorder) Delete(ctx, orderUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrderCache)(nil).Delete), ctx, orderUID)
+}
+
 // Get mocks base method.
 func (m *MockOrderCache) Get(ctx context.Context, orderUID string) (*domain.Order, bool) {
 	m.ctrl.T.Helper()
@@ -50,6 +65,50 @@ func (mr *MockOrderCacheMockRecorder) Get(ctx, orderUID interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockOrderCache)(nil).Get), ctx, orderUID)
 }
 
+// Keys mocks base method.
+func (m *MockOrderCache) Keys(ctx context.Context, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys", ctx, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *MockOrderCacheMockRecorder) Keys(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockOrderCache)(nil).Keys), ctx, limit)
+}
+
+// Len mocks base method.
+func (m *MockOrderCache) Len(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Len", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Len indicates an expected call of Len.
+func (mr *MockOrderCacheMockRecorder) Len(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Len", reflect.TypeOf((*MockOrderCache)(nil).Len), ctx)
+}
+
+// Purge mocks base method.
+func (m *MockOrderCache) Purge(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockOrderCacheMockRecorder) Purge(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockOrderCache)(nil).Purge), ctx)
+}
+
 // Set mocks base method.
 func (m *MockOrderCache) Set(ctx context.Context, order *domain.Order) error {
 	m.ctrl.T.Helper()
@@ -64,6 +123,35 @@ func (mr *MockOrderCacheMockRecorder) Set(ctx, order interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockOrderCache)(nil).Set), ctx, order)
 }
 
+// SetNegative mocks base method.
+func (m *MockOrderCache) SetNegative(ctx context.Context, orderUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNegative", ctx, orderUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNegative indicates an expected call of SetNegative.
+func (mr *MockOrderCacheMockRecorder) SetNegative(ctx, orderUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNegative", reflect.TypeOf((*MockOrderCache)(nil).SetNegative), ctx, orderUID)
+}
+
+// Stats mocks base method.
+func (m *MockOrderCache) Stats(ctx context.Context) (ports.CacheStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx)
+	ret0, _ := ret[0].(ports.CacheStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockOrderCacheMockRecorder) Stats(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockOrderCache)(nil).Stats), ctx)
+}
+
 // WarmUp mocks base method.
 func (m *MockOrderCache) WarmUp(ctx context.Context, orders []*domain.Order) error {
 	m.ctrl.T.Helper()
@@ -77,3 +165,40 @@ func (mr *MockOrderCacheMockRecorder) WarmUp(ctx, orders interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WarmUp", reflect.TypeOf((*MockOrderCache)(nil).WarmUp), ctx, orders)
 }
+
+// MockCacheWarmer is a mock of CacheWarmer interface.
+type MockCacheWarmer struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheWarmerMockRecorder
+}
+
+// MockCacheWarmerMockRecorder is the mock recorder for MockCacheWarmer.
+type MockCacheWarmerMockRecorder struct {
+	mock *MockCacheWarmer
+}
+
+// NewMockCacheWarmer creates a new mock instance.
+func NewMockCacheWarmer(ctrl *gomock.Controller) *MockCacheWarmer {
+	mock := &MockCacheWarmer{ctrl: ctrl}
+	mock.recorder = &MockCacheWarmerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheWarmer) EXPECT() *MockCacheWarmerMockRecorder {
+	return m.recorder
+}
+
+// WarmUpCache mocks base method.
+func (m *MockCacheWarmer) WarmUpCache(ctx context.Context, n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WarmUpCache", ctx, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WarmUpCache indicates an expected call of WarmUpCache.
+func (mr *MockCacheWarmerMockRecorder) WarmUpCache(ctx, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WarmUpCache", reflect.TypeOf((*MockCacheWarmer)(nil).WarmUpCache), ctx, n)
+}
@@ -64,3 +64,48 @@ func (mr *MockOrderReadServiceMockRecorder) OrdersByCustomer(ctx, customerID, li
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrdersByCustomer", reflect.TypeOf((*MockOrderReadService)(nil).OrdersByCustomer), ctx, customerID, limit, offset)
 }
+
+// OrdersByCustomerPage mocks base method.
+func (m *MockOrderReadService) OrdersByCustomerPage(ctx context.Context, customerID, cursor string, limit int) (*domain.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OrdersByCustomerPage", ctx, customerID, cursor, limit)
+	ret0, _ := ret[0].(*domain.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OrdersByCustomerPage indicates an expected call of OrdersByCustomerPage.
+func (mr *MockOrderReadServiceMockRecorder) OrdersByCustomerPage(ctx, customerID, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OrdersByCustomerPage", reflect.TypeOf((*MockOrderReadService)(nil).OrdersByCustomerPage), ctx, customerID, cursor, limit)
+}
+
+// SearchItems mocks base method.
+func (m *MockOrderReadService) SearchItems(ctx context.Context, query string, limit, offset int) ([]domain.ItemSearchHit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchItems", ctx, query, limit, offset)
+	ret0, _ := ret[0].([]domain.ItemSearchHit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchItems indicates an expected call of SearchItems.
+func (mr *MockOrderReadServiceMockRecorder) SearchItems(ctx, query, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchItems", reflect.TypeOf((*MockOrderReadService)(nil).SearchItems), ctx, query, limit, offset)
+}
+
+// SearchOrders mocks base method.
+func (m *MockOrderReadService) SearchOrders(ctx context.Context, filter domain.OrderFilter, cursor string, limit int) (*domain.OrderPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchOrders", ctx, filter, cursor, limit)
+	ret0, _ := ret[0].(*domain.OrderPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchOrders indicates an expected call of SearchOrders.
+func (mr *MockOrderReadServiceMockRecorder) SearchOrders(ctx, filter, cursor, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchOrders", reflect.TypeOf((*MockOrderReadService)(nil).SearchOrders), ctx, filter, cursor, limit)
+}
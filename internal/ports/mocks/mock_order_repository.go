@@ -50,6 +50,21 @@ func (mr *MockOrderRepositoryMockRecorder) GetByUID(ctx, orderUID interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUID", reflect.TypeOf((*MockOrderRepository)(nil).GetByUID), ctx, orderUID)
 }
 
+// GetByUIDs mocks base method.
+func (m *MockOrderRepository) GetByUIDs(ctx context.Context, orderUIDs []string) ([]*domain.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUIDs", ctx, orderUIDs)
+	ret0, _ := ret[0].([]*domain.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUIDs indicates an expected call of GetByUIDs.
+func (mr *MockOrderRepositoryMockRecorder) GetByUIDs(ctx, orderUIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUIDs", reflect.TypeOf((*MockOrderRepository)(nil).GetByUIDs), ctx, orderUIDs)
+}
+
 // LastN mocks base method.
 func (m *MockOrderRepository) LastN(ctx context.Context, n int) ([]*domain.Order, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +95,21 @@ func (mr *MockOrderRepositoryMockRecorder) ListByCustomer(ctx, customerID, limit
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCustomer", reflect.TypeOf((*MockOrderRepository)(nil).ListByCustomer), ctx, customerID, limit, offset)
 }
 
+// ListByCustomerAfter mocks base method.
+func (m *MockOrderRepository) ListByCustomerAfter(ctx context.Context, customerID string, after *domain.OrderCursor, limit int) ([]*domain.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByCustomerAfter", ctx, customerID, after, limit)
+	ret0, _ := ret[0].([]*domain.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByCustomerAfter indicates an expected call of ListByCustomerAfter.
+func (mr *MockOrderRepositoryMockRecorder) ListByCustomerAfter(ctx, customerID, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCustomerAfter", reflect.TypeOf((*MockOrderRepository)(nil).ListByCustomerAfter), ctx, customerID, after, limit)
+}
+
 // Save mocks base method.
 func (m *MockOrderRepository) Save(ctx context.Context, order *domain.Order) error {
 	m.ctrl.T.Helper()
@@ -93,3 +123,47 @@ func (mr *MockOrderRepositoryMockRecorder) Save(ctx, order interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOrderRepository)(nil).Save), ctx, order)
 }
+
+// SaveBatch mocks base method.
+func (m *MockOrderRepository) SaveBatch(ctx context.Context, orders []*domain.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBatch", ctx, orders)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveBatch indicates an expected call of SaveBatch.
+func (mr *MockOrderRepositoryMockRecorder) SaveBatch(ctx, orders interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBatch", reflect.TypeOf((*MockOrderRepository)(nil).SaveBatch), ctx, orders)
+}
+
+// Search mocks base method.
+func (m *MockOrderRepository) Search(ctx context.Context, filter domain.OrderFilter, after *domain.OrderCursor, limit int) ([]*domain.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, filter, after, limit)
+	ret0, _ := ret[0].([]*domain.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockOrderRepositoryMockRecorder) Search(ctx, filter, after, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockOrderRepository)(nil).Search), ctx, filter, after, limit)
+}
+
+// SearchItems mocks base method.
+func (m *MockOrderRepository) SearchItems(ctx context.Context, query string, limit, offset int) ([]domain.ItemSearchHit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchItems", ctx, query, limit, offset)
+	ret0, _ := ret[0].([]domain.ItemSearchHit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchItems indicates an expected call of SearchItems.
+func (mr *MockOrderRepositoryMockRecorder) SearchItems(ctx, query, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchItems", reflect.TypeOf((*MockOrderRepository)(nil).SearchItems), ctx, query, limit, offset)
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/Gunvolt24/wb_l0/internal/domain"
 )
@@ -9,13 +10,56 @@ import (
 // OrderCache — интерфейс кэша заказов.
 // Требования к реализации: потокобезопасность; доступ по ключу не хуже O(1); возврат копий сущности.
 type OrderCache interface {
-	// Get — вернуть заказ по UID; (order, true) при попадании, (nil, false) при промахе/истечении.
+	// Get — вернуть заказ по UID; (order, true) при попадании, (nil, false) при промахе/истечении,
+	// (nil, true) — негативная запись: заказа заведомо нет в БД (см. SetNegative).
 	Get(ctx context.Context, orderUID string) (*domain.Order, bool)
 
-	// Set — сохранить/обновить заказ в кэше.
+	// Set — сохранить/обновить заказ в кэше. Заменяет и негативную запись того же UID.
 	Set(ctx context.Context, order *domain.Order) error
 
+	// SetNegative — запомнить, что заказа с таким UID нет, на короткий negative TTL реализации.
+	// Существующую запись с заказом не затирает; при выключенном негативном кэшировании — no-op.
+	SetNegative(ctx context.Context, orderUID string) error
+
 	// WarmUp — массовая загрузка кэша (например, при старте).
 	// Реализация должна поддерживать отмену контекста.
 	WarmUp(ctx context.Context, orders []*domain.Order) error
+
+	// Delete — удалить запись UID (отсутствие записи — не ошибка).
+	Delete(ctx context.Context, orderUID string) error
+
+	// Purge — удалить все записи.
+	Purge(ctx context.Context) error
+
+	// Len — число записей, включая негативные.
+	Len(ctx context.Context) (int, error)
+
+	// Keys — не более limit UID: у in-memory кэша — от самых ценных для политики вытеснения
+	// к кандидатам на вытеснение (для LRU — от недавно использованных), у Redis — в порядке SCAN.
+	Keys(ctx context.Context, limit int) ([]string, error)
+
+	// Stats — состояние кэша для диагностики.
+	Stats(ctx context.Context) (CacheStats, error)
+}
+
+// CacheStats — состояние кэша. Счётчики обращений — с запуска экземпляра (Purge их не сбрасывает).
+type CacheStats struct {
+	Entries   int           // записей, включая негативные
+	Bytes     int64         // оценка занятой памяти (0 — неизвестно)
+	Hits      uint64        // попаданий, включая негативные записи
+	Misses    uint64        // промахов, включая истёкшие записи
+	OldestAge time.Duration // сколько хранится самая давно записанная запись (0 — кэш пуст или неизвестно)
+}
+
+// HitRatio — доля попаданий среди обращений (0 — обращений не было).
+func (s CacheStats) HitRatio() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+// CacheWarmer — прогрев кэша последними заказами (OrderService.WarmUpCache).
+type CacheWarmer interface {
+	WarmUpCache(ctx context.Context, n int) error
 }
@@ -10,4 +10,7 @@ import (
 type OrderReadService interface {
 	GetOrder(ctx context.Context, orderUID string) (*domain.Order, error)
 	OrdersByCustomer(ctx context.Context, customerID string, limit, offset int) ([]*domain.Order, error)
+	OrdersByCustomerPage(ctx context.Context, customerID, cursor string, limit int) (*domain.OrderPage, error)
+	SearchOrders(ctx context.Context, filter domain.OrderFilter, cursor string, limit int) (*domain.OrderPage, error)
+	SearchItems(ctx context.Context, query string, limit, offset int) ([]domain.ItemSearchHit, error)
 }
@@ -9,14 +9,35 @@ import (
 // OrderRepository — контракт хранилища заказов (PostgreSQL).
 type OrderRepository interface {
 	// Save — создать или обновить заказ по OrderUID. Операция должна быть атомарной.
+	// Ошибки хранилища оборачиваются sentinel-ошибками domain (ErrConstraintViolation, ErrTransient и т.д.).
+	// В той же транзакции пишется событие outbox (order.saved / order.updated).
 	Save(ctx context.Context, order *domain.Order) error
 
+	// SaveBatch — сохранить несколько заказов одной транзакцией (всё или ничего).
+	SaveBatch(ctx context.Context, orders []*domain.Order) error
+
 	// GetByUID — вернуть заказ по UID; (nil, nil), если не найден.
 	GetByUID(ctx context.Context, orderUID string) (*domain.Order, error)
 
+	// GetByUIDs — полные заказы по списку UID за постоянное число запросов.
+	// Порядок — как в uids; ненайденные пропускаются.
+	GetByUIDs(ctx context.Context, orderUIDs []string) ([]*domain.Order, error)
+
 	// ListByCustomer — заказы клиента с пагинацией; сортировка по DateCreated DESC.
 	ListByCustomer(ctx context.Context, customerID string, limit, offset int) ([]*domain.Order, error)
 
+	// ListByCustomerAfter — keyset-страница заказов клиента строго после курсора (nil — первая страница);
+	// сортировка по (DateCreated, OrderUID) DESC. Устойчива к вставкам во время листания.
+	ListByCustomerAfter(ctx context.Context, customerID string, after *domain.OrderCursor, limit int) ([]*domain.Order, error)
+
+	// Search — заказы по фильтру (критерии через AND) с keyset-пагинацией как у ListByCustomerAfter.
+	// Пустой/противоречивый фильтр — domain.ErrInvalidFilter.
+	Search(ctx context.Context, filter domain.OrderFilter, after *domain.OrderCursor, limit int) ([]*domain.Order, error)
+
+	// SearchItems — полнотекстовый поиск по названиям и брендам позиций; результаты сгруппированы
+	// по заказу и отсортированы по рангу. Пустой запрос — domain.ErrInvalidFilter.
+	SearchItems(ctx context.Context, query string, limit, offset int) ([]domain.ItemSearchHit, error)
+
 	// LastN — последние N заказов по DateCreated DESC (например, для прогрева кэша).
 	LastN(ctx context.Context, n int) ([]*domain.Order, error)
 }
@@ -0,0 +1,22 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+)
+
+// OutboxRepository — чтение и подтверждение событий transactional outbox.
+// События записываются OrderRepository.Save в той же транзакции, что и заказ.
+type OutboxRepository interface {
+	// ClaimUnsent — захватить неотправленные события (не более limit, в порядке записи) на время lease:
+	// пока аренда не истекла, другие экземпляры relay эти события не получат.
+	ClaimUnsent(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error)
+
+	// MarkSent — пометить события отправленными.
+	MarkSent(ctx context.Context, ids []int64) error
+
+	// PruneSent — удалить отправленные раньше olderThan события (не более limit), вернуть число удалённых.
+	PruneSent(ctx context.Context, olderThan time.Duration, limit int) (int64, error)
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OrderChangedChannel — канал LISTEN/NOTIFY: OrderRepository.Save шлёт в него order_uid
+// каждого сохранённого заказа (доставка — после COMMIT).
+const OrderChangedChannel = "orders_changed"
+
+// ChangeListener — подписка на OrderChangedChannel: каждое уведомление сбрасывает локальную
+// копию заказа в кэше (ports.CacheInvalidator), поэтому реплики не отдают устаревший заказ до истечения TTL.
+// Слушает на отдельном соединении, изъятом из пула. После обрыва переподключается и
+// сбрасывает локальный кэш целиком — уведомления за время разрыва потеряны.
+type ChangeListener struct {
+	pool   *pgxpool.Pool
+	target ports.CacheInvalidator
+	log    ports.Logger
+	retry  time.Duration // пауза перед переподключением
+}
+
+// NewChangeListener — DI-конструктор. Если retry <= 0, ставим дефолт 1s.
+func NewChangeListener(pool *pgxpool.Pool, target ports.CacheInvalidator, log ports.Logger, retry time.Duration) *ChangeListener {
+	if retry <= 0 {
+		retry = time.Second
+	}
+	return &ChangeListener{pool: pool, target: target, log: log, retry: retry}
+}
+
+// Run — слушать уведомления до отмены контекста; ошибки соединения логируются, подписка восстанавливается.
+func (l *ChangeListener) Run(ctx context.Context) error {
+	l.log.Infof(ctx, "cache invalidation listener started channel=%s", OrderChangedChannel)
+
+	for resync := false; ; resync = true {
+		err := l.listen(ctx, resync)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		l.log.Warnf(ctx, "cache invalidation listener: %v (reconnect in %s)", err, l.retry)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.retry):
+		}
+	}
+}
+
+// listen — одна сессия подписки. resync — сбросить локальный кэш после успешной подписки
+// (не при первом запуске, чтобы не терять прогрев).
+func (l *ChangeListener) listen(ctx context.Context, resync bool) error {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire conn: %w", err)
+	}
+	// Соединение с активным LISTEN не должно вернуться в пул.
+	conn := pooled.Hijack()
+	defer func() { _ = conn.Close(context.WithoutCancel(ctx)) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{OrderChangedChannel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	if resync {
+		l.target.InvalidateAll(ctx)
+		l.log.Infof(ctx, "cache invalidation listener resubscribed, local cache purged")
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait notification: %w", err)
+		}
+		l.target.Invalidate(ctx, n.Payload)
+		metrics.CacheInvalidations.Inc()
+	}
+}
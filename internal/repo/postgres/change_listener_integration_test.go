@@ -0,0 +1,106 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	pgrepo "github.com/Gunvolt24/wb_l0/internal/repo/postgres"
+	"github.com/Gunvolt24/wb_l0/internal/testutil"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Infof(context.Context, string, ...any)  {}
+func (nopLogger) Warnf(context.Context, string, ...any)  {}
+func (nopLogger) Errorf(context.Context, string, ...any) {}
+
+// recordingInvalidator — запоминает полученные order_uid.
+type recordingInvalidator struct {
+	mu   sync.Mutex
+	uids []string
+	all  int
+}
+
+func (r *recordingInvalidator) Invalidate(_ context.Context, uid string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.uids = append(r.uids, uid)
+}
+
+func (r *recordingInvalidator) InvalidateAll(context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.all++
+}
+
+func (r *recordingInvalidator) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.uids...)
+}
+
+// Save/SaveBatch на одной «реплике» → уведомление доходит до слушателя другой после COMMIT;
+// откаченная транзакция уведомлений не шлёт.
+func TestChangeListener_ReceivesCommittedSaves_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	writerPool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer writerPool.Close()
+	listenerPool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer listenerPool.Close()
+
+	inv := &recordingInvalidator{}
+	listener := pgrepo.NewChangeListener(listenerPool, inv, nopLogger{}, 100*time.Millisecond)
+	listenCtx, stopListen := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- listener.Run(listenCtx) }()
+
+	repo := pgrepo.NewOrderRepository(writerPool)
+
+	// Ждём, пока подписка активна: повторяем Save, пока не придёт первое уведомление
+	first := testutil.MakeOrder()
+	require.Eventually(t, func() bool {
+		require.NoError(t, repo.Save(ctx, &first))
+		return len(inv.snapshot()) > 0
+	}, 10*time.Second, 200*time.Millisecond)
+
+	o1 := testutil.MakeOrder()
+	o2 := testutil.MakeOrder()
+	require.NoError(t, repo.SaveBatch(ctx, []*domain.Order{&o1, &o2}))
+
+	bad := testutil.MakeOrder()
+	bad.Payment.Amount = -1 // CHECK — транзакция откатится
+	require.Error(t, repo.Save(ctx, &bad))
+
+	require.Eventually(t, func() bool {
+		got := inv.snapshot()
+		return slices.Contains(got, o1.OrderUID) && slices.Contains(got, o2.OrderUID)
+	}, 5*time.Second, 50*time.Millisecond)
+	require.NotContains(t, inv.snapshot(), bad.OrderUID)
+	require.Zero(t, inv.all, "first subscription must not purge the warmed cache")
+
+	stopListen()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE-коды и классы Postgres, которые различаем явно.
+const (
+	pgUniqueViolation      = "23505"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgLockNotAvailable     = "55P03"
+	pgAdminShutdown        = "57P01"
+	pgCrashShutdown        = "57P02"
+	pgCannotConnectNow     = "57P03"
+
+	pgClassDataException       = "22" // некорректные данные (переполнение, формат)
+	pgClassIntegrityConstraint = "23" // нарушение ограничений
+	pgClassConnectionException = "08" // проблемы соединения
+	pgClassInsufficientRes     = "53" // нехватка ресурсов (память, диск, соединения)
+
+	paymentsPrimaryKey = "payments_pkey" // PK по payments.transaction
+)
+
+// classifyError — оборачивает ошибку pgx доменной sentinel-ошибкой:
+// постоянные (ограничения, некорректные данные) → domain.ErrConstraintViolation / ErrDuplicatePaymentTransaction,
+// временные (соединение, сериализация, deadlock, таймаут) → domain.ErrTransient.
+// Неизвестные ошибки возвращаются как есть.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		code := pgErr.Code
+		switch {
+		case code == pgUniqueViolation && pgErr.ConstraintName == paymentsPrimaryKey:
+			return fmt.Errorf("%w: %w", domain.ErrDuplicatePaymentTransaction, err)
+		case strings.HasPrefix(code, pgClassIntegrityConstraint), strings.HasPrefix(code, pgClassDataException):
+			return fmt.Errorf("%w: %w", domain.ErrConstraintViolation, err)
+		case code == pgSerializationFailure, code == pgDeadlockDetected, code == pgLockNotAvailable,
+			code == pgAdminShutdown, code == pgCrashShutdown, code == pgCannotConnectNow,
+			strings.HasPrefix(code, pgClassConnectionException), strings.HasPrefix(code, pgClassInsufficientRes):
+			return fmt.Errorf("%w: %w", domain.ErrTransient, err)
+		}
+		return err
+	}
+
+	// Ошибки уровня соединения (до ответа сервера): обрыв, таймаут, отказ в подключении.
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) || pgconn.Timeout(err) || pgconn.SafeToRetry(err) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", domain.ErrTransient, err)
+	}
+	return err
+}
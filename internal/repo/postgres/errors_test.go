@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want error // nil — ошибка возвращается без sentinel
+	}{
+		{"duplicate payment transaction", &pgconn.PgError{Code: "23505", ConstraintName: "payments_pkey"}, domain.ErrDuplicatePaymentTransaction},
+		{"other unique violation", &pgconn.PgError{Code: "23505", ConstraintName: "orders_pkey"}, domain.ErrConstraintViolation},
+		{"check violation", &pgconn.PgError{Code: "23514", ConstraintName: "payments_amount_check"}, domain.ErrConstraintViolation},
+		{"fk violation", &pgconn.PgError{Code: "23503"}, domain.ErrConstraintViolation},
+		{"numeric out of range", &pgconn.PgError{Code: "22003"}, domain.ErrConstraintViolation},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, domain.ErrTransient},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, domain.ErrTransient},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, domain.ErrTransient},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, domain.ErrTransient},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, domain.ErrTransient},
+		{"wrapped pg error", fmt.Errorf("upsert payment: %w", &pgconn.PgError{Code: "23514"}), domain.ErrConstraintViolation},
+		{"timeout", context.DeadlineExceeded, domain.ErrTransient},
+		{"syntax error stays unclassified", &pgconn.PgError{Code: "42601"}, nil},
+		{"plain error stays unclassified", errors.New("boom"), nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := classifyError(tt.err)
+			if !errors.Is(got, tt.err) {
+				t.Fatalf("original error must stay in chain: got %v", got)
+			}
+			if tt.want == nil {
+				if errors.Is(got, domain.ErrConstraintViolation) || errors.Is(got, domain.ErrTransient) {
+					t.Fatalf("want unclassified error, got %v", got)
+				}
+				return
+			}
+			if !errors.Is(got, tt.want) {
+				t.Fatalf("want %v in chain, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClassifyError_DuplicatePaymentIsConstraintViolation(t *testing.T) {
+	t.Parallel()
+
+	err := classifyError(&pgconn.PgError{Code: "23505", ConstraintName: "payments_pkey"})
+	if !errors.Is(err, domain.ErrConstraintViolation) {
+		t.Fatalf("duplicate payment transaction must also be a constraint violation, got %v", err)
+	}
+	if errors.Is(err, domain.ErrTransient) {
+		t.Fatalf("duplicate payment transaction must not be transient, got %v", err)
+	}
+}
+
+func TestClassifyError_Nil(t *testing.T) {
+	t.Parallel()
+
+	if err := classifyError(nil); err != nil {
+		t.Fatalf("want nil, got %v", err)
+	}
+}
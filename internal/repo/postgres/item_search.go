@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/pkg/telemetry"
+)
+
+// Ограничения полнотекстового поиска.
+const (
+	maxSearchTerms  = 8 // слов запроса, остальные отбрасываются
+	maxItemSnippets = 3 // подсвеченных позиций на заказ
+)
+
+// SearchItems — полнотекстовый поиск по items.name/brand (колонка search_tsv, GIN-индекс).
+// Совпадения группируются по order_uid; порядок — по лучшему ts_rank позиции заказа, затем по order_uid.
+// Запрос без букв и цифр — domain.ErrInvalidFilter.
+func (r *OrderRepository) SearchItems(
+	ctx context.Context, query string, limit, offset int,
+) (_ []domain.ItemSearchHit, err error) {
+	tsQuery := prefixTSQuery(query)
+	if tsQuery == "" {
+		return nil, fmt.Errorf("%w: empty full-text query", domain.ErrInvalidFilter)
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.SearchItems",
+		attrLimit.Int(limit), attrOffset.Int(offset))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	rows, err := r.pool.Query(ctx, `
+		WITH q AS (SELECT to_tsquery('simple', $1) AS query)
+		SELECT
+			i.order_uid,
+			max(ts_rank(i.search_tsv, q.query))::float8 AS rank,
+			(array_agg(
+				ts_headline('simple', concat_ws(' · ', i.name, i.brand), q.query,
+					'StartSel=<mark>, StopSel=</mark>, HighlightAll=true')
+				ORDER BY ts_rank(i.search_tsv, q.query) DESC, i.id
+			))[1:$4::int] AS snippets
+		FROM items i CROSS JOIN q
+		WHERE i.search_tsv @@ q.query
+		GROUP BY i.order_uid
+		ORDER BY rank DESC, i.order_uid
+		LIMIT $2 OFFSET $3
+	`, tsQuery, limit, offset, maxItemSnippets)
+	if err != nil {
+		return nil, fmt.Errorf("search items: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	hits := make([]domain.ItemSearchHit, 0, limit)
+	for rows.Next() {
+		var hit domain.ItemSearchHit
+		if err := rows.Scan(&hit.OrderUID, &hit.Rank, &hit.Snippets); err != nil {
+			return nil, fmt.Errorf("scan item hit: %w", err)
+		}
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows item hits: %w", classifyError(err))
+	}
+
+	span.SetAttributes(attrOrders.Int(len(hits)))
+	return hits, nil
+}
+
+// prefixTSQuery — пользовательская строка → безопасный tsquery: слова из букв/цифр
+// в нижнем регистре, каждое как префикс (word:*), объединённые через &.
+// Операторы tsquery (&, |, !, :, скобки) из ввода не попадают — синтаксических ошибок не бывает.
+func prefixTSQuery(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
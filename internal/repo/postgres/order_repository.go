@@ -7,6 +7,7 @@ import (
 
 	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/pkg/telemetry"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -16,24 +17,78 @@ var _ ports.OrderRepository = (*OrderRepository)(nil)
 
 // OrderRepository — реализация репозитория заказов на Postgres (pgxpool).
 type OrderRepository struct {
-	pool *pgxpool.Pool
+	pool   *pgxpool.Pool
+	outbox bool // писать события в outbox (см. WithOutbox)
 }
 
 // NewOrderRepository - конструктор OrderRepository.
 func NewOrderRepository(pool *pgxpool.Pool) *OrderRepository { return &OrderRepository{pool: pool} }
 
+// WithOutbox — включает запись событий о сохранении в таблицу outbox (в той же транзакции).
+// Без неё события не пишутся: некому их публиковать и чистить (см. OutboxRelay).
+func (r *OrderRepository) WithOutbox() *OrderRepository {
+	r.outbox = true
+	return r
+}
+
 // Save — транзакционно сохраняет заказ (идемпотентный upsert всех частей).
-func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
+// Ошибки БД классифицируются (см. classifyError): domain.ErrConstraintViolation,
+// domain.ErrDuplicatePaymentTransaction — постоянные, domain.ErrTransient — можно повторить.
+func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) (err error) {
+	if err := checkSavable(order); err != nil {
+		return err
+	}
+
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.Save",
+		attrOrderUID.String(order.OrderUID), attrItems.Int(len(order.Items)))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		return saveOrder(ctx, tx, order, r.outbox)
+	})
+}
+
+// SaveBatch — сохраняет несколько заказов в одной транзакции: либо все, либо ни одного.
+// Порядок сохранения совпадает с порядком в срезе (повторный UID — побеждает последний).
+func (r *OrderRepository) SaveBatch(ctx context.Context, orders []*domain.Order) (err error) {
+	if len(orders) == 0 {
+		return nil
+	}
+	for _, order := range orders {
+		if err := checkSavable(order); err != nil {
+			return err
+		}
+	}
+
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.SaveBatch", attrBatchSize.Int(len(orders)))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		for _, order := range orders {
+			if err := saveOrder(ctx, tx, order, r.outbox); err != nil {
+				return fmt.Errorf("order_uid=%s: %w", order.OrderUID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// checkSavable — минимальные требования к заказу перед записью.
+func checkSavable(order *domain.Order) error {
 	if order == nil || order.OrderUID == "" {
 		return errors.New("order is empty or order_uid is required")
 	}
 	if order.CustomerID == "" {
 		return errors.New("customer_id is required")
 	}
+	return nil
+}
 
+// inTx — выполняет fn в транзакции: Commit при успехе, Rollback при ошибке.
+func (r *OrderRepository) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
 	transaction, err := r.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("begin: %w", classifyError(err))
 	}
 	defer func() {
 		// При уже завершённой транзакции Rollback вернёт ErrTxClosed — игнорируем.
@@ -42,16 +97,32 @@ func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
 		}
 	}()
 
+	if err := fn(transaction); err != nil {
+		return err
+	}
+
+	// Завершаем транзакцию
+	if err := transaction.Commit(ctx); err != nil {
+		return fmt.Errorf("commit: %w", classifyError(err))
+	}
+	return nil
+}
+
+// saveOrder — upsert всех частей заказа в рамках переданной транзакции.
+// outbox — дописать событие о сохранении в таблицу outbox.
+func saveOrder(ctx context.Context, tx pgx.Tx, order *domain.Order, outbox bool) error {
 	// 1) customers — upsert (оставляем, чтобы не падать на FK).
-	if _, err = transaction.Exec(ctx, `
+	if _, err := tx.Exec(ctx, `
 		INSERT INTO customers (id) VALUES ($1) 
 		ON CONFLICT (id) DO NOTHING 
 	`, order.CustomerID); err != nil {
-		return fmt.Errorf("insert customer: %w", err)
+		return fmt.Errorf("insert customer: %w", classifyError(err))
 	}
 
 	// 2) orders — upsert по order_uid (PRIMARY KEY/UNIQUE).
-	if _, err = transaction.Exec(ctx, `
+	// xmax = 0 только у только что вставленной строки — так отличаем вставку от обновления.
+	var inserted bool
+	if err := tx.QueryRow(ctx, `
 		INSERT INTO orders (
 			order_uid, track_number, entry, locale, internal_signature, 
 			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
@@ -67,15 +138,16 @@ func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
 			sm_id = EXCLUDED.sm_id,
 			date_created = EXCLUDED.date_created,
 			oof_shard = EXCLUDED.oof_shard
+		RETURNING (xmax = 0)
 	`,
 		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
 		order.CustomerID, order.DeliveryService, order.ShardKey, order.SmID, order.DateCreated, order.OofShard,
-	); err != nil {
-		return fmt.Errorf("upsert order: %w", err)
+	).Scan(&inserted); err != nil {
+		return fmt.Errorf("upsert order: %w", classifyError(err))
 	}
 
 	// 3) deliveries — upsert 1:1 по order_uid.
-	if _, err = transaction.Exec(ctx, `
+	if _, err := tx.Exec(ctx, `
 		INSERT INTO deliveries (
 			order_uid, name, phone, zip, city, address, region, email
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
@@ -91,11 +163,11 @@ func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
 		order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
 		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
 	); err != nil {
-		return fmt.Errorf("upsert delivery: %w", err)
+		return fmt.Errorf("upsert delivery: %w", classifyError(err))
 	}
 
 	// 4) payments — upsert по order_uid (order_uid не обновляем).
-	if _, err = transaction.Exec(ctx, `
+	if _, err := tx.Exec(ctx, `
 		INSERT INTO payments (
 			transaction, order_uid, request_id, currency, provider,
 			amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
@@ -115,218 +187,132 @@ func (r *OrderRepository) Save(ctx context.Context, order *domain.Order) error {
 		order.Payment.Provider, order.Payment.Amount, order.Payment.PaymentDT, order.Payment.Bank,
 		order.Payment.DeliveryCost, order.Payment.GoodsTotal, order.Payment.CustomFee,
 	); err != nil {
-		return fmt.Errorf("upsert payment: %w", err)
+		return fmt.Errorf("upsert payment: %w", classifyError(err))
 	}
 
 	// 5) items — replace: удаляем и вставляем список заново.
-	if _, err = transaction.Exec(ctx, `DELETE FROM items WHERE order_uid = $1`, order.OrderUID); err != nil {
-		return fmt.Errorf("delete items: %w", err)
+	if _, err := tx.Exec(ctx, `DELETE FROM items WHERE order_uid = $1`, order.OrderUID); err != nil {
+		return fmt.Errorf("delete items: %w", classifyError(err))
 	}
 	if len(order.Items) > 0 {
-		if err = copyItems(ctx, transaction, order.OrderUID, order.Items); err != nil {
+		if err := copyItems(ctx, tx, order.OrderUID, order.Items); err != nil {
 			return err
 		}
 	}
 
-	// Завершаем транзакцию
-	if err := transaction.Commit(ctx); err != nil {
-		return fmt.Errorf("commit: %w", err)
+	// 6) уведомление об изменении для сброса локальных кэшей других реплик (см. ChangeListener).
+	// NOTIFY внутри транзакции доставляется только после COMMIT.
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, OrderChangedChannel, order.OrderUID); err != nil {
+		return fmt.Errorf("notify order changed: %w", classifyError(err))
 	}
-	return nil
-}
 
-// GetByUID — получить заказ по uid (1:1). Если не нашли, возвращает (nil, nil).
-func (r *OrderRepository) GetByUID(ctx context.Context, uid string) (*domain.Order, error) {
-	var order domain.Order
-
-	// orders (основная запись)
-	err := r.pool.QueryRow(ctx, `
-		SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service,
-			shardkey, sm_id, date_created, oof_shard
-		FROM orders WHERE order_uid = $1
-	`, uid).Scan(&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
-		&order.CustomerID, &order.DeliveryService, &order.ShardKey, &order.SmID, &order.DateCreated, &order.OofShard,
-	)
-	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, nil
+	// 7) outbox — событие о сохранении в той же транзакции (публикует OutboxRelay).
+	if !outbox {
+		return nil
 	}
-	if err != nil {
-		return nil, fmt.Errorf("select order: %w", err)
-	}
-
-	// deliveries (может отсутствовать)
-	if err := r.pool.QueryRow(ctx, `
-		SELECT name, phone, zip, city, address, region, email
-		FROM deliveries WHERE order_uid = $1
-	`, uid).Scan(&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
-		&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
-	); err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return nil, fmt.Errorf("select delivery: %w", err)
+	eventType := domain.EventOrderUpdated
+	if inserted {
+		eventType = domain.EventOrderSaved
 	}
+	return insertOutboxEvent(ctx, tx, order, eventType)
+}
 
-	// payments (может отсутствовать)
-	if err := r.pool.QueryRow(ctx, `
-		SELECT transaction, request_id, currency, provider, amount, payment_dt, bank,
-			delivery_cost, goods_total, custom_fee
-		FROM payments WHERE order_uid = $1
-	`, uid).Scan(&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency,
-		&order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDT, &order.Payment.Bank,
-		&order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
-	); err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return nil, fmt.Errorf("select payment: %w", err)
-	}
+// GetByUID — получить заказ по uid (1:1) через GetByUIDs — 2 запроса. Если не нашли, возвращает (nil, nil).
+func (r *OrderRepository) GetByUID(ctx context.Context, uid string) (_ *domain.Order, err error) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.GetByUID", attrOrderUID.String(uid))
+	defer func() { telemetry.EndSpan(span, err) }()
 
-	// items (0..N)
-	rows, err := r.pool.Query(ctx, `
-		SELECT chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
-		FROM items WHERE order_uid = $1
-	`, uid)
+	orders, err := r.GetByUIDs(ctx, []string{uid})
 	if err != nil {
-		return nil, fmt.Errorf("select items: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var item domain.Item
-		if err := rows.Scan(
-			&item.ChrtID, &item.TrackNumber, &item.Price, &item.RID, &item.Name, &item.Sale, &item.Size,
-			&item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
-		); err != nil {
-			return nil, fmt.Errorf("scan item: %w", err)
-		}
-		order.Items = append(order.Items, item)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("items rows: %w", err)
+	if len(orders) == 0 {
+		span.SetAttributes(attrFound.Bool(false))
+		return nil, nil
 	}
 
-	return &order, nil
+	span.SetAttributes(attrFound.Bool(true), attrItems.Int(len(orders[0].Items)))
+	return orders[0], nil
 }
 
-// ListByCustomer — постраничный список заказов клиента.
-// Делает 4 запроса на страницу (пагинация): базовые заказы + payments + deliveries + items,
-// затем склеивает всё в памяти, сохраняя порядок.
-func (r *OrderRepository) ListByCustomer(ctx context.Context, customerID string, limit, offset int) ([]*domain.Order, error) {
-	if limit <= 0 {
-		limit = 20
-	}
-	if offset < 0 {
-		offset = 0
+// GetByUIDs — полные заказы по списку UID за постоянное число запросов (2 — независимо от len(uids)):
+// orders + deliveries + payments одним LEFT JOIN, затем все items через ANY($1).
+// Порядок результата совпадает с порядком uids; ненайденные UID пропускаются, повторы — схлопываются.
+// Позиции заказа — в порядке вставки (items.id), то есть как в исходном сообщении; это общий порядок
+// для GetByUID, ListByCustomer и LastN (раньше ListByCustomer сортировал позиции по chrt_id,
+// а GetByUID не задавал порядок вовсе).
+func (r *OrderRepository) GetByUIDs(ctx context.Context, uids []string) (_ []*domain.Order, err error) {
+	if len(uids) == 0 {
+		return nil, nil
 	}
 
-	// 1) База заказов для страницы (DESC).
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.GetByUIDs", attrUIDs.Int(len(uids)))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	// 1) Заказ + доставка + оплата (доставка и оплата могут отсутствовать — COALESCE в нулевые значения).
 	rows, err := r.pool.Query(ctx, `
 		SELECT
-			order_uid, track_number, entry, locale, internal_signature,
-			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-		FROM orders
-		WHERE customer_id = $1
-		ORDER BY date_created DESC, order_uid DESC
-		LIMIT $2 OFFSET $3
-	`, customerID, limit, offset)
+			o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
+			o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+			COALESCE(d.name, ''), COALESCE(d.phone, ''), COALESCE(d.zip, ''), COALESCE(d.city, ''),
+			COALESCE(d.address, ''), COALESCE(d.region, ''), COALESCE(d.email, ''),
+			COALESCE(p.transaction, ''), COALESCE(p.request_id, ''), COALESCE(p.currency, ''),
+			COALESCE(p.provider, ''), COALESCE(p.amount, 0), COALESCE(p.payment_dt, 0), COALESCE(p.bank, ''),
+			COALESCE(p.delivery_cost, 0), COALESCE(p.goods_total, 0), COALESCE(p.custom_fee, 0)
+		FROM orders o
+		LEFT JOIN deliveries d ON d.order_uid = o.order_uid
+		LEFT JOIN payments   p ON p.order_uid = o.order_uid
+		WHERE o.order_uid = ANY($1::text[])
+	`, uids)
 	if err != nil {
-		return nil, fmt.Errorf("select customer orders: %w", err)
+		return nil, fmt.Errorf("select orders: %w", err)
 	}
 	defer rows.Close()
 
-	orders := make([]*domain.Order, 0, limit)
-	byUID := make(map[string]*domain.Order, limit)
-	uids := make([]string, 0, limit)
-
+	byUID := make(map[string]*domain.Order, len(uids))
 	for rows.Next() {
 		order := &domain.Order{}
 		if err := rows.Scan(
 			&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
 			&order.CustomerID, &order.DeliveryService, &order.ShardKey, &order.SmID, &order.DateCreated, &order.OofShard,
+			&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
+			&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+			&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency,
+			&order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDT, &order.Payment.Bank,
+			&order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
 		); err != nil {
-			return nil, fmt.Errorf("scan order base: %w", err)
+			return nil, fmt.Errorf("scan order: %w", err)
 		}
-		orders = append(orders, order)
 		byUID[order.OrderUID] = order
-		uids = append(uids, order.OrderUID)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("orders rows: %w", err)
 	}
-	if len(orders) == 0 {
-		return orders, nil // пустая страница
-	}
+	rows.Close()
 
-	// 2) Payments для всех UID страницы
-	pRows, err := r.pool.Query(ctx, `
-		SELECT
-			order_uid, transaction, request_id, currency, provider, amount,
-			payment_dt, bank, delivery_cost, goods_total, custom_fee
-		FROM payments
-		WHERE order_uid = ANY($1::text[])
-	`, uids)
-	if err != nil {
-		return nil, fmt.Errorf("select payments: %w", err)
-	}
-	for pRows.Next() {
-		var uid string
-		var payment domain.Payment
-		if err := pRows.Scan(
-			&uid, &payment.Transaction, &payment.RequestID, &payment.Currency, &payment.Provider, &payment.Amount,
-			&payment.PaymentDT, &payment.Bank, &payment.DeliveryCost, &payment.GoodsTotal, &payment.CustomFee,
-		); err != nil {
-			pRows.Close()
-			return nil, fmt.Errorf("scan payment: %w", err)
-		}
-		if order := byUID[uid]; order != nil {
-			pCopy := payment
-			order.Payment = pCopy
-		}
-	}
-	if err := pRows.Err(); err != nil {
-		pRows.Close()
-		return nil, fmt.Errorf("payments rows: %w", err)
+	if len(byUID) == 0 {
+		span.SetAttributes(attrOrders.Int(0))
+		return nil, nil
 	}
-	pRows.Close()
 
-	// 3) Deliveries для всех UID страницы
-	dRows, err := r.pool.Query(ctx, `
-		SELECT
-			order_uid, name, phone, zip, city, address, region, email
-		FROM deliveries
-		WHERE order_uid = ANY($1::text[])
-	`, uids)
-	if err != nil {
-		return nil, fmt.Errorf("select deliveries: %w", err)
-	}
-	for dRows.Next() {
-		var uid string
-		var delivery domain.Delivery
-		if err := dRows.Scan(
-			&uid, &delivery.Name, &delivery.Phone, &delivery.Zip, &delivery.City, &delivery.Address, &delivery.Region, &delivery.Email,
-		); err != nil {
-			dRows.Close()
-			return nil, fmt.Errorf("scan delivery: %w", err)
-		}
-		if order := byUID[uid]; order != nil {
-			cDelivery := delivery
-			order.Delivery = cDelivery
-		}
-	}
-	if err := dRows.Err(); err != nil {
-		dRows.Close()
-		return nil, fmt.Errorf("deliveries rows: %w", err)
+	// 2) Items всех найденных заказов (порядок вставки — по id).
+	found := make([]string, 0, len(byUID))
+	for uid := range byUID {
+		found = append(found, uid)
 	}
-	dRows.Close()
-
-	// 4) Items для всех UID (сбор в map).
-	itemsByUID := make(map[string][]domain.Item, len(orders))
 	iRows, err := r.pool.Query(ctx, `
 		SELECT
 			order_uid, chrt_id, track_number, price, rid, name, sale, size,
 			total_price, nm_id, brand, status
 		FROM items
 		WHERE order_uid = ANY($1::text[])
-		ORDER BY order_uid, chrt_id
-	`, uids)
+		ORDER BY order_uid, id
+	`, found)
 	if err != nil {
 		return nil, fmt.Errorf("select items: %w", err)
 	}
+	defer iRows.Close()
+
 	for iRows.Next() {
 		var uid string
 		var item domain.Item
@@ -334,34 +320,128 @@ func (r *OrderRepository) ListByCustomer(ctx context.Context, customerID string,
 			&uid, &item.ChrtID, &item.TrackNumber, &item.Price, &item.RID, &item.Name, &item.Sale, &item.Size,
 			&item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
 		); err != nil {
-			iRows.Close()
 			return nil, fmt.Errorf("scan item: %w", err)
 		}
-		itemsByUID[uid] = append(itemsByUID[uid], item)
+		if order := byUID[uid]; order != nil {
+			order.Items = append(order.Items, item)
+		}
 	}
 	if err := iRows.Err(); err != nil {
-		iRows.Close()
 		return nil, fmt.Errorf("items rows: %w", err)
 	}
-	iRows.Close()
 
-	// Склейка: добавляем связанные части, порядок базового SELECT сохраняется.
-	for _, order := range orders {
-		if items := itemsByUID[order.OrderUID]; len(items) > 0 {
-			order.Items = items
+	// Склейка в порядке запрошенных UID.
+	orders := make([]*domain.Order, 0, len(byUID))
+	for _, uid := range uids {
+		if order, ok := byUID[uid]; ok {
+			orders = append(orders, order)
+			delete(byUID, uid) // повторный UID во входе не дублирует заказ
 		}
 	}
+
+	span.SetAttributes(attrOrders.Int(len(orders)))
+	return orders, nil
+}
+
+// ListByCustomer — постраничный список заказов клиента (сортировка по date_created DESC).
+// Страница UID одним запросом, затем полные заказы через GetByUIDs — 3 запроса на страницу.
+func (r *OrderRepository) ListByCustomer(
+	ctx context.Context, customerID string, limit, offset int,
+) (_ []*domain.Order, err error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.ListByCustomer",
+		attrCustomerID.String(customerID), attrLimit.Int(limit), attrOffset.Int(offset))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	uids, err := r.selectUIDs(ctx, `
+		SELECT order_uid
+		FROM orders
+		WHERE customer_id = $1
+		ORDER BY date_created DESC, order_uid DESC
+		LIMIT $2 OFFSET $3
+	`, customerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("select customer orders: %w", err)
+	}
+
+	orders, err := r.GetByUIDs(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []*domain.Order{} // пустая страница
+	}
+
+	span.SetAttributes(attrOrders.Int(len(orders)))
+	return orders, nil
+}
+
+// ListByCustomerAfter — keyset-страница заказов клиента: строго после курсора after
+// (nil — с начала) в порядке date_created DESC, order_uid DESC. Сравнение кортежей
+// использует индекс idx_orders_customer_date_uid, поэтому цена не растёт с номером страницы.
+func (r *OrderRepository) ListByCustomerAfter(
+	ctx context.Context, customerID string, after *domain.OrderCursor, limit int,
+) (_ []*domain.Order, err error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.ListByCustomerAfter",
+		attrCustomerID.String(customerID), attrLimit.Int(limit), attrCursor.Bool(after != nil))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	var uids []string
+	if after == nil {
+		uids, err = r.selectUIDs(ctx, `
+			SELECT order_uid
+			FROM orders
+			WHERE customer_id = $1
+			ORDER BY date_created DESC, order_uid DESC
+			LIMIT $2
+		`, customerID, limit)
+	} else {
+		uids, err = r.selectUIDs(ctx, `
+			SELECT order_uid
+			FROM orders
+			WHERE customer_id = $1
+			  AND (date_created, order_uid) < ($2, $3)
+			ORDER BY date_created DESC, order_uid DESC
+			LIMIT $4
+		`, customerID, after.DateCreated, after.OrderUID, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select customer orders after cursor: %w", err)
+	}
+
+	orders, err := r.GetByUIDs(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []*domain.Order{} // пустая страница
+	}
+
+	span.SetAttributes(attrOrders.Int(len(orders)))
 	return orders, nil
 }
 
 // LastN — последние N заказов (для прогрева кэша).
-// Используем подход N+1: берём только UID, затем дочитываем полные заказы.
-func (r *OrderRepository) LastN(ctx context.Context, n int) ([]*domain.Order, error) {
+// UID одним запросом, затем полные заказы через GetByUIDs — 3 запроса при любом N.
+func (r *OrderRepository) LastN(ctx context.Context, n int) (_ []*domain.Order, err error) {
 	if n <= 0 {
 		return nil, nil
 	}
 
-	rows, err := r.pool.Query(ctx, `
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.LastN", attrLimit.Int(n))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	uids, err := r.selectUIDs(ctx, `
 		SELECT order_uid
 		FROM orders
 		ORDER BY date_created DESC
@@ -370,29 +450,29 @@ func (r *OrderRepository) LastN(ctx context.Context, n int) ([]*domain.Order, er
 	if err != nil {
 		return nil, fmt.Errorf("select last uids: %w", err)
 	}
-	defer rows.Close()
 
-	var result []*domain.Order
-	for rows.Next() {
-		var orderUID string
-		if err := rows.Scan(&orderUID); err != nil {
-			return nil, fmt.Errorf("scan uid: %w", err)
-		}
-		order, err := r.GetByUID(ctx, orderUID)
-		if err != nil {
-			return nil, err
-		}
-		if order != nil {
-			result = append(result, order)
-		}
-	}
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("last rows: %w", err)
+	result, err := r.GetByUIDs(ctx, uids)
+	if err != nil {
+		return nil, err
 	}
 
+	span.SetAttributes(attrOrders.Int(len(result)))
 	return result, nil
 }
 
+// selectUIDs — выполняет запрос, возвращающий одну колонку order_uid, с сохранением порядка.
+func (r *OrderRepository) selectUIDs(ctx context.Context, sql string, args ...any) ([]string, error) {
+	rows, err := r.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	uids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan uid: %w", err)
+	}
+	return uids, nil
+}
+
 // copyItems — вставка items через COPY (CopyFromRows); быстрее, чем INSERT в цикле.
 func copyItems(ctx context.Context, tx pgx.Tx, orderUID string, items []domain.Item) error {
 	rows := make([][]any, 0, len(items))
@@ -411,7 +491,7 @@ func copyItems(ctx context.Context, tx pgx.Tx, orderUID string, items []domain.I
 		pgx.CopyFromRows(rows),
 	)
 	if err != nil {
-		return fmt.Errorf("copy items: %w", err)
+		return fmt.Errorf("copy items: %w", classifyError(err))
 	}
 	return nil
 }
@@ -0,0 +1,207 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	pgrepo "github.com/Gunvolt24/wb_l0/internal/repo/postgres"
+	"github.com/Gunvolt24/wb_l0/internal/testutil"
+)
+
+// Загрузка N заказов. GetByUID построен на GetByUIDs (2 запроса на вызов), поэтому для сравнения
+// с прежней схемой её воспроизводят legacy-варианты: 4 запроса на заказ и LastN по схеме N+1.
+//   - legacy-GetByUID-loop — 4N запросов;
+//   - GetByUID-loop        — 2N запросов;
+//   - GetByUIDs            — 2 запроса;
+//   - legacy-LastN         — 1 + 4N запросов;
+//   - LastN                — 3 запроса.
+func BenchmarkRepo_Hydrate(b *testing.B) {
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	if err != nil {
+		b.Fatalf("start postgres: %v", err)
+	}
+	defer func() { _ = stopPG(context.Background()) }()
+	if err := testutil.ApplyMigrationsGoose(pg.DSN); err != nil {
+		b.Fatalf("migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	if err != nil {
+		b.Fatalf("pool: %v", err)
+	}
+	defer pool.Close()
+
+	repo := pgrepo.NewOrderRepository(pool)
+
+	// наполняем БД один раз на все под-бенчмарки
+	const total = 500
+	uids := make([]string, 0, total)
+	batch := make([]*domain.Order, 0, total)
+	for i := 0; i < total; i++ {
+		o := testutil.MakeOrder(testutil.WithItems(3))
+		batch = append(batch, &o)
+		uids = append(uids, o.OrderUID)
+	}
+	if err := repo.SaveBatch(ctx, batch); err != nil {
+		b.Fatalf("seed: %v", err)
+	}
+
+	for _, n := range []int{10, 100, total} {
+		subset := uids[:n]
+
+		b.Run("legacy-GetByUID-loop/N="+strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, uid := range subset {
+					if _, err := legacyGetByUID(ctx, pool, uid); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run("GetByUID-loop/N="+strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, uid := range subset {
+					if _, err := repo.GetByUID(ctx, uid); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run("GetByUIDs/N="+strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.GetByUIDs(ctx, subset); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("legacy-LastN/N="+strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := legacyLastN(ctx, pool, n); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run("LastN/N="+strconv.Itoa(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.LastN(ctx, n); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// legacyGetByUID — прежняя загрузка заказа: orders, deliveries, payments и items отдельными запросами.
+func legacyGetByUID(ctx context.Context, pool *pgxpool.Pool, uid string) (*domain.Order, error) {
+	var order domain.Order
+
+	err := pool.QueryRow(ctx, `
+		SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, delivery_service,
+			shardkey, sm_id, date_created, oof_shard
+		FROM orders WHERE order_uid = $1
+	`, uid).Scan(&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
+		&order.CustomerID, &order.DeliveryService, &order.ShardKey, &order.SmID, &order.DateCreated, &order.OofShard,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select order: %w", err)
+	}
+
+	if err := pool.QueryRow(ctx, `
+		SELECT name, phone, zip, city, address, region, email
+		FROM deliveries WHERE order_uid = $1
+	`, uid).Scan(&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
+		&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+	); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("select delivery: %w", err)
+	}
+
+	if err := pool.QueryRow(ctx, `
+		SELECT transaction, request_id, currency, provider, amount, payment_dt, bank,
+			delivery_cost, goods_total, custom_fee
+		FROM payments WHERE order_uid = $1
+	`, uid).Scan(&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency,
+		&order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDT, &order.Payment.Bank,
+		&order.Payment.DeliveryCost, &order.Payment.GoodsTotal, &order.Payment.CustomFee,
+	); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("select payment: %w", err)
+	}
+
+	rows, err := pool.Query(ctx, `
+		SELECT chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+		FROM items WHERE order_uid = $1
+	`, uid)
+	if err != nil {
+		return nil, fmt.Errorf("select items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.Item
+		if err := rows.Scan(
+			&item.ChrtID, &item.TrackNumber, &item.Price, &item.RID, &item.Name, &item.Sale, &item.Size,
+			&item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+		); err != nil {
+			return nil, fmt.Errorf("scan item: %w", err)
+		}
+		order.Items = append(order.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("items rows: %w", err)
+	}
+	return &order, nil
+}
+
+// legacyLastN — прежний LastN: страница UID, затем legacyGetByUID на каждый заказ (N+1).
+func legacyLastN(ctx context.Context, pool *pgxpool.Pool, n int) ([]*domain.Order, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT order_uid
+		FROM orders
+		ORDER BY date_created DESC
+		LIMIT $1
+	`, n)
+	if err != nil {
+		return nil, fmt.Errorf("select last uids: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*domain.Order
+	for rows.Next() {
+		var orderUID string
+		if err := rows.Scan(&orderUID); err != nil {
+			return nil, fmt.Errorf("scan uid: %w", err)
+		}
+		order, err := legacyGetByUID(ctx, pool, orderUID)
+		if err != nil {
+			return nil, err
+		}
+		if order != nil {
+			result = append(result, order)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("last rows: %w", err)
+	}
+	return result, nil
+}
@@ -303,3 +303,357 @@ func TestRepo_Save_ValidationErrors_TC(t *testing.T) {
 	o2.CustomerID = ""
 	require.Error(t, repo.Save(ctx, &o2))
 }
+
+// 7) Save — ошибки БД классифицируются доменными sentinel-ошибками
+func TestRepo_Save_ClassifiesConstraintErrors_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	repo := pgrepo.NewOrderRepository(pool)
+
+	// payments.transaction другого заказа → ErrDuplicatePaymentTransaction
+	first := testutil.MakeOrder()
+	require.NoError(t, repo.Save(ctx, &first))
+
+	second := testutil.MakeOrder()
+	second.Payment.Transaction = first.Payment.Transaction
+	err = repo.Save(ctx, &second)
+	require.ErrorIs(t, err, domain.ErrDuplicatePaymentTransaction)
+	require.ErrorIs(t, err, domain.ErrConstraintViolation)
+
+	// CHECK (amount >= 0) → ErrConstraintViolation
+	negative := testutil.MakeOrder()
+	negative.Payment.Amount = -1
+	err = repo.Save(ctx, &negative)
+	require.ErrorIs(t, err, domain.ErrConstraintViolation)
+	require.NotErrorIs(t, err, domain.ErrTransient)
+
+	// Транзакция откатилась — заказа нет
+	got, err := repo.GetByUID(ctx, second.OrderUID)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+// 8) SaveBatch — все заказы одной транзакцией; ошибка одного откатывает весь пакет
+func TestRepo_SaveBatch_AllOrNothing_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	repo := pgrepo.NewOrderRepository(pool)
+
+	// Успешный пакет — все заказы читаются
+	o1 := testutil.MakeOrder(testutil.WithItems(2))
+	o2 := testutil.MakeOrder()
+	require.NoError(t, repo.SaveBatch(ctx, []*domain.Order{&o1, &o2}))
+
+	for _, uid := range []string{o1.OrderUID, o2.OrderUID} {
+		got, err := repo.GetByUID(ctx, uid)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+	}
+
+	// Пакет с нарушением ограничения — откатывается целиком
+	ok := testutil.MakeOrder()
+	bad := testutil.MakeOrder()
+	bad.Payment.Amount = -1
+	err = repo.SaveBatch(ctx, []*domain.Order{&ok, &bad})
+	require.ErrorIs(t, err, domain.ErrConstraintViolation)
+	require.Contains(t, err.Error(), bad.OrderUID)
+
+	got, err := repo.GetByUID(ctx, ok.OrderUID)
+	require.NoError(t, err)
+	require.Nil(t, got)
+}
+
+// 9) GetByUIDs — порядок входных UID, пропуск отсутствующих, схлопывание дублей, items на месте
+func TestRepo_GetByUIDs_OrderMissingAndDuplicates_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	repo := pgrepo.NewOrderRepository(pool)
+
+	o1 := testutil.MakeOrder(testutil.WithItems(3))
+	o2 := testutil.MakeOrder(testutil.WithItems(1))
+	o3 := testutil.MakeOrder(testutil.WithItems(2))
+	// chrt_id по убыванию: позиции должны вернуться в порядке сообщения, а не по chrt_id
+	for i := range o1.Items {
+		o1.Items[i].ChrtID = 3000 - i
+	}
+	require.NoError(t, repo.SaveBatch(ctx, []*domain.Order{&o1, &o2, &o3}))
+
+	got, err := repo.GetByUIDs(ctx, []string{o3.OrderUID, "missing-uid", o1.OrderUID, o3.OrderUID, o2.OrderUID})
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+
+	require.Equal(t, o3.OrderUID, got[0].OrderUID)
+	require.Equal(t, o1.OrderUID, got[1].OrderUID)
+	require.Equal(t, o2.OrderUID, got[2].OrderUID)
+
+	require.Len(t, got[0].Items, 2)
+	require.Len(t, got[1].Items, 3)
+	require.Len(t, got[2].Items, 1)
+	require.Equal(t, o1.Payment.Transaction, got[1].Payment.Transaction)
+	require.Equal(t, o1.Delivery.Name, got[1].Delivery.Name)
+
+	// Позиции — в порядке исходного сообщения, одинаково для GetByUIDs и GetByUID
+	wantChrt := []int{3000, 2999, 2998}
+	single, err := repo.GetByUID(ctx, o1.OrderUID)
+	require.NoError(t, err)
+	for _, ord := range []*domain.Order{got[1], single} {
+		chrt := make([]int, 0, len(ord.Items))
+		for _, it := range ord.Items {
+			chrt = append(chrt, it.ChrtID)
+		}
+		require.Equal(t, wantChrt, chrt)
+	}
+
+	// Пустой вход — без обращения к БД
+	empty, err := repo.GetByUIDs(ctx, nil)
+	require.NoError(t, err)
+	require.Empty(t, empty)
+}
+
+// 10) ListByCustomerAfter — keyset-пагинация: без пропусков и повторов при вставке во время листания,
+// равные date_created упорядочиваются по order_uid
+func TestRepo_ListByCustomerAfter_StableUnderInserts_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	repo := pgrepo.NewOrderRepository(pool)
+
+	const cust = "cust-keyset"
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	// 6 заказов; у двух пар одинаковое время — порядок внутри пары задаёт order_uid
+	var seeded []string
+	for i := 0; i < 6; i++ {
+		o := testutil.MakeOrder(testutil.WithCustomer(cust))
+		o.DateCreated = base.Add(time.Duration(i/2) * time.Minute)
+		require.NoError(t, repo.Save(ctx, &o))
+		seeded = append(seeded, o.OrderUID)
+	}
+
+	page1, err := repo.ListByCustomerAfter(ctx, cust, nil, 4)
+	require.NoError(t, err)
+	require.Len(t, page1, 4)
+
+	// Новый заказ приходит между страницами — при OFFSET он сдвинул бы выборку
+	fresh := testutil.MakeOrder(testutil.WithCustomer(cust))
+	fresh.DateCreated = time.Now().UTC()
+	require.NoError(t, repo.Save(ctx, &fresh))
+
+	cursor := domain.CursorAfter(page1[len(page1)-1])
+	page2, err := repo.ListByCustomerAfter(ctx, cust, &cursor, 4)
+	require.NoError(t, err)
+	require.Len(t, page2, 2)
+
+	seen := make(map[string]bool)
+	all := append(append([]*domain.Order(nil), page1...), page2...)
+	for i, o := range all {
+		require.False(t, seen[o.OrderUID], "duplicate %s", o.OrderUID)
+		seen[o.OrderUID] = true
+		if i > 0 {
+			prev := all[i-1]
+			desc := prev.DateCreated.After(o.DateCreated) ||
+				(prev.DateCreated.Equal(o.DateCreated) && prev.OrderUID > o.OrderUID)
+			require.True(t, desc, "order broken at %d", i)
+		}
+	}
+	for _, uid := range seeded {
+		require.True(t, seen[uid], "skipped %s", uid)
+	}
+	require.False(t, seen[fresh.OrderUID])
+}
+
+// 11) Search — критерии по заказу, items и доставке объединяются через AND, пагинация курсором
+func TestRepo_Search_Filters_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	repo := pgrepo.NewOrderRepository(pool)
+
+	base := time.Now().UTC().Add(-24 * time.Hour).Truncate(time.Second)
+
+	target := testutil.MakeOrder(testutil.WithItems(2))
+	target.DateCreated = base
+	target.DeliveryService = "meest"
+	target.Delivery.Email = "Support.Case@Example.com"
+	target.Delivery.Phone = "+9720000001"
+	target.Items[1].Brand = "Vivienne Sabo"
+
+	sameBrand := testutil.MakeOrder(testutil.WithItems(1))
+	sameBrand.DateCreated = base.Add(time.Hour)
+	sameBrand.Items[0].Brand = "Vivienne Sabo"
+
+	noise := testutil.MakeOrder()
+	require.NoError(t, repo.SaveBatch(ctx, []*domain.Order{&target, &sameBrand, &noise}))
+
+	uidsOf := func(list []*domain.Order) []string {
+		out := make([]string, 0, len(list))
+		for _, o := range list {
+			out = append(out, o.OrderUID)
+		}
+		return out
+	}
+
+	cases := []struct {
+		name   string
+		filter domain.OrderFilter
+		want   []string
+	}{
+		{"track_number", domain.OrderFilter{TrackNumber: target.TrackNumber}, []string{target.OrderUID}},
+		{"rid", domain.OrderFilter{RID: target.Items[0].RID}, []string{target.OrderUID}},
+		{"email case-insensitive", domain.OrderFilter{Email: "support.case@example.com"}, []string{target.OrderUID}},
+		{"phone", domain.OrderFilter{Phone: "+9720000001"}, []string{target.OrderUID}},
+		{"brand newest first", domain.OrderFilter{Brand: "Vivienne Sabo"}, []string{sameBrand.OrderUID, target.OrderUID}},
+		{"brand and service", domain.OrderFilter{Brand: "Vivienne Sabo", DeliveryService: "meest"}, []string{target.OrderUID}},
+		{"date range", domain.OrderFilter{Brand: "Vivienne Sabo", CreatedFrom: base, CreatedTo: base.Add(time.Minute)}, []string{target.OrderUID}},
+		{"no match", domain.OrderFilter{TrackNumber: "nope"}, []string{}},
+	}
+	for _, tc := range cases {
+		got, err := repo.Search(ctx, tc.filter, nil, 10)
+		require.NoError(t, err, tc.name)
+		require.Equal(t, tc.want, uidsOf(got), tc.name)
+	}
+
+	// Курсор: вторая страница поиска по бренду
+	page1, err := repo.Search(ctx, domain.OrderFilter{Brand: "Vivienne Sabo"}, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, page1, 1)
+	cursor := domain.CursorAfter(page1[0])
+	page2, err := repo.Search(ctx, domain.OrderFilter{Brand: "Vivienne Sabo"}, &cursor, 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{target.OrderUID}, uidsOf(page2))
+
+	// Пустой фильтр отклоняется
+	_, err = repo.Search(ctx, domain.OrderFilter{}, nil, 10)
+	require.ErrorIs(t, err, domain.ErrInvalidFilter)
+}
+
+// 12) SearchItems — префиксный полнотекстовый поиск по name/brand, группировка по заказу, ранг и подсветка
+func TestRepo_SearchItems_RankedAndGrouped_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	repo := pgrepo.NewOrderRepository(pool)
+
+	// совпадение в названии (вес A) у двух позиций одного заказа
+	byName := testutil.MakeOrder(testutil.WithItems(3))
+	byName.Items[0].Name, byName.Items[0].Brand = "Mascaras Lash", "Vivienne Sabo"
+	byName.Items[1].Name, byName.Items[1].Brand = "Mascara Waterproof", "Maybelline"
+	// совпадение только в бренде (вес B)
+	byBrand := testutil.MakeOrder(testutil.WithItems(1))
+	byBrand.Items[0].Name, byBrand.Items[0].Brand = "Lipstick", "Mascara House"
+	noise := testutil.MakeOrder()
+	require.NoError(t, repo.SaveBatch(ctx, []*domain.Order{&byName, &byBrand, &noise}))
+
+	hits, err := repo.SearchItems(ctx, "masc", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+
+	require.Equal(t, byName.OrderUID, hits[0].OrderUID)
+	require.Equal(t, byBrand.OrderUID, hits[1].OrderUID)
+	require.Greater(t, hits[0].Rank, hits[1].Rank)
+	require.Len(t, hits[0].Snippets, 2) // обе позиции заказа — в одной группе
+	require.Contains(t, hits[0].Snippets[0], "<mark>")
+	require.Contains(t, hits[1].Snippets[0], "<mark>Mascara</mark>")
+
+	// несколько слов — AND
+	hits, err = repo.SearchItems(ctx, "mascara maybel", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, byName.OrderUID, hits[0].OrderUID)
+
+	// offset
+	hits, err = repo.SearchItems(ctx, "masc", 10, 1)
+	require.NoError(t, err)
+	require.Len(t, hits, 1)
+	require.Equal(t, byBrand.OrderUID, hits[0].OrderUID)
+
+	_, err = repo.SearchItems(ctx, "!!!", 10, 0)
+	require.ErrorIs(t, err, domain.ErrInvalidFilter)
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/pkg/telemetry"
+)
+
+// Search — поиск заказов по фильтру с keyset-пагинацией (как ListByCustomerAfter):
+// страница UID одним запросом, затем полные заказы через GetByUIDs.
+// Сортировка — date_created DESC, order_uid DESC; after == nil — первая страница.
+func (r *OrderRepository) Search(
+	ctx context.Context, filter domain.OrderFilter, after *domain.OrderCursor, limit int,
+) (_ []*domain.Order, err error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderRepository.Search",
+		attrLimit.Int(limit), attrCursor.Bool(after != nil))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	sql, args := searchQuery(filter, after, limit)
+	uids, err := r.selectUIDs(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search orders: %w", err)
+	}
+
+	orders, err := r.GetByUIDs(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+	if orders == nil {
+		orders = []*domain.Order{} // пустая страница
+	}
+
+	span.SetAttributes(attrOrders.Int(len(orders)))
+	return orders, nil
+}
+
+// searchQuery — SQL выборки UID по фильтру. Условия только на заданные поля, значения — плейсхолдерами.
+// items/deliveries проверяются через EXISTS: заказ не дублируется при нескольких совпавших позициях.
+// Индексы: idx_orders_track_number, idx_items_rid, idx_items_brand, idx_deliveries_email_lower,
+// idx_deliveries_phone, idx_orders_delivery_service_date, idx_orders_date_created.
+func searchQuery(f domain.OrderFilter, after *domain.OrderCursor, limit int) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if f.TrackNumber != "" {
+		conds = append(conds, "o.track_number = "+arg(f.TrackNumber))
+	}
+	if f.DeliveryService != "" {
+		conds = append(conds, "o.delivery_service = "+arg(f.DeliveryService))
+	}
+	if f.RID != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.rid = "+arg(f.RID)+")")
+	}
+	if f.Brand != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.brand = "+arg(f.Brand)+")")
+	}
+	if f.Email != "" {
+		conds = append(conds,
+			"EXISTS (SELECT 1 FROM deliveries d WHERE d.order_uid = o.order_uid AND lower(d.email) = lower("+arg(f.Email)+"))")
+	}
+	if f.Phone != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM deliveries d WHERE d.order_uid = o.order_uid AND d.phone = "+arg(f.Phone)+")")
+	}
+	if !f.CreatedFrom.IsZero() {
+		conds = append(conds, "o.date_created >= "+arg(f.CreatedFrom))
+	}
+	if !f.CreatedTo.IsZero() {
+		conds = append(conds, "o.date_created < "+arg(f.CreatedTo))
+	}
+	if after != nil {
+		conds = append(conds, "(o.date_created, o.order_uid) < ("+arg(after.DateCreated)+", "+arg(after.OrderUID)+")")
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT o.order_uid FROM orders o")
+	if len(conds) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(conds, " AND "))
+	}
+	b.WriteString(" ORDER BY o.date_created DESC, o.order_uid DESC LIMIT ")
+	b.WriteString(arg(limit))
+	return b.String(), args
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+)
+
+func TestSearchQuery(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	tests := []struct {
+		name   string
+		filter domain.OrderFilter
+		after  *domain.OrderCursor
+		sql    string
+		args   int
+	}{
+		{
+			name:   "track number only",
+			filter: domain.OrderFilter{TrackNumber: "WBILM"},
+			sql: "SELECT o.order_uid FROM orders o WHERE o.track_number = $1" +
+				" ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $2",
+			args: 2,
+		},
+		{
+			name:   "items and delivery via EXISTS",
+			filter: domain.OrderFilter{RID: "rid-1", Email: "A@B.C"},
+			sql: "SELECT o.order_uid FROM orders o WHERE " +
+				"EXISTS (SELECT 1 FROM items i WHERE i.order_uid = o.order_uid AND i.rid = $1) AND " +
+				"EXISTS (SELECT 1 FROM deliveries d WHERE d.order_uid = o.order_uid AND lower(d.email) = lower($2))" +
+				" ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $3",
+			args: 3,
+		},
+		{
+			name:   "date range with cursor",
+			filter: domain.OrderFilter{CreatedFrom: from, CreatedTo: to},
+			after:  &domain.OrderCursor{DateCreated: to, OrderUID: "x"},
+			sql: "SELECT o.order_uid FROM orders o WHERE o.date_created >= $1 AND o.date_created < $2" +
+				" AND (o.date_created, o.order_uid) < ($3, $4)" +
+				" ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $5",
+			args: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sql, args := searchQuery(tt.filter, tt.after, 10)
+			if sql != tt.sql {
+				t.Fatalf("sql:\n got %s\nwant %s", sql, tt.sql)
+			}
+			if len(args) != tt.args || args[len(args)-1] != 10 {
+				t.Fatalf("args: got %v, want %d args ending with limit", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestPrefixTSQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"Mascaras":             "mascaras:*",
+		"  vivienne   SABO ":   "vivienne:* & sabo:*",
+		"тушь для ресниц":      "тушь:* & для:* & ресниц:*",
+		"a&b | !c:* (d)":       "a:* & b:* & c:* & d:*",
+		"&|!()":                "",
+		"":                     "",
+		"1 2 3 4 5 6 7 8 9 10": "1:* & 2:* & 3:* & 4:* & 5:* & 6:* & 7:* & 8:*",
+		"o'reilly":             "o:* & reilly:*",
+	}
+	for in, want := range tests {
+		if got := prefixTSQuery(in); got != want {
+			t.Fatalf("prefixTSQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
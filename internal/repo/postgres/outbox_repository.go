@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Проверка, что OutboxRepository удовлетворяет интерфейсу OutboxRepository.
+var _ ports.OutboxRepository = (*OutboxRepository)(nil)
+
+// OutboxRepository — чтение и подтверждение событий outbox (запись — в OrderRepository.Save).
+type OutboxRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewOutboxRepository — конструктор.
+func NewOutboxRepository(pool *pgxpool.Pool) *OutboxRepository { return &OutboxRepository{pool: pool} }
+
+// ClaimUnsent — захватывает неотправленные события на время lease и возвращает их в порядке записи.
+// Строки выбираются с FOR UPDATE SKIP LOCKED, поэтому параллельные relay разных реплик не получают
+// одни и те же события; если relay упал до MarkSent, события снова доступны после истечения аренды.
+func (r *OutboxRepository) ClaimUnsent(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		UPDATE outbox SET claimed_until = now() + $2::interval
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at IS NULL AND (claimed_until IS NULL OR claimed_until < now())
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, aggregate_id, event_type, payload, created_at
+	`, limit, lease)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox: %w", classifyError(err))
+	}
+	defer rows.Close()
+
+	events := make([]domain.OutboxEvent, 0, limit)
+	for rows.Next() {
+		var event domain.OutboxEvent
+		if err := rows.Scan(&event.ID, &event.AggregateID, &event.EventType, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan outbox: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows outbox: %w", classifyError(err))
+	}
+
+	// RETURNING не гарантирует порядок — восстанавливаем порядок записи.
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// MarkSent — помечает события отправленными.
+func (r *OutboxRepository) MarkSent(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if _, err := r.pool.Exec(ctx, `
+		UPDATE outbox SET sent_at = now()
+		WHERE id = ANY($1) AND sent_at IS NULL
+	`, ids); err != nil {
+		return fmt.Errorf("mark outbox sent: %w", classifyError(err))
+	}
+	return nil
+}
+
+// PruneSent — удаляет события, отправленные раньше olderThan (не более limit за вызов,
+// чтобы не держать долгую блокировку). Возвращает число удалённых строк.
+func (r *OutboxRepository) PruneSent(ctx context.Context, olderThan time.Duration, limit int) (int64, error) {
+	if limit <= 0 {
+		return 0, nil
+	}
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM outbox
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at IS NOT NULL AND sent_at < now() - $1::interval
+			LIMIT $2
+		)
+	`, olderThan, limit)
+	if err != nil {
+		return 0, fmt.Errorf("prune outbox: %w", classifyError(err))
+	}
+	return tag.RowsAffected(), nil
+}
+
+// insertOutboxEvent — пишет событие о заказе в outbox в рамках транзакции сохранения.
+func insertOutboxEvent(ctx context.Context, tx pgx.Tx, order *domain.Order, eventType string) error {
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("marshal outbox payload: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO outbox (aggregate_id, event_type, payload) VALUES ($1, $2, $3)
+	`, order.OrderUID, eventType, payload); err != nil {
+		return fmt.Errorf("insert outbox: %w", classifyError(err))
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	pgrepo "github.com/Gunvolt24/wb_l0/internal/repo/postgres"
+	"github.com/Gunvolt24/wb_l0/internal/testutil"
+)
+
+// Save пишет событие outbox: первый раз — order.saved, повторно — order.updated;
+// захваченные события не выдаются повторно до истечения аренды, MarkSent убирает их насовсем
+func TestOutbox_SaveWritesEvents_ClaimAndMarkSent_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	orders := pgrepo.NewOrderRepository(pool).WithOutbox()
+	outbox := pgrepo.NewOutboxRepository(pool)
+
+	ord := testutil.MakeOrder()
+	require.NoError(t, orders.Save(ctx, &ord))
+	ord.TrackNumber = "UPDATED"
+	require.NoError(t, orders.Save(ctx, &ord))
+
+	const lease = 300 * time.Millisecond
+	events, err := outbox.ClaimUnsent(ctx, 10, lease)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, domain.EventOrderSaved, events[0].EventType)
+	require.Equal(t, domain.EventOrderUpdated, events[1].EventType)
+	require.Equal(t, ord.OrderUID, events[1].AggregateID)
+	require.Contains(t, string(events[1].Payload), "UPDATED")
+
+	// Пока аренда действует, другая реплика событий не получает
+	other, err := outbox.ClaimUnsent(ctx, 10, lease)
+	require.NoError(t, err)
+	require.Empty(t, other)
+
+	require.NoError(t, outbox.MarkSent(ctx, []int64{events[0].ID}))
+
+	// После истечения аренды неотправленное событие снова доступно, отправленное — нет
+	time.Sleep(lease + 100*time.Millisecond)
+	left, err := outbox.ClaimUnsent(ctx, 10, lease)
+	require.NoError(t, err)
+	require.Len(t, left, 1)
+	require.Equal(t, events[1].ID, left[0].ID)
+
+	// Откат транзакции Save не оставляет событий
+	bad := testutil.MakeOrder()
+	bad.Payment.Amount = -1
+	require.Error(t, orders.Save(ctx, &bad))
+
+	time.Sleep(lease + 100*time.Millisecond)
+	left, err = outbox.ClaimUnsent(ctx, 10, lease)
+	require.NoError(t, err)
+	require.Len(t, left, 1)
+}
+
+// Без WithOutbox Save событий не пишет (outbox выключен в конфигурации)
+func TestOutbox_DisabledWritesNoEvents_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	orders := pgrepo.NewOrderRepository(pool)
+	outbox := pgrepo.NewOutboxRepository(pool)
+
+	ord := testutil.MakeOrder()
+	require.NoError(t, orders.Save(ctx, &ord))
+	require.NoError(t, orders.SaveBatch(ctx, []*domain.Order{&ord}))
+
+	events, err := outbox.ClaimUnsent(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+// Параллельные захваты не выдают одно событие дважды
+func TestOutbox_ConcurrentClaims_Disjoint_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	orders := pgrepo.NewOrderRepository(pool).WithOutbox()
+	outbox := pgrepo.NewOutboxRepository(pool)
+
+	const total = 40
+	for i := 0; i < total; i++ {
+		ord := testutil.MakeOrder()
+		require.NoError(t, orders.Save(ctx, &ord))
+	}
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[int64]int)
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				events, err := outbox.ClaimUnsent(ctx, 5, time.Minute)
+				if err != nil {
+					t.Errorf("claim: %v", err)
+					return
+				}
+				if len(events) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, e := range events {
+					seen[e.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, seen, total)
+	for id, n := range seen {
+		require.Equal(t, 1, n, "event %d claimed %d times", id, n)
+	}
+}
+
+// PruneSent удаляет только отправленные события старше срока хранения
+func TestOutbox_PruneSent_TC(t *testing.T) {
+	t.Parallel()
+
+	ctxStart, cancelStart := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelStart()
+
+	pg, stopPG, err := testutil.StartPostgresTC(ctxStart)
+	require.NoError(t, err)
+	defer func() { _ = stopPG(context.Background()) }()
+	require.NoError(t, testutil.ApplyMigrationsGoose(pg.DSN))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, pg.DSN)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	orders := pgrepo.NewOrderRepository(pool).WithOutbox()
+	outbox := pgrepo.NewOutboxRepository(pool)
+
+	for i := 0; i < 3; i++ {
+		ord := testutil.MakeOrder()
+		require.NoError(t, orders.Save(ctx, &ord))
+	}
+	events, err := outbox.ClaimUnsent(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	// Два события отправлены давно, третье — только что; событие нового заказа не отправлено вовсе
+	require.NoError(t, outbox.MarkSent(ctx, []int64{events[0].ID, events[1].ID, events[2].ID}))
+	_, err = pool.Exec(ctx, `UPDATE outbox SET sent_at = now() - interval '2 hours' WHERE id = ANY($1)`,
+		[]int64{events[0].ID, events[1].ID})
+	require.NoError(t, err)
+	unsent := testutil.MakeOrder()
+	require.NoError(t, orders.Save(ctx, &unsent))
+
+	n, err := outbox.PruneSent(ctx, time.Hour, 1)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n) // limit соблюдается
+
+	n, err = outbox.PruneSent(ctx, time.Hour, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	var left int
+	require.NoError(t, pool.QueryRow(ctx, `SELECT count(*) FROM outbox`).Scan(&left))
+	require.Equal(t, 2, left)
+}
@@ -25,6 +25,9 @@ func NewPool(ctx context.Context, dsn string, maxConns int32) (*pgxpool.Pool, er
 	cfg.MaxConnLifetime = time.Hour
 	cfg.MaxConnIdleTime = 30 * time.Minute
 
+	// Трейсинг SQL: client-спан на каждый запрос (no-op, если трейсинг не настроен).
+	cfg.ConnConfig.Tracer = queryTracer{}
+
 	// Пул соединений.
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
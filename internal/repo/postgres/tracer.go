@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName — имя инструментирующей библиотеки для спанов репозитория и SQL.
+const tracerName = "github.com/Gunvolt24/wb_l0/internal/repo/postgres"
+
+// Атрибуты спанов репозитория.
+const (
+	attrRows       = attribute.Key("db.rows_affected") // строк вернул/затронул запрос (из CommandTag)
+	attrOrderUID   = attribute.Key("order.uid")
+	attrCustomerID = attribute.Key("order.customer_id")
+	attrItems      = attribute.Key("order.items")
+	attrFound      = attribute.Key("order.found")
+	attrOrders     = attribute.Key("orders.count")     // заказов в результате
+	attrUIDs       = attribute.Key("orders.requested") // запрошено UID
+	attrBatchSize  = attribute.Key("orders.batch_size")
+	attrLimit      = attribute.Key("query.limit")
+	attrOffset     = attribute.Key("query.offset")
+	attrCursor     = attribute.Key("query.cursor") // страница продолжает курсор (keyset)
+)
+
+// queryTracer — хуки pgx: client-спан и замер времени (db_query_duration_seconds) на каждый SQL-запрос и COPY.
+type queryTracer struct{}
+
+// queryStartKey — ключ контекста: начало запроса и его операция (для гистограммы в TraceQueryEnd).
+type queryStartKey struct{}
+
+type queryStart struct {
+	at        time.Time
+	operation string
+}
+
+var (
+	_ pgx.QueryTracer    = queryTracer{}
+	_ pgx.CopyFromTracer = queryTracer{}
+)
+
+// TraceQueryStart — открывает спан запроса (имя — «<операция> <база>»).
+func (queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	statement := compactSQL(data.SQL)
+	operation := sqlOperation(statement)
+	database := conn.Config().Database
+
+	ctx, _ = otel.Tracer(tracerName).Start(ctx, operation+" "+database,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBName(database),
+			semconv.DBOperation(operation),
+			semconv.DBStatement(statement),
+		),
+	)
+	return context.WithValue(ctx, queryStartKey{}, queryStart{at: time.Now(), operation: operation})
+}
+
+// TraceQueryEnd — закрывает спан запроса; для Query вызывается при закрытии rows.
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	observeQuery(ctx, data.Err)
+	endQuerySpan(trace.SpanFromContext(ctx), data.CommandTag.RowsAffected(), data.Err)
+}
+
+// TraceCopyFromStart — открывает спан COPY.
+func (queryTracer) TraceCopyFromStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	table := data.TableName.Sanitize()
+	database := conn.Config().Database
+
+	ctx, _ = otel.Tracer(tracerName).Start(ctx, "COPY "+database,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBName(database),
+			semconv.DBOperation("COPY"),
+			semconv.DBSQLTable(strings.Trim(table, `"`)),
+		),
+	)
+	return context.WithValue(ctx, queryStartKey{}, queryStart{at: time.Now(), operation: "COPY"})
+}
+
+// TraceCopyFromEnd — закрывает спан COPY.
+func (queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	observeQuery(ctx, data.Err)
+	endQuerySpan(trace.SpanFromContext(ctx), data.CommandTag.RowsAffected(), data.Err)
+}
+
+// observeQuery — записать время запроса, начатого в TraceQueryStart/TraceCopyFromStart.
+func observeQuery(ctx context.Context, err error) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	metrics.DBQueryDuration.WithLabelValues(start.operation, status).Observe(time.Since(start.at).Seconds())
+}
+
+func endQuerySpan(span trace.Span, rows int64, err error) {
+	span.SetAttributes(attrRows.Int64(rows))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// compactSQL — схлопывает пробелы и переводы строк в тексте запроса.
+func compactSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// sqlOperation — первое ключевое слово запроса (SELECT, INSERT, ...).
+func sqlOperation(statement string) string {
+	if i := strings.IndexByte(statement, ' '); i > 0 {
+		statement = statement[:i]
+	}
+	return strings.ToUpper(statement)
+}
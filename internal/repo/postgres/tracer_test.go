@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCompactSQLAndOperation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		sql       string
+		statement string
+		operation string
+	}{
+		{"\n\t\tSELECT order_uid\n\t\tFROM orders WHERE order_uid = $1\n\t", "SELECT order_uid FROM orders WHERE order_uid = $1", "SELECT"},
+		{"insert into customers (id) values ($1)", "insert into customers (id) values ($1)", "INSERT"},
+		{"begin", "begin", "BEGIN"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		statement := compactSQL(tt.sql)
+		if statement != tt.statement {
+			t.Fatalf("compactSQL(%q) = %q, want %q", tt.sql, statement, tt.statement)
+		}
+		if op := sqlOperation(statement); op != tt.operation {
+			t.Fatalf("sqlOperation(%q) = %q, want %q", statement, op, tt.operation)
+		}
+	}
+}
+
+func TestObserveQuery(t *testing.T) {
+	series := testutil.CollectAndCount(metrics.DBQueryDuration)
+
+	// Без начала запроса в контексте — ничего не пишем.
+	observeQuery(context.Background(), nil)
+	if got := testutil.CollectAndCount(metrics.DBQueryDuration); got != series {
+		t.Fatalf("query without start must not be observed, series %d -> %d", series, got)
+	}
+
+	ctx := context.WithValue(context.Background(), queryStartKey{}, queryStart{at: time.Now(), operation: "UPDATE"})
+	observeQuery(ctx, errors.New("deadlock"))
+	if got := testutil.CollectAndCount(metrics.DBQueryDuration); got != series+1 {
+		t.Fatalf("failed query must be observed with status=error, series %d -> %d", series, got)
+	}
+}
@@ -0,0 +1,161 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/pkg/httpx"
+	"github.com/gin-gonic/gin"
+)
+
+// Ограничения админских операций.
+const (
+	adminDefaultKeys   = 100
+	adminMaxKeys       = 10_000
+	adminMaxWarmUpN    = 100_000
+	adminWarmUpTimeout = 5 * time.Minute // прогрев идёт в фоне, дольше запроса
+)
+
+// AdminHandler — обработчик /admin/cache: состояние кэша, ключи, удаление, очистка и прогрев.
+type AdminHandler struct {
+	cache      ports.OrderCache
+	warmer     ports.CacheWarmer
+	log        ports.Logger
+	token      string        // Bearer-токен доступа
+	reqTimeout time.Duration // таймаут на обработку запроса
+
+	warming atomic.Bool // идёт фоновый прогрев
+}
+
+// NewAdminHandler — DI-конструктор. Пустой token закрывает доступ ко всем админским маршрутам.
+// Если reqTimeout <= 0, ставим дефолт 3s.
+func NewAdminHandler(cache ports.OrderCache, warmer ports.CacheWarmer, log ports.Logger, token string, reqTimeout time.Duration) *AdminHandler {
+	if reqTimeout <= 0 {
+		reqTimeout = 3 * time.Second
+	}
+	return &AdminHandler{cache: cache, warmer: warmer, log: log, token: token, reqTimeout: reqTimeout}
+}
+
+// register — маршруты группы /admin/cache (за Bearer-авторизацией).
+func (a *AdminHandler) register(r *gin.Engine) {
+	g := r.Group("/admin/cache", httpx.RequestLogger(a.log), httpx.BearerAuth(a.token))
+	g.GET("/stats", a.stats)
+	g.GET("/keys", a.keys)
+	g.DELETE("/keys/:id", a.evict)
+	g.DELETE("", a.purge)
+	g.POST("/warmup", a.warmUp)
+}
+
+// cacheStatsResponse — ответ GET /admin/cache/stats.
+type cacheStatsResponse struct {
+	Entries          int     `json:"entries"`
+	Bytes            int64   `json:"bytes"`
+	Hits             uint64  `json:"hits"`
+	Misses           uint64  `json:"misses"`
+	HitRatio         float64 `json:"hit_ratio"`
+	OldestAgeSeconds float64 `json:"oldest_entry_age_seconds"`
+	WarmUpRunning    bool    `json:"warmup_running"`
+}
+
+// stats — GET /admin/cache/stats: размер, оценка памяти, доля попаданий и возраст самой давней записи.
+func (a *AdminHandler) stats(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), a.reqTimeout)
+	defer cancel()
+
+	st, err := a.cache.Stats(ctx)
+	if err != nil {
+		a.log.Errorf(ctx, "cache stats failed err=%v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, cacheStatsResponse{
+		Entries:          st.Entries,
+		Bytes:            st.Bytes,
+		Hits:             st.Hits,
+		Misses:           st.Misses,
+		HitRatio:         st.HitRatio(),
+		OldestAgeSeconds: st.OldestAge.Seconds(),
+		WarmUpRunning:    a.warming.Load(),
+	})
+}
+
+// keys — GET /admin/cache/keys?limit=: UID в порядке политики вытеснения (для LRU — от недавно использованных).
+func (a *AdminHandler) keys(c *gin.Context) {
+	limit, _ := httpx.ParseLimitOffset(c, adminDefaultKeys, adminMaxKeys)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), a.reqTimeout)
+	defer cancel()
+
+	keys, err := a.cache.Keys(ctx, limit)
+	if err != nil {
+		a.log.Errorf(ctx, "cache keys failed err=%v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// evict — DELETE /admin/cache/keys/:id: удалить запись UID (отсутствие — тоже 204).
+func (a *AdminHandler) evict(c *gin.Context) {
+	id := c.Param("id")
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), a.reqTimeout)
+	defer cancel()
+
+	if err := a.cache.Delete(ctx, id); err != nil {
+		a.log.Errorf(ctx, "cache delete failed id=%s err=%v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	a.log.Infof(ctx, "admin: cache entry evicted id=%s", id)
+	c.Status(http.StatusNoContent)
+}
+
+// purge — DELETE /admin/cache: очистить кэш целиком.
+func (a *AdminHandler) purge(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), a.reqTimeout)
+	defer cancel()
+
+	if err := a.cache.Purge(ctx); err != nil {
+		a.log.Errorf(ctx, "cache purge failed err=%v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	a.log.Infof(ctx, "admin: cache purged")
+	c.Status(http.StatusNoContent)
+}
+
+// warmUp — POST /admin/cache/warmup?n=: запустить WarmUpCache(n) в фоне и ответить 202.
+// 400 — n не положительное число (максимум adminMaxWarmUpN); 409 — прогрев уже идёт.
+func (a *AdminHandler) warmUp(c *gin.Context) {
+	n, err := strconv.Atoi(c.Query("n"))
+	if err != nil || n <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "n must be a positive integer"})
+		return
+	}
+	n = min(n, adminMaxWarmUpN)
+
+	if !a.warming.CompareAndSwap(false, true) {
+		c.JSON(http.StatusConflict, gin.H{"error": "warm-up already running"})
+		return
+	}
+
+	// Запрос завершится раньше прогрева: контекст без отмены, но со своим таймаутом (request_id и трейс сохраняются).
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), adminWarmUpTimeout)
+	go func() {
+		defer cancel()
+		defer a.warming.Store(false)
+		if err := a.warmer.WarmUpCache(ctx, n); err != nil {
+			a.log.Warnf(ctx, "admin: cache warm-up failed n=%d err=%v", n, err)
+		}
+	}()
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "started", "n": n})
+}
@@ -0,0 +1,137 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/internal/ports/mocks"
+	rest "github.com/Gunvolt24/wb_l0/internal/transport/http"
+	"github.com/Gunvolt24/wb_l0/pkg/health"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+)
+
+const adminToken = "admin-secret"
+
+// newAdminRouter — роутер с админкой поверх моков кэша и прогрева.
+func newAdminRouter(t *testing.T) (*gin.Engine, *mocks.MockOrderCache, *mocks.MockCacheWarmer) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	cache := mocks.NewMockOrderCache(ctrl)
+	warmer := mocks.NewMockCacheWarmer(ctrl)
+
+	admin := rest.NewAdminHandler(cache, warmer, noopLogger{}, adminToken, 0)
+	return rest.NewOpsRouter(health.NewChecker(0), admin), cache, warmer
+}
+
+// adminRequest — запрос к админке с токеном.
+func adminRequest(r http.Handler, method, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, http.NoBody)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	r, _, _ := newAdminRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/cache/stats", http.NoBody)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 without token, got %d", w.Code)
+	}
+}
+
+func TestAdmin_DisabledWithoutHandler(t *testing.T) {
+	r := rest.NewOpsRouter(health.NewChecker(0), nil)
+
+	if w := adminRequest(r, http.MethodGet, "/admin/cache/stats"); w.Code != http.StatusNotFound {
+		t.Fatalf("want 404 when admin is disabled, got %d", w.Code)
+	}
+}
+
+func TestAdmin_NotOnPublicRouter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	h := rest.NewHandler(mocks.NewMockOrderReadService(ctrl), noopLogger{}, 0)
+	r := rest.NewRouter(h, "", "test")
+
+	if w := adminRequest(r, http.MethodGet, "/admin/cache/stats"); w.Code != http.StatusNotFound {
+		t.Fatalf("admin must be served only on the ops port, got %d", w.Code)
+	}
+}
+
+func TestAdmin_Stats(t *testing.T) {
+	r, cache, _ := newAdminRouter(t)
+	cache.EXPECT().Stats(gomock.Any()).Return(ports.CacheStats{
+		Entries: 10, Bytes: 4096, Hits: 3, Misses: 1, OldestAge: 90 * time.Second,
+	}, nil)
+
+	w := adminRequest(r, http.MethodGet, "/admin/cache/stats")
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	var got map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if got["entries"] != 10.0 || got["bytes"] != 4096.0 || got["hit_ratio"] != 0.75 || got["oldest_entry_age_seconds"] != 90.0 {
+		t.Fatalf("unexpected stats: %v", got)
+	}
+}
+
+func TestAdmin_KeysEvictPurge(t *testing.T) {
+	r, cache, _ := newAdminRouter(t)
+	gomock.InOrder(
+		cache.EXPECT().Keys(gomock.Any(), 2).Return([]string{"b", "a"}, nil),
+		cache.EXPECT().Delete(gomock.Any(), "a").Return(nil),
+		cache.EXPECT().Purge(gomock.Any()).Return(nil),
+	)
+
+	w := adminRequest(r, http.MethodGet, "/admin/cache/keys?limit=2")
+	if w.Code != http.StatusOK || w.Body.String() != `{"keys":["b","a"]}` {
+		t.Fatalf("keys: code=%d body=%s", w.Code, w.Body.String())
+	}
+	if w = adminRequest(r, http.MethodDelete, "/admin/cache/keys/a"); w.Code != http.StatusNoContent {
+		t.Fatalf("evict: want 204, got %d", w.Code)
+	}
+	if w = adminRequest(r, http.MethodDelete, "/admin/cache"); w.Code != http.StatusNoContent {
+		t.Fatalf("purge: want 204, got %d", w.Code)
+	}
+}
+
+func TestAdmin_WarmUpRunsInBackgroundOnce(t *testing.T) {
+	r, _, warmer := newAdminRouter(t)
+
+	started, release := make(chan int, 1), make(chan struct{})
+	warmer.EXPECT().WarmUpCache(gomock.Any(), 500).DoAndReturn(func(ctx context.Context, n int) error {
+		started <- n
+		<-release
+		return ctx.Err()
+	})
+
+	if w := adminRequest(r, http.MethodPost, "/admin/cache/warmup?n=abc"); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid n: want 400, got %d", w.Code)
+	}
+	if w := adminRequest(r, http.MethodPost, "/admin/cache/warmup?n=500"); w.Code != http.StatusAccepted {
+		t.Fatalf("want 202, got %d, body=%s", w.Code, w.Body.String())
+	}
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("warm-up was not started")
+	}
+
+	// Второй запуск, пока первый идёт, отклоняется; контекст прогрева пережил завершение запроса.
+	if w := adminRequest(r, http.MethodPost, "/admin/cache/warmup?n=500"); w.Code != http.StatusConflict {
+		t.Fatalf("concurrent warm-up: want 409, got %d", w.Code)
+	}
+	close(release)
+}
@@ -113,6 +113,15 @@ func (s svcOne) GetOrder(context.Context, string) (*domain.Order, error) { retur
 func (s svcOne) OrdersByCustomer(context.Context, string, int, int) ([]*domain.Order, error) {
 	return []*domain.Order{s.o}, nil
 }
+func (s svcOne) OrdersByCustomerPage(context.Context, string, string, int) (*domain.OrderPage, error) {
+	return &domain.OrderPage{Orders: []*domain.Order{s.o}}, nil
+}
+func (s svcOne) SearchItems(context.Context, string, int, int) ([]domain.ItemSearchHit, error) {
+	return nil, nil
+}
+func (s svcOne) SearchOrders(context.Context, domain.OrderFilter, string, int) (*domain.OrderPage, error) {
+	return &domain.OrderPage{Orders: []*domain.Order{s.o}}, nil
+}
 
 // для списка: заранее подготовленная выборка N элементов (без аллокаций на каждом вызове)
 type svcList struct{ list []*domain.Order }
@@ -121,6 +130,15 @@ func (s svcList) GetOrder(context.Context, string) (*domain.Order, error) { retu
 func (s svcList) OrdersByCustomer(context.Context, string, int, int) ([]*domain.Order, error) {
 	return s.list, nil
 }
+func (s svcList) OrdersByCustomerPage(context.Context, string, string, int) (*domain.OrderPage, error) {
+	return &domain.OrderPage{Orders: s.list, NextCursor: domain.CursorAfter(s.list[len(s.list)-1]).Encode()}, nil
+}
+func (s svcList) SearchItems(context.Context, string, int, int) ([]domain.ItemSearchHit, error) {
+	return nil, nil
+}
+func (s svcList) SearchOrders(context.Context, domain.OrderFilter, string, int) (*domain.OrderPage, error) {
+	return &domain.OrderPage{Orders: s.list}, nil
+}
 
 // --- функции-помощники ---
 
@@ -20,6 +20,7 @@ import (
 	"github.com/Gunvolt24/wb_l0/internal/testutil"
 	rest "github.com/Gunvolt24/wb_l0/internal/transport/http"
 	"github.com/Gunvolt24/wb_l0/internal/usecase"
+	"github.com/Gunvolt24/wb_l0/pkg/health"
 	"github.com/Gunvolt24/wb_l0/pkg/logger"
 	"github.com/Gunvolt24/wb_l0/pkg/validate"
 )
@@ -39,7 +40,7 @@ func TestHTTP_GetOrder_TC(t *testing.T) {
 	defer func() { _ = cleanup() }()
 
 	repo := pgrepo.NewOrderRepository(pg.Pool)
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 
 	// seed: генерим уникальный заказ
 	ord := testutil.MakeOrder()
@@ -75,7 +76,7 @@ func TestHTTP_GetOrder_NotFound_TC(t *testing.T) {
 	defer func() { _ = cleanup() }()
 
 	repo := pgrepo.NewOrderRepository(pg.Pool)
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 
 	h := rest.NewHandler(svc, logg, 2*time.Second)
 	r := rest.NewRouter(h, "", "")
@@ -131,7 +132,7 @@ func TestHTTP_ListOrdersByCustomer_Pagination_TC(t *testing.T) {
 	defer func() { _ = cleanup() }()
 
 	repo := pgrepo.NewOrderRepository(pg.Pool)
-	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, time.Minute), logg, validate.NewOrderValidator())
+	svc := usecase.NewOrderService(repo, cachemem.NewLRUCacheTTL(100, 0, time.Minute, 0), logg, validate.NewOrderValidator())
 
 	// seed: 3 заказа одного клиента + 1 другого
 	const cust = "cust-pagination"
@@ -163,7 +164,7 @@ func TestHTTP_ListOrdersByCustomer_Pagination_TC(t *testing.T) {
 	}
 }
 
-// 4) /ping, /metrics, 404 на неизвестный маршрут
+// 4) /ping, /metrics (служебный порт), 404 на неизвестный маршрут
 func TestHTTP_Health_Metrics_And_404_TC(t *testing.T) {
 	logg, cleanup, err := logger.NewZapLogger(false)
 	require.NoError(t, err)
@@ -182,8 +183,10 @@ func TestHTTP_Health_Metrics_And_404_TC(t *testing.T) {
 	body := readAll(t, resp.Body)
 	require.Equal(t, "pong", string(body))
 
-	// /metrics
-	respM, err := http.Get(ts.URL + "/metrics")
+	// /metrics — на служебном роутере
+	ops := httptest.NewServer(rest.NewOpsRouter(health.NewChecker(0), nil))
+	defer ops.Close()
+	respM, err := http.Get(ops.URL + "/metrics")
 	require.NoError(t, err)
 	defer respM.Body.Close()
 	require.Equal(t, http.StatusOK, respM.StatusCode)
@@ -235,6 +238,15 @@ func (noOpService) GetOrder(context.Context, string) (*domain.Order, error) { re
 func (noOpService) OrdersByCustomer(context.Context, string, int, int) ([]*domain.Order, error) {
 	return nil, nil
 }
+func (noOpService) OrdersByCustomerPage(context.Context, string, string, int) (*domain.OrderPage, error) {
+	return &domain.OrderPage{}, nil
+}
+func (noOpService) SearchItems(context.Context, string, int, int) ([]domain.ItemSearchHit, error) {
+	return nil, nil
+}
+func (noOpService) SearchOrders(context.Context, domain.OrderFilter, string, int) (*domain.OrderPage, error) {
+	return &domain.OrderPage{}, nil
+}
 
 // slowService — всегда ждёт ctx.Done() и возвращает ошибку контекста (для проверки таймаута 500).
 type slowService struct{}
@@ -247,6 +259,18 @@ func (slowService) OrdersByCustomer(ctx context.Context, _ string, _, _ int) ([]
 	<-ctx.Done()
 	return nil, ctx.Err()
 }
+func (slowService) OrdersByCustomerPage(ctx context.Context, _, _ string, _ int) (*domain.OrderPage, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+func (slowService) SearchItems(ctx context.Context, _ string, _, _ int) ([]domain.ItemSearchHit, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+func (slowService) SearchOrders(ctx context.Context, _ domain.OrderFilter, _ string, _ int) (*domain.OrderPage, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
 
 // readAll — просто прочитать тело.
 func readAll(t *testing.T, r io.Reader) []byte {
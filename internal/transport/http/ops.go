@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/Gunvolt24/wb_l0/pkg/health"
+	"github.com/Gunvolt24/wb_l0/pkg/httpx"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewOpsRouter — роутер служебного порта (Metrics.Addr): /metrics, пробы /healthz и /readyz,
+// профилирование /debug/pprof/ и админка кэша. Порт не должен быть доступен извне кластера.
+// admin == nil — группа /admin/cache не регистрируется.
+func NewOpsRouter(checker *health.Checker, admin *AdminHandler) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(httpx.RequestIDMiddleware())
+
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	registerProbes(r, checker)
+	r.Any("/debug/pprof/*name", pprofHandler)
+
+	if admin != nil {
+		admin.register(r)
+	}
+
+	r.NoRoute(func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "route not found"})
+	})
+	return r
+}
+
+// pprofHandler — /debug/pprof/*: индекс, именованные профили (heap, goroutine, ...) и отдельные
+// обработчики cmdline, profile, symbol, trace из net/http/pprof.
+func pprofHandler(c *gin.Context) {
+	switch strings.TrimPrefix(c.Param("name"), "/") {
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Index(c.Writer, c.Request) // разбирает имя профиля из пути /debug/pprof/<name>
+	}
+}
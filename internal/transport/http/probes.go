@@ -0,0 +1,25 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/Gunvolt24/wb_l0/pkg/health"
+	"github.com/gin-gonic/gin"
+)
+
+// registerProbes — пробы оркестратора:
+// /healthz (liveness) — процесс жив и обслуживает HTTP, зависимости не проверяются;
+// /readyz (readiness) — отчёт checker по каждой проверке: 200, если все прошли, иначе 503.
+func registerProbes(r *gin.Engine, checker *health.Checker) {
+	r.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+	})
+	r.GET("/readyz", func(c *gin.Context) {
+		report := checker.Ready(c.Request.Context())
+		status := http.StatusOK
+		if !report.OK() {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+}
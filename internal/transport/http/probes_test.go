@@ -0,0 +1,83 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rest "github.com/Gunvolt24/wb_l0/internal/transport/http"
+	"github.com/Gunvolt24/wb_l0/pkg/health"
+	"github.com/gin-gonic/gin"
+)
+
+// newProbeRouter — служебный роутер с пробами поверх checker.
+func newProbeRouter(t *testing.T, checker *health.Checker) *gin.Engine {
+	t.Helper()
+	return rest.NewOpsRouter(checker, nil)
+}
+
+func getProbe(r http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+	return w
+}
+
+func TestProbes_ReadyAndLive(t *testing.T) {
+	checker := health.NewChecker(0)
+	checker.Add("postgres", func(context.Context) error { return nil })
+	r := newProbeRouter(t, checker)
+
+	if w := getProbe(r, "/healthz"); w.Code != http.StatusOK {
+		t.Fatalf("healthz: want 200, got %d", w.Code)
+	}
+	w := getProbe(r, "/readyz")
+	if w.Code != http.StatusOK {
+		t.Fatalf("readyz: want 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	var report health.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if report.Status != health.StatusOK || report.Checks["postgres"].Status != health.StatusOK {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestProbes_NotReady(t *testing.T) {
+	checker := health.NewChecker(0)
+	checker.Add("postgres", func(context.Context) error { return errors.New("connection refused") })
+	r := newProbeRouter(t, checker)
+
+	w := getProbe(r, "/readyz")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz: want 503, got %d", w.Code)
+	}
+	var report health.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if report.Checks["postgres"].Error != "connection refused" {
+		t.Fatalf("report must carry check error: %+v", report)
+	}
+
+	// liveness не зависит от зависимостей
+	if w := getProbe(r, "/healthz"); w.Code != http.StatusOK {
+		t.Fatalf("healthz: want 200 even when not ready, got %d", w.Code)
+	}
+}
+
+func TestProbes_DrainingFlipsReadiness(t *testing.T) {
+	checker := health.NewChecker(0)
+	r := newProbeRouter(t, checker)
+
+	if w := getProbe(r, "/readyz"); w.Code != http.StatusOK {
+		t.Fatalf("readyz before shutdown: want 200, got %d", w.Code)
+	}
+	checker.SetDraining()
+	if w := getProbe(r, "/readyz"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz while draining: want 503, got %d", w.Code)
+	}
+}
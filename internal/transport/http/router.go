@@ -2,14 +2,16 @@ package rest
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/internal/ports"
 	"github.com/Gunvolt24/wb_l0/pkg/httpx"
 	"github.com/gin-gonic/gin"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
@@ -28,16 +30,17 @@ func NewHandler(service ports.OrderReadService, log ports.Logger, reqTimeout tim
 	return &Handler{service: service, log: log, reqTimeout: reqTimeout}
 }
 
-// NewRouter — сборка роутера gin: middleware, эндпоинты API и статика.
-// Порядок: Recovery → otelgin → RequestID → RequestLogger (trace_id попадёт в логи).
+// NewRouter — сборка роутера gin публичного порта: middleware, эндпоинты API и статика.
+// Порядок: RequestMetrics → Recovery → otelgin → RequestID → RequestLogger (trace_id попадёт в логи).
+// Метрики, пробы, pprof и админка — на служебном порту (см. NewOpsRouter).
 func NewRouter(h *Handler, staticDir, otelServiceName string) *gin.Engine {
 	r := gin.New()                     // Создаём роутер
+	r.Use(httpx.RequestMetrics())      // RED-метрики (до Recovery, чтобы паника попала в 5xx)
 	r.Use(gin.Recovery())              // Подключаем middleware Recovery
 	r.Use(httpx.RequestIDMiddleware()) // Кладём request_id в контекст и заголовок
 
-	// healthcheck/metrics
+	// healthcheck
 	r.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
-	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// API (трейсинг только на этой группе)
 	api := r.Group("/",
@@ -46,6 +49,8 @@ func NewRouter(h *Handler, staticDir, otelServiceName string) *gin.Engine {
 	)
 	api.GET("/order/:id", h.getOrderByID)
 	api.GET("/customer/:id/orders", h.listOrdersByCustomer)
+	api.GET("/orders/search", h.searchOrders)
+	api.GET("/items/search", h.searchItems)
 
 	// Static (простая веб-страница)
 	if staticDir != "" {
@@ -58,7 +63,7 @@ func NewRouter(h *Handler, staticDir, otelServiceName string) *gin.Engine {
 		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "route not found"})
 	})
 
-	// 405 для неподдерживаемых методов (разрешаем только GET)
+	// 405 для неподдерживаемых методов (публичный API — только GET)
 	r.HandleMethodNotAllowed = true
 	r.NoMethod(func(c *gin.Context) {
 		c.Header("Allow", "GET")
@@ -94,8 +99,10 @@ func (h *Handler) getOrderByID(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
-// listOrdersByCustomer — GET /customer/:id/orders?limit=&offset=.
-// Возвращает 200 с массивом; 400 — при пустом id; 500 — при ошибке. Пагинация через limit/offset
+// listOrdersByCustomer — GET /customer/:id/orders?limit=&offset= | ?limit=&cursor=.
+// Возвращает 200; 400 — при пустом id или битом курсоре; 500 — при ошибке.
+// Без параметра cursor — прежний режим limit/offset с массивом в ответе (обратная совместимость).
+// С параметром cursor (пустой — первая страница) — keyset-пагинация и конверт {orders, next_cursor}.
 func (h *Handler) listOrdersByCustomer(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -113,6 +120,11 @@ func (h *Handler) listOrdersByCustomer(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.reqTimeout)
 	defer cancel()
 
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		h.listOrdersByCustomerPage(ctx, c, id, cursor, limit)
+		return
+	}
+
 	orders, err := h.service.OrdersByCustomer(ctx, id, limit, offset)
 	if err != nil {
 		h.log.Errorf(ctx, "OrdersByCustomer failed id=%s err=%v", id, err)
@@ -122,3 +134,122 @@ func (h *Handler) listOrdersByCustomer(c *gin.Context) {
 
 	c.JSON(http.StatusOK, orders)
 }
+
+// listOrdersByCustomerPage — keyset-режим listOrdersByCustomer.
+func (h *Handler) listOrdersByCustomerPage(ctx context.Context, c *gin.Context, id, cursor string, limit int) {
+	page, err := h.service.OrdersByCustomerPage(ctx, id, cursor, limit)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+		return
+	}
+	if err != nil {
+		h.log.Errorf(ctx, "OrdersByCustomerPage failed id=%s err=%v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// searchOrders — GET /orders/search?track_number=&rid=&email=&phone=&delivery_service=&brand=&date_from=&date_to=&limit=&cursor=.
+// Критерии объединяются через AND; нужен хотя бы один. Даты — RFC3339 или YYYY-MM-DD
+// (date_to в формате даты включает весь день). Ответ — конверт {orders, next_cursor}, как в keyset-режиме
+// /customer/:id/orders. 400 — нет критериев, битая дата или курсор; 500 — при ошибке.
+func (h *Handler) searchOrders(c *gin.Context) {
+	filter := domain.OrderFilter{
+		TrackNumber:     strings.TrimSpace(c.Query("track_number")),
+		RID:             strings.TrimSpace(c.Query("rid")),
+		Email:           strings.TrimSpace(c.Query("email")),
+		Phone:           strings.TrimSpace(c.Query("phone")),
+		DeliveryService: strings.TrimSpace(c.Query("delivery_service")),
+		Brand:           strings.TrimSpace(c.Query("brand")),
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseDateParam(c.Query("date_from"), false); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_from"})
+		return
+	}
+	if filter.CreatedTo, err = parseDateParam(c.Query("date_to"), true); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date_to"})
+		return
+	}
+
+	const (
+		defaultLimit = 20
+		maxLimit     = 100
+	)
+	limit, _ := httpx.ParseLimitOffset(c, defaultLimit, maxLimit)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.reqTimeout)
+	defer cancel()
+
+	page, err := h.service.SearchOrders(ctx, filter, c.Query("cursor"), limit)
+	switch {
+	case errors.Is(err, domain.ErrInvalidFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domain.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+		return
+	case err != nil:
+		h.log.Errorf(ctx, "SearchOrders failed filter=%+v err=%v", filter, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// searchItems — GET /items/search?q=&limit=&offset=.
+// Полнотекстовый поиск по названиям и брендам позиций: массив {order_uid, rank, snippets}
+// по убыванию ранга; в snippets совпадения обёрнуты в <mark></mark> (остальной текст не экранирован).
+// 400 — пустой запрос; 500 — при ошибке.
+func (h *Handler) searchItems(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty query"})
+		return
+	}
+
+	const (
+		defaultLimit = 20
+		maxLimit     = 100
+	)
+	limit, offset := httpx.ParseLimitOffset(c, defaultLimit, maxLimit)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.reqTimeout)
+	defer cancel()
+
+	hits, err := h.service.SearchItems(ctx, q, limit, offset)
+	if errors.Is(err, domain.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "empty query"})
+		return
+	}
+	if err != nil {
+		h.log.Errorf(ctx, "SearchItems failed q=%q err=%v", q, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hits)
+}
+
+// parseDateParam — RFC3339 или YYYY-MM-DD (UTC); пустая строка — нулевое время.
+// Для верхней границы (endOfDay) дата без времени сдвигается на сутки: date_to=2025-08-01 включает весь день.
+func parseDateParam(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -6,11 +6,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/internal/ports/mocks"
 	rest "github.com/Gunvolt24/wb_l0/internal/transport/http"
+	"github.com/Gunvolt24/wb_l0/pkg/health"
 	"github.com/golang/mock/gomock"
 )
 
@@ -165,6 +168,193 @@ func TestListOrdersByCustomer_ServiceError(t *testing.T) {
 	}
 }
 
+func TestListOrdersByCustomer_Cursor_Envelope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	svc := mocks.NewMockOrderReadService(ctrl)
+	log := noopLogger{}
+
+	// Пустой cursor — первая страница в keyset-режиме; offset игнорируется
+	ret := &domain.OrderPage{Orders: []*domain.Order{{OrderUID: "a"}}, NextCursor: "next"}
+	svc.EXPECT().OrdersByCustomerPage(gomock.Any(), "cust-1", "", 5).Return(ret, nil)
+
+	h := rest.NewHandler(svc, log, 0)
+	r := rest.NewRouter(h, "", "test")
+
+	req := httptest.NewRequest(http.MethodGet, "/customer/cust-1/orders?limit=5&offset=10&cursor=", http.NoBody)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	var got domain.OrderPage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(got.Orders) != 1 || got.Orders[0].OrderUID != "a" || got.NextCursor != "next" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestListOrdersByCustomer_Cursor_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	svc := mocks.NewMockOrderReadService(ctrl)
+	log := noopLogger{}
+
+	svc.EXPECT().OrdersByCustomerPage(gomock.Any(), "cust-1", "garbage", 20).Return(nil, domain.ErrInvalidCursor)
+
+	h := rest.NewHandler(svc, log, 0)
+	r := rest.NewRouter(h, "", "test")
+
+	req := httptest.NewRequest(http.MethodGet, "/customer/cust-1/orders?cursor=garbage", http.NoBody)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("want 400, got %d, body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestListOrdersByCustomer_Cursor_ServiceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	svc := mocks.NewMockOrderReadService(ctrl)
+	log := noopLogger{}
+
+	svc.EXPECT().OrdersByCustomerPage(gomock.Any(), "cust-err", "", 20).Return(nil, errors.New("service error"))
+
+	h := rest.NewHandler(svc, log, 0)
+	r := rest.NewRouter(h, "", "test")
+
+	req := httptest.NewRequest(http.MethodGet, "/customer/cust-err/orders?cursor=", http.NoBody)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("want 500, got %d, body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestSearchOrders_ParsesFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	svc := mocks.NewMockOrderReadService(ctrl)
+	log := noopLogger{}
+
+	want := domain.OrderFilter{
+		TrackNumber: "WBILM",
+		RID:         "rid-1",
+		Email:       "a@b.c",
+		Brand:       "Vivienne Sabo",
+		CreatedFrom: time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC),
+		CreatedTo:   time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC), // date_to включает весь день
+	}
+	ret := &domain.OrderPage{Orders: []*domain.Order{{OrderUID: "a"}}}
+	svc.EXPECT().SearchOrders(gomock.Any(), want, "", 5).Return(ret, nil)
+
+	h := rest.NewHandler(svc, log, 0)
+	r := rest.NewRouter(h, "", "test")
+
+	q := url.Values{}
+	q.Set("track_number", "WBILM")
+	q.Set("rid", "rid-1")
+	q.Set("email", " a@b.c ")
+	q.Set("brand", "Vivienne Sabo")
+	q.Set("date_from", "2025-08-01T10:00:00Z")
+	q.Set("date_to", "2025-08-02")
+	q.Set("limit", "5")
+	req := httptest.NewRequest(http.MethodGet, "/orders/search?"+q.Encode(), http.NoBody)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	var got domain.OrderPage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(got.Orders) != 1 || got.Orders[0].OrderUID != "a" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestSearchOrders_BadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	svc := mocks.NewMockOrderReadService(ctrl)
+	log := noopLogger{}
+
+	// пустой фильтр отклоняет сервис; битую дату — сам хендлер (в сервис не ходим)
+	svc.EXPECT().SearchOrders(gomock.Any(), domain.OrderFilter{}, "", 20).Return(nil, domain.ErrInvalidFilter)
+
+	h := rest.NewHandler(svc, log, 0)
+	r := rest.NewRouter(h, "", "test")
+
+	for _, target := range []string{"/orders/search", "/orders/search?date_from=yesterday"} {
+		req := httptest.NewRequest(http.MethodGet, target, http.NoBody)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: want 400, got %d, body=%s", target, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestSearchItems_OK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	svc := mocks.NewMockOrderReadService(ctrl)
+	log := noopLogger{}
+
+	ret := []domain.ItemSearchHit{{OrderUID: "a", Rank: 0.6, Snippets: []string{"<mark>Mascaras</mark> · Vivienne Sabo"}}}
+	svc.EXPECT().SearchItems(gomock.Any(), "mascar", 20, 0).Return(ret, nil)
+
+	h := rest.NewHandler(svc, log, 0)
+	r := rest.NewRouter(h, "", "test")
+
+	req := httptest.NewRequest(http.MethodGet, "/items/search?q=+mascar+", http.NoBody)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d, body=%s", w.Code, w.Body.String())
+	}
+	var got []domain.ItemSearchHit
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(got) != 1 || got[0].OrderUID != "a" || len(got[0].Snippets) != 1 {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestSearchItems_EmptyQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	svc := mocks.NewMockOrderReadService(ctrl)
+	log := noopLogger{}
+
+	// запрос из одних спецсимволов отсекает репозиторий
+	svc.EXPECT().SearchItems(gomock.Any(), "&|!", 20, 0).Return(nil, domain.ErrInvalidFilter)
+
+	h := rest.NewHandler(svc, log, 0)
+	r := rest.NewRouter(h, "", "test")
+
+	for _, target := range []string{"/items/search", "/items/search?q=%20", "/items/search?q=%26%7C%21"} {
+		req := httptest.NewRequest(http.MethodGet, target, http.NoBody)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: want 400, got %d, body=%s", target, w.Code, w.Body.String())
+		}
+	}
+}
+
 func TestNoRoute_404(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -222,24 +412,42 @@ func TestPing_200(t *testing.T) {
 	}
 }
 
-func TestMetrics_200(t *testing.T) {
+func TestMetrics_OnlyOnOpsRouter(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	svc := mocks.NewMockOrderReadService(ctrl)
 	log := noopLogger{}
 
 	h := rest.NewHandler(svc, log, 0)
-	r := rest.NewRouter(h, "", "test")
+	public := rest.NewRouter(h, "", "test")
+	ops := rest.NewOpsRouter(health.NewChecker(0), nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody)
 	w := httptest.NewRecorder()
-	r.ServeHTTP(w, req)
+	public.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("public /metrics: want 404, got %d", w.Code)
+	}
 
+	w = httptest.NewRecorder()
+	ops.ServeHTTP(w, req)
 	if w.Code != http.StatusOK {
-		t.Fatalf("want 200, got %d", w.Code)
+		t.Fatalf("ops /metrics: want 200, got %d", w.Code)
 	}
 	// Содержимое может меняться — достаточно проверить, что не пусто.
 	if w.Body.Len() == 0 {
 		t.Fatal("metrics body is empty")
 	}
 }
+
+func TestOpsRouter_Pprof(t *testing.T) {
+	ops := rest.NewOpsRouter(health.NewChecker(0), nil)
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/goroutine?debug=1", "/debug/pprof/cmdline"} {
+		w := httptest.NewRecorder()
+		ops.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Fatalf("%s: want 200 with body, got %d", path, w.Code)
+		}
+	}
+}
@@ -10,14 +10,41 @@ import (
 
 	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/Gunvolt24/wb_l0/pkg/telemetry"
+	"github.com/Gunvolt24/wb_l0/pkg/validate"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
+// tracerName — имя инструментирующей библиотеки для спанов usecase-слоя.
+const tracerName = "github.com/Gunvolt24/wb_l0/internal/usecase"
+
+// Атрибуты спанов usecase-слоя.
+const (
+	attrOrderUID    = attribute.Key("order.uid")
+	attrItems       = attribute.Key("order.items")
+	attrFound       = attribute.Key("order.found")
+	attrCacheHit    = attribute.Key("cache.hit")
+	attrCoalesced   = attribute.Key("cache.load_shared")
+	attrMessageSize = attribute.Key("messaging.message.payload_size_bytes")
+	attrBatchSize   = attribute.Key("orders.batch_size")
+	attrRejected    = attribute.Key("orders.rejected")
+	attrOrders      = attribute.Key("orders.count")
+	attrWarmUpN     = attribute.Key("cache.warmup_n")
+)
+
+// sharedLoadTimeout — верхняя граница общей загрузки заказа из БД при промахе кэша
+// (загрузка не привязана к отмене контекста конкретного запроса, см. loadShared).
+const sharedLoadTimeout = 10 * time.Second
+
 // OrderService — прикладная логика работы с заказами (без знаний о транспорте).
 type OrderService struct {
 	repo      ports.OrderRepository // прямой доступ к хранилищу
 	cache     ports.OrderCache      // прямой доступ к кэшу
 	log       ports.Logger          // прямой доступ к логгеру
 	validator ports.OrderValidator  // прямой доступ к валидатору
+	loads     singleflight.Group    // загрузки из БД при промахе кэша, не более одной на UID
 }
 
 // NewOrderService — DI-конструктор.
@@ -36,14 +63,65 @@ func NewOrderService(
 }
 
 // GetOrder — получить заказ по UID: сначала из кэша, при промахе — из БД с записью в кэш.
+// Отсутствие заказа тоже кэшируется (негативная запись), чтобы повторные запросы несуществующих UID не шли в БД.
 // Возвращает (*Order, nil) или (nil, nil), если записи нет.
-func (s *OrderService) GetOrder(ctx context.Context, orderUID string) (*domain.Order, error) {
+func (s *OrderService) GetOrder(ctx context.Context, orderUID string) (_ *domain.Order, err error) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderService.GetOrder", attrOrderUID.String(orderUID))
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	if order, found := s.cache.Get(ctx, orderUID); found {
+		span.SetAttributes(attrCacheHit.Bool(true), attrFound.Bool(order != nil))
+		if order == nil {
+			s.log.Infof(ctx, "negative cache hit for order=%s", orderUID)
+			return nil, nil
+		}
 		s.log.Infof(ctx, "cache hit for order=%s", orderUID)
 		return order, nil
 	}
+	span.SetAttributes(attrCacheHit.Bool(false))
 	s.log.Infof(ctx, "cache miss for order=%s", orderUID)
 
+	order, shared, err := s.loadShared(ctx, orderUID)
+	span.SetAttributes(attrCoalesced.Bool(shared))
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attrFound.Bool(order != nil))
+	return order, nil
+}
+
+// loadShared — загрузка заказа при промахе кэша с объединением конкурентных запросов:
+// на один UID одновременно выполняется не более одной загрузки, остальные ждут её результат
+// (shared=true). Загрузка не прерывается отменой контекста одного из ожидающих — каждый
+// перестаёт ждать по своему контексту, а сама загрузка ограничена sharedLoadTimeout.
+func (s *OrderService) loadShared(ctx context.Context, orderUID string) (_ *domain.Order, shared bool, _ error) {
+	leader := false
+	ch := s.loads.DoChan(orderUID, func() (any, error) {
+		leader = true
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedLoadTimeout)
+		defer cancel()
+		return s.loadOrder(loadCtx, orderUID)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case res := <-ch:
+		// leader записан внутри fn до отправки результата в канал — гонки нет.
+		if !leader {
+			metrics.CacheLoadsDeduplicated.Inc()
+		}
+		if res.Err != nil {
+			return nil, !leader, res.Err
+		}
+		order, _ := res.Val.(*domain.Order)
+		return order, !leader, nil
+	}
+}
+
+// loadOrder — чтение заказа из БД с записью в кэш. Возвращает (nil, nil), если записи нет
+// (в кэш тогда пишется негативная запись).
+func (s *OrderService) loadOrder(ctx context.Context, orderUID string) (*domain.Order, error) {
 	start := time.Now()
 	order, err := s.repo.GetByUID(ctx, orderUID)
 	if err != nil {
@@ -56,6 +134,8 @@ func (s *OrderService) GetOrder(ctx context.Context, orderUID string) (*domain.O
 		if setErr := s.cache.Set(ctx, order); setErr != nil {
 			s.log.Warnf(ctx, "cache.Set failed order_uid=%s err=%v", orderUID, setErr)
 		}
+	} else if setErr := s.cache.SetNegative(ctx, orderUID); setErr != nil {
+		s.log.Warnf(ctx, "cache.SetNegative failed order_uid=%s err=%v", orderUID, setErr)
 	}
 
 	s.log.Infof(ctx, "db fetch order_uid=%s took=%s", orderUID, time.Since(start))
@@ -71,52 +151,171 @@ func (s *OrderService) OrdersByCustomer(
 	return s.repo.ListByCustomer(ctx, customerID, limit, offset)
 }
 
+// OrdersByCustomerPage — keyset-пагинация заказов клиента по непрозрачному курсору.
+// Пустой cursor — первая страница; неразборчивый — domain.ErrInvalidCursor.
+func (s *OrderService) OrdersByCustomerPage(
+	ctx context.Context,
+	customerID, cursor string,
+	limit int,
+) (*domain.OrderPage, error) {
+	return keysetPage(cursor, limit, func(after *domain.OrderCursor, n int) ([]*domain.Order, error) {
+		return s.repo.ListByCustomerAfter(ctx, customerID, after, n)
+	})
+}
+
+// SearchOrders — поиск заказов по фильтру с той же keyset-пагинацией, что и OrdersByCustomerPage.
+// Пустой/противоречивый фильтр — domain.ErrInvalidFilter (в репозиторий не ходим).
+func (s *OrderService) SearchOrders(
+	ctx context.Context,
+	filter domain.OrderFilter,
+	cursor string,
+	limit int,
+) (*domain.OrderPage, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return keysetPage(cursor, limit, func(after *domain.OrderCursor, n int) ([]*domain.Order, error) {
+		return s.repo.Search(ctx, filter, after, n)
+	})
+}
+
+// SearchItems — полнотекстовый поиск заказов по позициям (проксирование в репозиторий).
+func (s *OrderService) SearchItems(ctx context.Context, query string, limit, offset int) ([]domain.ItemSearchHit, error) {
+	return s.repo.SearchItems(ctx, query, limit, offset)
+}
+
+// keysetPage — общая часть keyset-пагинации: разбор курсора и выборка limit+1 заказа.
+// Лишний заказ означает, что есть следующая страница, и в ответ не попадает.
+func keysetPage(
+	cursor string,
+	limit int,
+	fetch func(after *domain.OrderCursor, n int) ([]*domain.Order, error),
+) (*domain.OrderPage, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	after, err := domain.DecodeOrderCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := fetch(after, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		page.NextCursor = domain.CursorAfter(orders[limit-1]).Encode()
+	}
+	return page, nil
+}
+
 // SaveFromMessage — сохранить заказ, пришедший из Kafka (raw JSON).
 // Шаги:
 //  1. строгий парсинг JSON (DisallowUnknownFields) —> отлавливаем незадокументированные поля;
 //  2. доменная валидация (вернёт validate.ErrInvalidOrder при проблемах);
 //  3. транзакционное сохранение в БД (идемпотентные upsert);
 //  4. положить запись в кэш.
-func (s *OrderService) SaveFromMessage(ctx context.Context, raw []byte) error {
+func (s *OrderService) SaveFromMessage(ctx context.Context, raw []byte) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderService.SaveFromMessage", attrMessageSize.Int(len(raw)))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	order, err := s.decodeOrder(ctx, raw)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attrOrderUID.String(order.OrderUID), attrItems.Int(len(order.Items)))
+
+	// Сохранение в БД в транзакции.
+	if err := s.repo.Save(ctx, order); err != nil {
+		s.log.Errorf(ctx, "repo.Save failed order_uid=%s err=%v", order.OrderUID, err)
+		return fmt.Errorf("failed to save order: %w", err)
+	}
+
+	// Обновление кэша (заменяет и негативную запись, если заказ уже запрашивали до его прихода).
+	if err := s.cache.Set(ctx, order); err != nil {
+		s.log.Warnf(ctx, "cache.Set failed order_uid=%s err=%v", order.OrderUID, err)
+	}
+
+	s.log.Infof(ctx, "order saved uid=%s items=%d", order.OrderUID, len(order.Items))
+	return nil
+}
+
+// SaveBatchFromMessages — пакетный вариант SaveFromMessage: каждое сообщение парсится и валидируется
+// отдельно, валидные заказы сохраняются одной транзакцией.
+// results[i] — ошибка парсинга/валидации i-го сообщения (nil — заказ попал в транзакцию).
+// err != nil — транзакция не прошла: ни один из валидных заказов не сохранён.
+func (s *OrderService) SaveBatchFromMessages(ctx context.Context, raws [][]byte) (results []error, err error) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderService.SaveBatchFromMessages", attrBatchSize.Int(len(raws)))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	results = make([]error, len(raws))
+	orders := make([]*domain.Order, 0, len(raws))
+	for i, raw := range raws {
+		order, decodeErr := s.decodeOrder(ctx, raw)
+		if decodeErr != nil {
+			results[i] = decodeErr
+			continue
+		}
+		orders = append(orders, order)
+	}
+	span.SetAttributes(attrRejected.Int(len(raws) - len(orders)))
+	if len(orders) == 0 {
+		return results, nil
+	}
+
+	// Сохранение всех валидных заказов одной транзакцией.
+	if err := s.repo.SaveBatch(ctx, orders); err != nil {
+		s.log.Errorf(ctx, "repo.SaveBatch failed orders=%d err=%v", len(orders), err)
+		return results, fmt.Errorf("failed to save batch: %w", err)
+	}
+
+	// Обновление кэша.
+	for _, order := range orders {
+		if err := s.cache.Set(ctx, order); err != nil {
+			s.log.Warnf(ctx, "cache.Set failed order_uid=%s err=%v", order.OrderUID, err)
+		}
+	}
+
+	s.log.Infof(ctx, "batch saved orders=%d rejected=%d", len(orders), len(raws)-len(orders))
+	return results, nil
+}
+
+// decodeOrder — строгий парсинг JSON и доменная валидация заказа.
+// Битый JSON, лишние данные и провал валидации оборачивают validate.ErrInvalidOrder:
+// повтор их не исправит, поэтому консьюмер сразу отправляет такое сообщение в DLQ.
+func (s *OrderService) decodeOrder(ctx context.Context, raw []byte) (*domain.Order, error) {
 	// Строгое декодирование: запрещаем неизвестные поля.
 	var order domain.Order
 	dec := json.NewDecoder(bytes.NewReader(raw))
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(&order); err != nil {
 		s.log.Warnf(ctx, "invalid json err=%v", err)
-		return fmt.Errorf("invalid json: %w", err)
+		return nil, fmt.Errorf("%w: invalid json: %w", validate.ErrInvalidOrder, err)
 	}
 
 	// Убеждаемся, что после объекта нет лишних данных.
 	if err := dec.Decode(new(struct{})); err != io.EOF {
 		s.log.Warnf(ctx, "invalid json: trailing data")
-		return fmt.Errorf("invalid json: trailing data")
+		return nil, fmt.Errorf("%w: invalid json: trailing data", validate.ErrInvalidOrder)
 	}
 
 	// Доменная валидация (обязательные поля, корректность email, суммы и т.д.).
 	if err := s.validator.Validate(ctx, &order); err != nil {
 		s.log.Warnf(ctx, "validation failed order_uid=%s err=%v", order.OrderUID, err)
-		return fmt.Errorf("validation failed: %w", err)
-	}
-
-	// Сохранение в БД в транзакции.
-	if err := s.repo.Save(ctx, &order); err != nil {
-		s.log.Errorf(ctx, "repo.Save failed order_uid=%s err=%v", order.OrderUID, err)
-		return fmt.Errorf("failed to save order: %w", err)
-	}
-
-	// Обновление кэша.
-	if err := s.cache.Set(ctx, &order); err != nil {
-		s.log.Warnf(ctx, "cache.Set failed order_uid=%s err=%v", order.OrderUID, err)
+		return nil, fmt.Errorf("validation failed: %w", err)
 	}
-
-	s.log.Infof(ctx, "order saved uid=%s items=%d", order.OrderUID, len(order.Items))
-	return nil
+	return &order, nil
 }
 
 // WarmUpCache — прогрев кэша последними N заказами из БД.
 // Если n <= 0, прогрев не выполняется (но это не ошибка).
-func (s *OrderService) WarmUpCache(ctx context.Context, n int) error {
+func (s *OrderService) WarmUpCache(ctx context.Context, n int) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, tracerName, "OrderService.WarmUpCache", attrWarmUpN.Int(n))
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	if n <= 0 {
 		s.log.Warnf(ctx, "cache warm-up skipped: n <= 0 (n=%d)", n)
 		return nil
@@ -128,6 +327,7 @@ func (s *OrderService) WarmUpCache(ctx context.Context, n int) error {
 		s.log.Errorf(ctx, "repo.LastN failed n=%d err=%v", n, err)
 		return err
 	}
+	span.SetAttributes(attrOrders.Int(len(list)))
 	if warmUpErr := s.cache.WarmUp(ctx, list); warmUpErr != nil {
 		s.log.Warnf(ctx, "cache.WarmUp failed err=%v", warmUpErr)
 	}
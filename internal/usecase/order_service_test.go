@@ -5,13 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Gunvolt24/wb_l0/internal/cache/memory"
 	"github.com/Gunvolt24/wb_l0/internal/domain"
 	"github.com/Gunvolt24/wb_l0/internal/ports/mocks"
 	"github.com/Gunvolt24/wb_l0/internal/usecase"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
 	"github.com/Gunvolt24/wb_l0/pkg/validate"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 const orderUID = "order-1"
@@ -77,8 +82,8 @@ func TestSaveFromMessage_InvalidJson(t *testing.T) {
 	svc := usecase.NewOrderService(repo, cache, log, validator)
 
 	err := svc.SaveFromMessage(context.Background(), []byte("{"))
-	if err == nil || !strings.Contains(err.Error(), "invalid json") {
-		t.Fatalf("expected invalid json error, got err=%v", err)
+	if err == nil || !strings.Contains(err.Error(), "invalid json") || !errors.Is(err, validate.ErrInvalidOrder) {
+		t.Fatalf("expected permanent invalid json error, got err=%v", err)
 	}
 }
 
@@ -175,7 +180,7 @@ func TestGetOrder_CacheMiss_RepoError(t *testing.T) {
 	}
 }
 
-func TestGetOrder_CacheMiss_NotFound_NoCache(t *testing.T) {
+func TestGetOrder_CacheMiss_NotFound_CachesNegative(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
 	repo := mocks.NewMockOrderRepository(ctrl)
@@ -186,6 +191,7 @@ func TestGetOrder_CacheMiss_NotFound_NoCache(t *testing.T) {
 	cache.EXPECT().Get(gomock.Any(), orderUID).Return(nil, false)
 	repo.EXPECT().GetByUID(gomock.Any(), orderUID).Return(nil, nil)
 	cache.EXPECT().Set(gomock.Any(), gomock.Any()).Times(0)
+	cache.EXPECT().SetNegative(gomock.Any(), orderUID).Return(nil)
 
 	svc := usecase.NewOrderService(repo, cache, log, validator)
 	got, err := svc.GetOrder(context.Background(), orderUID)
@@ -217,6 +223,171 @@ func TestGetOrder_CacheMiss_CacheSetWarnOnly(t *testing.T) {
 	}
 }
 
+// N конкурентных промахов по одному UID — один запрос в репозиторий, остальные получают его результат.
+func TestGetOrder_ConcurrentMisses_SingleLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl)
+	cache := mocks.NewMockOrderCache(ctrl)
+	validator := mocks.NewMockOrderValidator(ctrl)
+
+	const n = 16
+	o := &domain.Order{OrderUID: orderUID}
+
+	var missed sync.WaitGroup
+	missed.Add(n)
+	loading := make(chan struct{})
+	release := make(chan struct{})
+
+	cache.EXPECT().Get(gomock.Any(), orderUID).Times(n).DoAndReturn(
+		func(context.Context, string) (*domain.Order, bool) {
+			missed.Done()
+			return nil, false
+		})
+	repo.EXPECT().GetByUID(gomock.Any(), orderUID).Times(1).DoAndReturn(
+		func(context.Context, string) (*domain.Order, error) {
+			close(loading)
+			<-release
+			return o, nil
+		})
+	cache.EXPECT().Set(gomock.Any(), o).Times(1)
+
+	svc := usecase.NewOrderService(repo, cache, noopLogger{}, validator)
+	dedupBefore := testutil.ToFloat64(metrics.CacheLoadsDeduplicated)
+
+	results := make(chan *domain.Order, n)
+	errs := make(chan error, n)
+	for range n {
+		go func() {
+			got, err := svc.GetOrder(context.Background(), orderUID)
+			results <- got
+			errs <- err
+		}()
+	}
+
+	// Все запросы промахнулись и загрузка идёт; даём им дойти до ожидания общего результата.
+	<-loading
+	missed.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for range n {
+		if err := <-errs; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := <-results; got != o {
+			t.Fatalf("want shared order, got %+v", got)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.CacheLoadsDeduplicated) - dedupBefore; got != n-1 {
+		t.Fatalf("deduplicated loads: want %d, got %v", n-1, got)
+	}
+}
+
+// Отмена запроса, начавшего загрузку, не прерывает её для остальных ожидающих.
+func TestGetOrder_SharedLoad_SurvivesCallerCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl)
+	cache := mocks.NewMockOrderCache(ctrl)
+	validator := mocks.NewMockOrderValidator(ctrl)
+
+	o := &domain.Order{OrderUID: orderUID}
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	secondMissed := make(chan struct{})
+
+	gomock.InOrder(
+		cache.EXPECT().Get(gomock.Any(), orderUID).Return(nil, false),
+		cache.EXPECT().Get(gomock.Any(), orderUID).DoAndReturn(
+			func(context.Context, string) (*domain.Order, bool) {
+				close(secondMissed)
+				return nil, false
+			}),
+	)
+	repo.EXPECT().GetByUID(gomock.Any(), orderUID).Times(1).DoAndReturn(
+		func(ctx context.Context, _ string) (*domain.Order, error) {
+			close(loading)
+			<-release
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return o, nil
+		})
+	cache.EXPECT().Set(gomock.Any(), o).Times(1)
+
+	svc := usecase.NewOrderService(repo, cache, noopLogger{}, validator)
+
+	firstCtx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := svc.GetOrder(firstCtx, orderUID)
+		firstErr <- err
+	}()
+	<-loading
+
+	secondRes := make(chan *domain.Order, 1)
+	go func() {
+		got, err := svc.GetOrder(context.Background(), orderUID)
+		if err != nil {
+			t.Errorf("second caller: unexpected error: %v", err)
+		}
+		secondRes <- got
+	}()
+	<-secondMissed
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller: want context.Canceled, got %v", err)
+	}
+
+	close(release)
+	if got := <-secondRes; got != o {
+		t.Fatalf("second caller: want shared order, got %+v", got)
+	}
+}
+
+// Негативная запись отвечает без обращения к БД; пришедший из Kafka заказ её заменяет.
+func TestGetOrder_NegativeEntry_ReplacedBySave(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl)
+	validator := mocks.NewMockOrderValidator(ctrl)
+	cache := memory.NewLRUCacheTTL(10, 0, time.Minute, time.Minute)
+
+	o := &domain.Order{OrderUID: orderUID, TrackNumber: "track-1", Entry: "entry-1"}
+	raw, err := json.Marshal(o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repo.EXPECT().GetByUID(gomock.Any(), orderUID).Return(nil, nil).Times(1)
+	validator.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+	svc := usecase.NewOrderService(repo, cache, noopLogger{}, validator)
+	negBefore := testutil.ToFloat64(metrics.CacheOps.WithLabelValues("miss_negative"))
+
+	for range 3 {
+		got, err := svc.GetOrder(context.Background(), orderUID)
+		if err != nil || got != nil {
+			t.Fatalf("want not found, got order=%+v err=%v", got, err)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.CacheOps.WithLabelValues("miss_negative")) - negBefore; got != 2 {
+		t.Fatalf("miss_negative: want 2, got %v", got)
+	}
+
+	if err := svc.SaveFromMessage(context.Background(), raw); err != nil {
+		t.Fatalf("SaveFromMessage: %v", err)
+	}
+	got, err := svc.GetOrder(context.Background(), orderUID)
+	if err != nil || got == nil || got.TrackNumber != "track-1" {
+		t.Fatalf("want saved order from cache, got order=%+v err=%v", got, err)
+	}
+}
+
 func TestSaveFromMessage_TrailingData(t *testing.T) {
 	ctrl := gomock.NewController(t)
 
@@ -244,8 +415,8 @@ func TestSaveFromMessage_TrailingData(t *testing.T) {
 
 	svc := usecase.NewOrderService(repo, cache, log, validator)
 	err2 := svc.SaveFromMessage(context.Background(), raw)
-	if err2 == nil || !strings.Contains(err2.Error(), "trailing data") {
-		t.Fatalf("want trailing data error, got %v", err2)
+	if err2 == nil || !strings.Contains(err2.Error(), "trailing data") || !errors.Is(err2, validate.ErrInvalidOrder) {
+		t.Fatalf("want permanent trailing data error, got %v", err2)
 	}
 }
 
@@ -335,3 +506,141 @@ func TestOrdersByCustomer_Proxy(t *testing.T) {
 		t.Fatalf("unexpected result: %+v, err=%v", got, err)
 	}
 }
+
+func TestOrdersByCustomerPage_NextCursorRoundTrip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl)
+	cache := mocks.NewMockOrderCache(ctrl)
+	log := noopLogger{}
+	val := mocks.NewMockOrderValidator(ctrl)
+
+	t0 := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	a := &domain.Order{OrderUID: "a", DateCreated: t0.Add(2 * time.Minute)}
+	b := &domain.Order{OrderUID: "b", DateCreated: t0.Add(time.Minute)}
+	c := &domain.Order{OrderUID: "c", DateCreated: t0}
+
+	// 1-я страница: запрашиваем limit+1; лишний заказ отбрасывается и даёт next_cursor
+	repo.EXPECT().ListByCustomerAfter(gomock.Any(), "cust-1", (*domain.OrderCursor)(nil), 3).
+		Return([]*domain.Order{a, b, c}, nil)
+	// 2-я страница: курсор указывает на последний отданный заказ (b)
+	repo.EXPECT().ListByCustomerAfter(gomock.Any(), "cust-1",
+		&domain.OrderCursor{DateCreated: b.DateCreated, OrderUID: "b"}, 3).
+		Return([]*domain.Order{c}, nil)
+
+	svc := usecase.NewOrderService(repo, cache, log, val)
+
+	page, err := svc.OrdersByCustomerPage(context.Background(), "cust-1", "", 2)
+	if err != nil || len(page.Orders) != 2 || page.Orders[1].OrderUID != "b" || page.NextCursor == "" {
+		t.Fatalf("unexpected first page: %+v, err=%v", page, err)
+	}
+
+	page, err = svc.OrdersByCustomerPage(context.Background(), "cust-1", page.NextCursor, 2)
+	if err != nil || len(page.Orders) != 1 || page.Orders[0].OrderUID != "c" || page.NextCursor != "" {
+		t.Fatalf("unexpected last page: %+v, err=%v", page, err)
+	}
+}
+
+func TestOrdersByCustomerPage_InvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl) // в репозиторий не ходим
+	cache := mocks.NewMockOrderCache(ctrl)
+	log := noopLogger{}
+	val := mocks.NewMockOrderValidator(ctrl)
+
+	svc := usecase.NewOrderService(repo, cache, log, val)
+	for _, cursor := range []string{"%%%", "bm90LWpzb24", "e30"} { // не base64, не JSON, пустой объект
+		if _, err := svc.OrdersByCustomerPage(context.Background(), "cust-1", cursor, 10); !errors.Is(err, domain.ErrInvalidCursor) {
+			t.Fatalf("cursor %q: want ErrInvalidCursor, got %v", cursor, err)
+		}
+	}
+}
+
+func TestSearchOrders_ValidatesAndPaginates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl)
+	cache := mocks.NewMockOrderCache(ctrl)
+	log := noopLogger{}
+	val := mocks.NewMockOrderValidator(ctrl)
+
+	svc := usecase.NewOrderService(repo, cache, log, val)
+
+	// Пустой фильтр — ошибка без обращения к репозиторию
+	if _, err := svc.SearchOrders(context.Background(), domain.OrderFilter{}, "", 10); !errors.Is(err, domain.ErrInvalidFilter) {
+		t.Fatalf("want ErrInvalidFilter, got %v", err)
+	}
+
+	filter := domain.OrderFilter{Phone: "+9720000000"}
+	repo.EXPECT().Search(gomock.Any(), filter, (*domain.OrderCursor)(nil), 2).
+		Return([]*domain.Order{{OrderUID: "a"}, {OrderUID: "b"}}, nil)
+
+	page, err := svc.SearchOrders(context.Background(), filter, "", 1)
+	if err != nil || len(page.Orders) != 1 || page.Orders[0].OrderUID != "a" || page.NextCursor == "" {
+		t.Fatalf("unexpected page: %+v, err=%v", page, err)
+	}
+}
+
+func TestSaveBatchFromMessages_InvalidSkipped_ValidSavedTogether(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl)
+	cache := mocks.NewMockOrderCache(ctrl)
+	log := noopLogger{}
+	validator := mocks.NewMockOrderValidator(ctrl)
+
+	raw1, err := json.Marshal(&domain.Order{OrderUID: "order-1", CustomerID: "c1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw2, err := json.Marshal(&domain.Order{OrderUID: "order-2", CustomerID: "c1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	validator.EXPECT().Validate(gomock.Any(), gomock.AssignableToTypeOf(&domain.Order{})).Return(nil).Times(2)
+	repo.EXPECT().SaveBatch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, orders []*domain.Order) error {
+			if len(orders) != 2 || orders[0].OrderUID != "order-1" || orders[1].OrderUID != "order-2" {
+				t.Fatalf("unexpected batch: %+v", orders)
+			}
+			return nil
+		})
+	cache.EXPECT().Set(gomock.Any(), gomock.AssignableToTypeOf(&domain.Order{})).Return(nil).Times(2)
+
+	svc := usecase.NewOrderService(repo, cache, log, validator)
+
+	results, err := svc.SaveBatchFromMessages(context.Background(), [][]byte{raw1, []byte("{bad"), raw2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[0] != nil || results[1] == nil || results[2] != nil {
+		t.Fatalf("unexpected results: %v", results)
+	}
+}
+
+func TestSaveBatchFromMessages_RepoErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl)
+	cache := mocks.NewMockOrderCache(ctrl)
+	log := noopLogger{}
+	validator := mocks.NewMockOrderValidator(ctrl)
+
+	raw, err := json.Marshal(&domain.Order{OrderUID: orderUID, CustomerID: "c1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	validator.EXPECT().Validate(gomock.Any(), gomock.AssignableToTypeOf(&domain.Order{})).Return(nil)
+	repo.EXPECT().SaveBatch(gomock.Any(), gomock.Any()).Return(errors.New("tx aborted"))
+	cache.EXPECT().Set(gomock.Any(), gomock.Any()).Times(0)
+
+	svc := usecase.NewOrderService(repo, cache, log, validator)
+
+	_, err = svc.SaveBatchFromMessages(context.Background(), [][]byte{raw})
+	if err == nil || !strings.Contains(err.Error(), "failed to save batch") {
+		t.Fatalf("want wrapped batch error, got %v", err)
+	}
+}
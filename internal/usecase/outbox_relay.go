@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+)
+
+// Заголовки публикуемых событий outbox.
+const (
+	HeaderEventType  = "x-event-type"  // order.saved | order.updated
+	HeaderEventID    = "x-event-id"    // id записи outbox (для дедупликации на стороне получателя)
+	HeaderOccurredAt = "x-occurred-at" // момент записи события (RFC3339Nano, UTC)
+)
+
+// outboxPruneInterval — как часто relay удаляет отправленные события старше retention.
+const outboxPruneInterval = time.Minute
+
+// OutboxRelay — публикует события outbox в брокер (at-least-once):
+// событие помечается отправленным только после подтверждения публикации,
+// поэтому при сбое между Publish и MarkSent возможен дубль (дедупликация — по x-event-id).
+// Пачка захватывается на время lease, поэтому relay на нескольких репликах не публикуют одно и то же.
+type OutboxRelay struct {
+	repo      ports.OutboxRepository
+	producer  ports.MessageProducer
+	log       ports.Logger
+	interval  time.Duration // пауза опроса, когда неотправленных событий нет
+	batchSize int           // событий за одну итерацию
+	lease     time.Duration // аренда захваченной пачки (после неё события снова доступны)
+	retention time.Duration // срок хранения отправленных событий (0 — не удалять)
+}
+
+// NewOutboxRelay — DI-конструктор. Если lease <= 0, ставим дефолт 30s.
+func NewOutboxRelay(
+	repo ports.OutboxRepository,
+	producer ports.MessageProducer,
+	log ports.Logger,
+	interval time.Duration,
+	batchSize int,
+	lease time.Duration,
+	retention time.Duration,
+) *OutboxRelay {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if lease <= 0 {
+		lease = 30 * time.Second
+	}
+	return &OutboxRelay{
+		repo:      repo,
+		producer:  producer,
+		log:       log,
+		interval:  interval,
+		batchSize: batchSize,
+		lease:     lease,
+		retention: retention,
+	}
+}
+
+// Run — цикл публикации до отмены контекста. Полная пачка — сразу следующая итерация,
+// иначе ждём interval. Раз в outboxPruneInterval удаляются отправленные события старше retention.
+// Ошибки логируются, цикл продолжается.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	r.log.Infof(ctx, "outbox relay started interval=%s batch=%d lease=%s retention=%s",
+		r.interval, r.batchSize, r.lease, r.retention)
+
+	var lastPrune time.Time
+	for {
+		if r.retention > 0 && time.Since(lastPrune) >= outboxPruneInterval {
+			lastPrune = time.Now()
+			if _, err := r.PruneOnce(ctx); err != nil && ctx.Err() == nil {
+				r.log.Warnf(ctx, "outbox prune: %v", err)
+			}
+		}
+
+		n, err := r.RelayOnce(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			r.log.Warnf(ctx, "outbox relay: %v (will retry in %s)", err, r.interval)
+		}
+		if err == nil && n == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+// RelayOnce — одна итерация: захватить пачку неотправленных событий, опубликовать, пометить отправленными.
+// Возвращает число опубликованных событий.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	events, err := r.repo.ClaimUnsent(ctx, r.batchSize, r.lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim outbox: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	msgs := make([]ports.OutgoingMessage, 0, len(events))
+	ids := make([]int64, 0, len(events))
+	for i := range events {
+		msgs = append(msgs, outboxMessage(&events[i]))
+		ids = append(ids, events[i].ID)
+	}
+
+	if err := r.producer.Publish(ctx, msgs...); err != nil {
+		metrics.OutboxPublishFailures.Inc()
+		return 0, fmt.Errorf("publish outbox events=%d: %w", len(events), err)
+	}
+
+	// Сбой здесь приведёт к повторной публикации — это допустимо для at-least-once.
+	if err := r.repo.MarkSent(ctx, ids); err != nil {
+		return 0, fmt.Errorf("mark outbox sent: %w", err)
+	}
+
+	metrics.OutboxEventsPublished.Add(float64(len(events)))
+	return len(events), nil
+}
+
+// PruneOnce — удалить отправленные события старше retention (пачками по batchSize, пока они есть).
+// Возвращает число удалённых событий; при retention <= 0 ничего не делает.
+func (r *OutboxRelay) PruneOnce(ctx context.Context) (int64, error) {
+	if r.retention <= 0 {
+		return 0, nil
+	}
+	var total int64
+	for {
+		n, err := r.repo.PruneSent(ctx, r.retention, r.batchSize)
+		total += n
+		if err != nil {
+			return total, fmt.Errorf("prune outbox: %w", err)
+		}
+		if n < int64(r.batchSize) {
+			if total > 0 {
+				r.log.Infof(ctx, "outbox pruned events=%d older_than=%s", total, r.retention)
+			}
+			return total, nil
+		}
+	}
+}
+
+// outboxMessage — событие outbox → сообщение брокера (ключ — order_uid).
+func outboxMessage(event *domain.OutboxEvent) ports.OutgoingMessage {
+	return ports.OutgoingMessage{
+		Key:   []byte(event.AggregateID),
+		Value: event.Payload,
+		Headers: map[string]string{
+			HeaderEventType:  event.EventType,
+			HeaderEventID:    strconv.FormatInt(event.ID, 10),
+			HeaderOccurredAt: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		},
+	}
+}
@@ -0,0 +1,124 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/ports"
+	"github.com/Gunvolt24/wb_l0/internal/ports/mocks"
+	"github.com/Gunvolt24/wb_l0/internal/usecase"
+)
+
+func TestRelayOnce_PublishesAndMarksSent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	producer := mocks.NewMockMessageProducer(ctrl)
+
+	createdAt := time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC)
+	events := []domain.OutboxEvent{
+		{ID: 1, AggregateID: "order-1", EventType: domain.EventOrderSaved, Payload: []byte(`{"order_uid":"order-1"}`), CreatedAt: createdAt},
+		{ID: 2, AggregateID: "order-1", EventType: domain.EventOrderUpdated, Payload: []byte(`{"order_uid":"order-1"}`), CreatedAt: createdAt},
+	}
+
+	gomock.InOrder(
+		repo.EXPECT().ClaimUnsent(gomock.Any(), 10, 30*time.Second).Return(events, nil),
+		producer.EXPECT().Publish(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, msgs ...ports.OutgoingMessage) error {
+				if len(msgs) != 2 || string(msgs[0].Key) != "order-1" {
+					t.Fatalf("unexpected messages: %+v", msgs)
+				}
+				if msgs[0].Headers[usecase.HeaderEventType] != domain.EventOrderSaved ||
+					msgs[1].Headers[usecase.HeaderEventType] != domain.EventOrderUpdated ||
+					msgs[1].Headers[usecase.HeaderEventID] != "2" ||
+					msgs[0].Headers[usecase.HeaderOccurredAt] != "2025-08-20T10:00:00Z" {
+					t.Fatalf("unexpected headers: %+v", msgs)
+				}
+				return nil
+			}),
+		repo.EXPECT().MarkSent(gomock.Any(), []int64{1, 2}).Return(nil),
+	)
+
+	relay := usecase.NewOutboxRelay(repo, producer, noopLogger{}, time.Second, 10, 0, 0)
+
+	n, err := relay.RelayOnce(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("want 2 events relayed, got n=%d err=%v", n, err)
+	}
+}
+
+func TestRelayOnce_PublishFailed_NotMarked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	producer := mocks.NewMockMessageProducer(ctrl)
+
+	repo.EXPECT().ClaimUnsent(gomock.Any(), 10, 30*time.Second).
+		Return([]domain.OutboxEvent{{ID: 1, AggregateID: "order-1", EventType: domain.EventOrderSaved}}, nil)
+	producer.EXPECT().Publish(gomock.Any(), gomock.Any()).Return(errors.New("broker unavailable"))
+	repo.EXPECT().MarkSent(gomock.Any(), gomock.Any()).Times(0)
+
+	relay := usecase.NewOutboxRelay(repo, producer, noopLogger{}, time.Second, 10, 0, 0)
+
+	if _, err := relay.RelayOnce(context.Background()); err == nil {
+		t.Fatal("want publish error, got nil")
+	}
+}
+
+func TestRelayRun_StopsOnCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	producer := mocks.NewMockMessageProducer(ctrl)
+
+	repo.EXPECT().ClaimUnsent(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).MinTimes(1)
+
+	relay := usecase.NewOutboxRelay(repo, producer, noopLogger{}, 5*time.Millisecond, 10, time.Second, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	if err := relay.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// Отправленные события удаляются пачками, пока удаляется полная пачка
+func TestPruneOnce_DeletesInBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	producer := mocks.NewMockMessageProducer(ctrl)
+
+	gomock.InOrder(
+		repo.EXPECT().PruneSent(gomock.Any(), 24*time.Hour, 10).Return(int64(10), nil),
+		repo.EXPECT().PruneSent(gomock.Any(), 24*time.Hour, 10).Return(int64(3), nil),
+	)
+
+	relay := usecase.NewOutboxRelay(repo, producer, noopLogger{}, time.Second, 10, time.Second, 24*time.Hour)
+
+	n, err := relay.PruneOnce(context.Background())
+	if err != nil || n != 13 {
+		t.Fatalf("want 13 pruned, got n=%d err=%v", n, err)
+	}
+}
+
+// Без retention отправленные события не удаляются
+func TestPruneOnce_RetentionDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	producer := mocks.NewMockMessageProducer(ctrl)
+	repo.EXPECT().PruneSent(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	relay := usecase.NewOutboxRelay(repo, producer, noopLogger{}, time.Second, 10, time.Second, 0)
+
+	if n, err := relay.PruneOnce(context.Background()); err != nil || n != 0 {
+		t.Fatalf("want no pruning, got n=%d err=%v", n, err)
+	}
+}
@@ -0,0 +1,69 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/Gunvolt24/wb_l0/internal/domain"
+	"github.com/Gunvolt24/wb_l0/internal/ports/mocks"
+	"github.com/Gunvolt24/wb_l0/internal/usecase"
+)
+
+// setupSpanRecorder — подменяет глобальный провайдер на запись спанов в память.
+func setupSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestGetOrder_SpanRecordsCacheHitOrMiss(t *testing.T) {
+	recorder := setupSpanRecorder(t)
+	ctrl := gomock.NewController(t)
+
+	repo := mocks.NewMockOrderRepository(ctrl)
+	cache := mocks.NewMockOrderCache(ctrl)
+	validator := mocks.NewMockOrderValidator(ctrl)
+
+	order := &domain.Order{OrderUID: orderUID}
+	gomock.InOrder(
+		cache.EXPECT().Get(gomock.Any(), orderUID).Return(order, true),
+		cache.EXPECT().Get(gomock.Any(), "order-2").Return(nil, false),
+		repo.EXPECT().GetByUID(gomock.Any(), "order-2").Return(nil, nil),
+		cache.EXPECT().SetNegative(gomock.Any(), "order-2").Return(nil),
+	)
+
+	svc := usecase.NewOrderService(repo, cache, noopLogger{}, validator)
+	if _, err := svc.GetOrder(context.Background(), orderUID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := svc.GetOrder(context.Background(), "order-2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, got %d", len(spans))
+	}
+	for i, wantHit := range []string{"true", "false"} {
+		if spans[i].Name() != "OrderService.GetOrder" {
+			t.Fatalf("unexpected span name %q", spans[i].Name())
+		}
+		var hit string
+		for _, kv := range spans[i].Attributes() {
+			if kv.Key == "cache.hit" {
+				hit = kv.Value.Emit()
+			}
+		}
+		if hit != wantHit {
+			t.Fatalf("span %d: cache.hit=%q, want %q", i, hit, wantHit)
+		}
+	}
+}
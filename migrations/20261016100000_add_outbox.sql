@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Transactional outbox: события пишутся в одной транзакции с заказом,
+-- relay публикует их в Kafka и проставляет sent_at.
+CREATE TABLE IF NOT EXISTS outbox (
+  id           BIGSERIAL   PRIMARY KEY,
+  aggregate_id TEXT        NOT NULL,  -- order_uid
+  event_type   TEXT        NOT NULL,  -- order.saved | order.updated
+  payload      JSONB       NOT NULL,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  sent_at      TIMESTAMPTZ
+);
+
+-- выборка неотправленных событий в порядке записи
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent ON outbox (id) WHERE sent_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_unsent;
+DROP TABLE IF EXISTS outbox;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Индексы для поиска заказов (GET /orders/search). track_number и rid уже
+-- покрыты idx_orders_track_number / idx_items_rid, дата — idx_orders_date_created.
+CREATE INDEX IF NOT EXISTS idx_deliveries_email_lower ON deliveries (lower(email));
+CREATE INDEX IF NOT EXISTS idx_deliveries_phone       ON deliveries (phone);
+CREATE INDEX IF NOT EXISTS idx_items_brand            ON items (brand);
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_service_date
+  ON orders (delivery_service, date_created DESC, order_uid DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_orders_delivery_service_date;
+DROP INDEX IF EXISTS idx_items_brand;
+DROP INDEX IF EXISTS idx_deliveries_phone;
+DROP INDEX IF EXISTS idx_deliveries_email_lower;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Полнотекстовый поиск по позициям заказа (GET /items/search): вычисляемый tsvector
+-- по name (вес A) и brand (вес B) + GIN-индекс. Конфигурация 'simple' — без стемминга,
+-- т.к. названия и бренды смешивают языки; нечёткость даёт префиксный поиск (word:*).
+ALTER TABLE items
+  ADD COLUMN IF NOT EXISTS search_tsv tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(brand, '')), 'B')
+  ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_items_search_tsv ON items USING GIN (search_tsv);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_items_search_tsv;
+ALTER TABLE items DROP COLUMN IF EXISTS search_tsv;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Аренда событий outbox: relay захватывает пачку до claimed_until,
+-- чтобы реплики не публиковали одни и те же события.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ;
+
+-- удаление отправленных событий по сроку хранения
+CREATE INDEX IF NOT EXISTS idx_outbox_sent_at ON outbox (sent_at) WHERE sent_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_sent_at;
+ALTER TABLE outbox DROP COLUMN IF EXISTS claimed_until;
+-- +goose StatementEnd
@@ -0,0 +1,127 @@
+// Package health — проверки живости и готовности сервиса (liveness/readiness) для HTTP-проб.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Статусы проверок и отчёта.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// shutdownCheck — встроенная проверка: остановка ещё не началась.
+const shutdownCheck = "shutdown"
+
+// ErrDraining — сервис останавливается и не должен получать новый трафик.
+var ErrDraining = errors.New("shutdown in progress")
+
+// Check — проверка зависимости: nil — зависимость готова.
+type Check func(ctx context.Context) error
+
+// Result — результат одной проверки.
+type Result struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report — отчёт о готовности: общий статус (fail, если упала хотя бы одна проверка) и результаты по именам.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// OK — все проверки прошли.
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+// namedCheck — зарегистрированная проверка.
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker — набор проверок готовности сервиса и признак начавшейся остановки.
+type Checker struct {
+	timeout time.Duration // ограничение на одну проверку
+
+	mu       sync.RWMutex
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// NewChecker — DI-конструктор. Если timeout <= 0, ставим дефолт 2s.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add — зарегистрировать проверку под именем name (имя попадает в отчёт).
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetDraining — отметить начало остановки: с этого момента сервис не готов.
+func (c *Checker) SetDraining() { c.draining.Store(true) }
+
+// Draining — остановка уже началась.
+func (c *Checker) Draining() bool { return c.draining.Load() }
+
+// Ready — выполнить все проверки параллельно (каждую — не дольше timeout) и собрать отчёт.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, nc.check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks)+1)}
+	for i, nc := range checks {
+		report.Checks[nc.name] = results[i]
+	}
+	shutdown := Result{Status: StatusOK}
+	if c.Draining() {
+		shutdown = Result{Status: StatusFail, Error: ErrDraining.Error()}
+	}
+	report.Checks[shutdownCheck] = shutdown
+
+	for _, r := range report.Checks {
+		if r.Status != StatusOK {
+			report.Status = StatusFail
+			break
+		}
+	}
+	return report
+}
+
+// run — одна проверка с таймаутом.
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	res := Result{Status: StatusOK, DurationMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	return res
+}
@@ -0,0 +1,64 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/pkg/health"
+)
+
+func TestChecker_AllOK(t *testing.T) {
+	c := health.NewChecker(time.Second)
+	c.Add("db", func(context.Context) error { return nil })
+
+	r := c.Ready(context.Background())
+	if !r.OK() || r.Checks["db"].Status != health.StatusOK || r.Checks["shutdown"].Status != health.StatusOK {
+		t.Fatalf("want ready report, got %+v", r)
+	}
+}
+
+func TestChecker_FailedCheck(t *testing.T) {
+	c := health.NewChecker(time.Second)
+	c.Add("db", func(context.Context) error { return nil })
+	c.Add("kafka", func(context.Context) error { return errors.New("no brokers") })
+
+	r := c.Ready(context.Background())
+	if r.OK() || r.Status != health.StatusFail {
+		t.Fatalf("one failed check must fail the report: %+v", r)
+	}
+	if got := r.Checks["kafka"]; got.Status != health.StatusFail || got.Error != "no brokers" {
+		t.Fatalf("unexpected kafka result: %+v", got)
+	}
+	if r.Checks["db"].Status != health.StatusOK {
+		t.Fatalf("db must stay ok: %+v", r.Checks["db"])
+	}
+}
+
+func TestChecker_TimeoutBoundsSlowCheck(t *testing.T) {
+	c := health.NewChecker(20 * time.Millisecond)
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	r := c.Ready(context.Background())
+	if r.OK() || r.Checks["slow"].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("slow check must fail by timeout: %+v", r)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Ready must not wait longer than the timeout, took %s", elapsed)
+	}
+}
+
+func TestChecker_Draining(t *testing.T) {
+	c := health.NewChecker(time.Second)
+	c.SetDraining()
+
+	r := c.Ready(context.Background())
+	if r.OK() || r.Checks["shutdown"].Error != health.ErrDraining.Error() {
+		t.Fatalf("draining checker must not be ready: %+v", r)
+	}
+}
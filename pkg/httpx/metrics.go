@@ -0,0 +1,55 @@
+package httpx
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute — лейбл route для запросов без маршрута (404): путь в лейбл не пишем,
+// иначе произвольные URL раздуют число временных рядов.
+const unmatchedRoute = "unmatched"
+
+// RequestMetrics — middleware RED-метрик: число запросов, запросы в работе, время обработки и размер ответа
+// по шаблону маршрута, методу и классу статуса. Ставить первым, до Recovery: тогда паника учитывается как 5xx.
+func RequestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		labels := []string{route, methodLabel(c.Request.Method), statusClass(c.Writer.Status())}
+
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		metrics.HTTPResponseSize.WithLabelValues(labels...).Observe(float64(max(c.Writer.Size(), 0)))
+	}
+}
+
+// methodLabel — стандартные методы как есть, остальные — "other" (ограничиваем кардинальность).
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "other"
+	}
+}
+
+// statusClass — класс статуса: 200 → "2xx", 404 → "4xx".
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "other"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
@@ -0,0 +1,55 @@
+package httpx_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gunvolt24/wb_l0/pkg/httpx"
+	"github.com/Gunvolt24/wb_l0/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRequestMetrics_LabelsByRouteMethodAndStatusClass(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(httpx.RequestMetrics(), gin.RecoveryWithWriter(io.Discard))
+	r.GET("/metrics-test/:id", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	r.GET("/metrics-test-panic", func(*gin.Context) { panic("boom") })
+
+	ok := metrics.HTTPRequests.WithLabelValues("/metrics-test/:id", "GET", "2xx")
+	panicked := metrics.HTTPRequests.WithLabelValues("/metrics-test-panic", "GET", "5xx")
+	unmatched := metrics.HTTPRequests.WithLabelValues("unmatched", "other", "4xx")
+	okBefore, panicBefore, unmatchedBefore := testutil.ToFloat64(ok), testutil.ToFloat64(panicked), testutil.ToFloat64(unmatched)
+	sizeSeries := testutil.CollectAndCount(metrics.HTTPResponseSize)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/metrics-test/1", http.NoBody),
+		httptest.NewRequest(http.MethodGet, "/metrics-test/2", http.NoBody),
+		httptest.NewRequest(http.MethodGet, "/metrics-test-panic", http.NoBody),
+		httptest.NewRequest("PROPFIND", "/no/such/route", http.NoBody),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Путь с параметром учитывается шаблоном маршрута, а не конкретным URL.
+	if got := testutil.ToFloat64(ok) - okBefore; got != 2 {
+		t.Fatalf("route template requests: want 2, got %v", got)
+	}
+	// Паника, перехваченная Recovery, — 5xx.
+	if got := testutil.ToFloat64(panicked) - panicBefore; got != 1 {
+		t.Fatalf("panic must be counted as 5xx, got %v", got)
+	}
+	// Неизвестный маршрут и нестандартный метод не создают новых значений лейблов.
+	if got := testutil.ToFloat64(unmatched) - unmatchedBefore; got != 1 {
+		t.Fatalf("unmatched route must be counted as route=unmatched method=other, got %v", got)
+	}
+	if got := testutil.CollectAndCount(metrics.HTTPResponseSize) - sizeSeries; got != 3 {
+		t.Fatalf("response size must be observed per route/method/status, new series=%d", got)
+	}
+	if got := testutil.ToFloat64(metrics.HTTPRequestsInFlight); got != 0 {
+		t.Fatalf("in-flight gauge must return to 0, got %v", got)
+	}
+}
@@ -14,9 +14,9 @@ func RequestLogger(log ports.Logger) gin.HandlerFunc {
 		start := time.Now()
 		c.Next()
 
-		// не логируем /metrics, /ping
+		// не логируем /metrics и пробы
 		switch c.FullPath() {
-		case "/metrics", "/ping":
+		case "/metrics", "/ping", "/healthz", "/readyz":
 			return
 		}
 
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -38,16 +39,98 @@ var KafkaMessagesFailed = prometheus.NewCounterVec(
 	[]string{"topic"},
 )
 
+// KafkaMessagesDeadLettered — количество сообщений, отправленных в dead-letter топик.
+var KafkaMessagesDeadLettered = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "kafka_messages_dead_lettered_total",
+		Help: "Number of rejected messages published to the dead-letter topic",
+	},
+	[]string{"topic"},
+)
+
+// KafkaMessageAttempts — количество попыток обработки сообщений (включая первую).
+var KafkaMessageAttempts = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "kafka_message_attempts_total",
+		Help: "Number of message processing attempts, including the first one",
+	},
+	[]string{"topic"},
+)
+
+// KafkaMessagesParked — количество сообщений, перенесённых в parking-топик после исчерпания попыток.
+var KafkaMessagesParked = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "kafka_messages_parked_total",
+		Help: "Number of messages moved to the parking topic after exhausting attempts",
+	},
+	[]string{"topic"},
+)
+
+// KafkaWorkersBusy — число воркеров, занятых обработкой сообщения (режим пула воркеров).
+var KafkaWorkersBusy = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "kafka_workers_busy",
+		Help: "Number of consumer workers currently processing a message",
+	},
+	[]string{"topic"},
+)
+
+// KafkaWorkerQueueDepth — число сообщений, ожидающих в очередях воркеров.
+var KafkaWorkerQueueDepth = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "kafka_worker_queue_depth",
+		Help: "Number of fetched messages waiting in consumer worker queues",
+	},
+	[]string{"topic"},
+)
+
+// KafkaProcessingDuration — время одной попытки обработки сообщения или транзакции пакета;
+// каждая попытка — отдельное наблюдение, паузы backoff между повторами не учитываются.
+// Лейбл "mode": message | batch (транзакция пакета в пакетном режиме).
+var KafkaProcessingDuration = newKafkaProcessingDuration(prometheus.DefBuckets)
+
+func newKafkaProcessingDuration(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kafka_processing_duration_seconds",
+			Help:    "Time of a single processing attempt of a Kafka message or batch (each retry is observed separately, backoff excluded)",
+			Buckets: buckets,
+		},
+		[]string{"topic", "mode"}, // mode: message|batch
+	)
+}
+
+// -------------- Outbox --------------
+
+// OutboxEventsPublished — количество событий outbox, опубликованных в брокер.
+var OutboxEventsPublished = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "outbox_events_published_total",
+		Help: "Number of outbox events published to the broker",
+	},
+)
+
+// OutboxPublishFailures — количество неудачных попыток публикации пачки событий outbox.
+var OutboxPublishFailures = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "outbox_publish_failures_total",
+		Help: "Number of failed outbox batch publish attempts",
+	},
+)
+
 // -------------- Cache --------------
 
 // CacheOps — счётчик операций кэша.
-// Лейбл "op" принимает ограниченный набор значений: hit|miss|evicted|expired.
+// Лейбл "op" принимает ограниченный набор значений: hit|miss|miss_negative|evicted|expired|too_large|error
+// (miss_negative — попадание в негативную запись: заказа нет в БД;
+// too_large — заказ больше бюджета памяти in-memory кэша и не закэширован;
+// error — сбой внешнего кэша: недоступен Redis или значение не декодируется).
 var CacheOps = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
 		Name: "cache_operations_total",
 		Help: "Cache operations",
 	},
-	[]string{"op"}, // hit|miss|evicted|expired
+	[]string{"op"}, // hit|miss|miss_negative|evicted|expired|too_large|error
 )
 
 // CacheSize — текущий размер кэша (количество элементов).
@@ -58,9 +141,150 @@ var CacheSize = prometheus.NewGauge(
 	},
 )
 
+// CacheBytes — оценка памяти, занятой in-memory кэшем (байты; сумма по всем экземплярам/шардам).
+var CacheBytes = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "cache_bytes",
+		Help: "Estimated memory used by in-memory cache entries, in bytes",
+	},
+)
+
+// CacheTierLookups — результат чтения двухуровневого кэша: l1 | l2 | miss.
+var CacheTierLookups = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_tier_lookups_total",
+		Help: "Two-tier cache lookups by the level that served them",
+	},
+	[]string{"tier"}, // l1|l2|miss
+)
+
+// CacheInvalidations — уведомления об изменении заказов, полученные для сброса локального кэша.
+var CacheInvalidations = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "cache_invalidations_total",
+		Help: "Order change notifications applied to the local cache",
+	},
+)
+
+// CacheLoadsDeduplicated — промахи кэша, получившие заказ из уже идущей загрузки
+// того же UID вместо собственного запроса в БД.
+var CacheLoadsDeduplicated = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "cache_loads_deduplicated_total",
+		Help: "Cache misses served by an in-flight database load of the same order",
+	},
+)
+
+// -------------- HTTP --------------
+
+// HTTPRequests — количество обработанных запросов публичного API.
+// Лейблы: route — шаблон маршрута (c.FullPath(), "unmatched" для неизвестных), method, status — класс (2xx, 4xx, ...).
+var HTTPRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests by route, method and status class",
+	},
+	[]string{"route", "method", "status"},
+)
+
+// HTTPRequestsInFlight — запросы, обрабатываемые прямо сейчас.
+var HTTPRequestsInFlight = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served",
+	},
+)
+
+// HTTPRequestDuration — время обработки запроса (лейблы — как у HTTPRequests).
+var HTTPRequestDuration = newHTTPRequestDuration(prometheus.DefBuckets)
+
+func newHTTPRequestDuration(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route, method and status class",
+			Buckets: buckets,
+		},
+		[]string{"route", "method", "status"},
+	)
+}
+
+// DefSizeBuckets — границы гистограммы размера ответа по умолчанию: 100B … 10MB.
+var DefSizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+
+// HTTPResponseSize — размер тела ответа (лейблы — как у HTTPRequests).
+var HTTPResponseSize = newHTTPResponseSize(DefSizeBuckets)
+
+func newHTTPResponseSize(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "HTTP response body size by route, method and status class",
+			Buckets: buckets,
+		},
+		[]string{"route", "method", "status"},
+	)
+}
+
+// -------------- Postgres --------------
+
+// DBQueryDuration — время SQL-запроса репозитория (для SELECT — до закрытия rows).
+// Лейблы: operation — первое слово запроса (SELECT, INSERT, COPY, ...), status — ok | error.
+var DBQueryDuration = newDBQueryDuration(prometheus.DefBuckets)
+
+func newDBQueryDuration(buckets []float64) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Repository SQL query latency by operation and status",
+			Buckets: buckets,
+		},
+		[]string{"operation", "status"}, // status: ok|error
+	)
+}
+
+// ConfigureBuckets — границы гистограмм: duration (секунды) — для HTTP, Kafka и SQL, size (байты) — для размера
+// ответа. Пустой срез — границы по умолчанию; границы должны строго возрастать. Вызывать до MustRegister
+// и до начала обработки: гистограммы пересоздаются, наблюдения, сделанные раньше, теряются.
+func ConfigureBuckets(duration, size []float64) error {
+	if err := checkBuckets(duration); err != nil {
+		return fmt.Errorf("duration buckets: %w", err)
+	}
+	if err := checkBuckets(size); err != nil {
+		return fmt.Errorf("size buckets: %w", err)
+	}
+	if len(duration) > 0 {
+		HTTPRequestDuration = newHTTPRequestDuration(duration)
+		KafkaProcessingDuration = newKafkaProcessingDuration(duration)
+		DBQueryDuration = newDBQueryDuration(duration)
+	}
+	if len(size) > 0 {
+		HTTPResponseSize = newHTTPResponseSize(size)
+	}
+	return nil
+}
+
+// checkBuckets — границы строго возрастают (иначе prometheus паникует при создании гистограммы).
+func checkBuckets(buckets []float64) error {
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("must be in increasing order: %v", buckets)
+		}
+	}
+	return nil
+}
+
 // MustRegister — регистрирует метрики.
 func MustRegister() {
 	registerOnce.Do(func() {
-		prometheus.MustRegister(KafkaMessagesConsumed, KafkaMessagesProcessed, KafkaMessagesFailed, CacheOps, CacheSize)
+		prometheus.MustRegister(
+			KafkaMessagesConsumed, KafkaMessagesProcessed, KafkaMessagesFailed, KafkaMessagesDeadLettered,
+			KafkaMessageAttempts, KafkaMessagesParked, KafkaWorkersBusy, KafkaWorkerQueueDepth,
+			KafkaProcessingDuration,
+			OutboxEventsPublished, OutboxPublishFailures,
+			CacheOps, CacheSize, CacheBytes, CacheTierLookups, CacheInvalidations, CacheLoadsDeduplicated,
+			HTTPRequests, HTTPRequestsInFlight, HTTPRequestDuration, HTTPResponseSize,
+			DBQueryDuration,
+		)
 	})
 }
@@ -68,3 +68,20 @@ func TestCacheSize_GaugeSet(t *testing.T) {
 		t.Fatalf("CacheSize restore: got=%v want=%v", got, cur)
 	}
 }
+
+func TestConfigureBuckets(t *testing.T) {
+	duration, size := metrics.HTTPRequestDuration, metrics.HTTPResponseSize
+
+	if err := metrics.ConfigureBuckets([]float64{1, 0.5}, nil); err == nil {
+		t.Fatalf("unsorted duration buckets must be rejected")
+	}
+	if err := metrics.ConfigureBuckets(nil, []float64{100, 100}); err == nil {
+		t.Fatalf("duplicate size buckets must be rejected")
+	}
+	if err := metrics.ConfigureBuckets(nil, nil); err != nil {
+		t.Fatalf("empty buckets must keep defaults: %v", err)
+	}
+	if metrics.HTTPRequestDuration != duration || metrics.HTTPResponseSize != size {
+		t.Fatalf("histograms must not be replaced on error or empty buckets")
+	}
+}